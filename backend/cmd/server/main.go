@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/whk-newbie/blog/internal/config"
@@ -113,7 +117,10 @@ func main() {
 	}
 
 	// 初始化路由
-	r, schedulerManager := router.Setup(cfg)
+	r, schedulerManager, visitBuffer := router.Setup(cfg)
+
+	// 启动访问记录写入协程
+	visitBuffer.Start()
 
 	// 启动调度器
 	if err := schedulerManager.Start(); err != nil {
@@ -125,9 +132,15 @@ func main() {
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	logger.Info("Starting server on %s", addr)
 
+	// 不设置读写超时（与r.Run一致），备份下载、结果导出和附件上传可能超过配置的超时时间
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: r,
+	}
+
 	// 优雅关闭
 	go func() {
-		if err := r.Run(addr); err != nil {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Fatal("Failed to start server: %v", err)
 		}
 	}()
@@ -139,7 +152,24 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// 停止接收新请求，等待处理中的请求结束
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Server shutdown: %v", err)
+	}
+	cancel()
+
 	// 停止调度器
 	schedulerManager.Stop()
 	logger.Info("Scheduler stopped")
+
+	// 写完队列中的访问记录
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.Visit.DrainTimeout)
+	defer cancel()
+	if err := visitBuffer.Stop(drainCtx); err != nil {
+		stats := visitBuffer.Stats()
+		logger.Error("Visit queue not fully drained: %v (%d visits still queued)", err, stats.QueueLength)
+	} else {
+		logger.Info("Visit queue drained")
+	}
 }
@@ -10,14 +10,20 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Redis    RedisConfig    `yaml:"redis"`
-	JWT      JWTConfig      `yaml:"jwt"`
-	Crypto   CryptoConfig   `yaml:"crypto"`
-	Upload   UploadConfig   `yaml:"upload"`
-	Log      LogConfig      `yaml:"log"`
-	CORS     CORSConfig     `yaml:"cors"`
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Redis     RedisConfig     `yaml:"redis"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	Crypto    CryptoConfig    `yaml:"crypto"`
+	Upload    UploadConfig    `yaml:"upload"`
+	Log       LogConfig       `yaml:"log"`
+	CORS      CORSConfig      `yaml:"cors"`
+	Site      SiteConfig      `yaml:"site"`
+	Search    SearchConfig    `yaml:"search"`
+	Crawler   CrawlerConfig   `yaml:"crawler"`
+	Visit     VisitConfig     `yaml:"visit"`
+	GeoIP     GeoIPConfig     `yaml:"geoip"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
 }
 
 // ServerConfig 服务器配置
@@ -58,9 +64,10 @@ type RedisConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret     string        `yaml:"secret"`
-	ExpireTime time.Duration `yaml:"expire_time"`
-	Issuer     string        `yaml:"issuer"`
+	Secret            string        `yaml:"secret"`
+	ExpireTime        time.Duration `yaml:"expire_time"`         // 访问Token有效期
+	RefreshExpireTime time.Duration `yaml:"refresh_expire_time"` // 刷新Token有效期（为空时默认7天）
+	Issuer            string        `yaml:"issuer"`
 }
 
 // CryptoConfig 加密配置
@@ -70,10 +77,34 @@ type CryptoConfig struct {
 
 // UploadConfig 上传配置
 type UploadConfig struct {
-	Path          string   `yaml:"path"`
-	MaxSize       int64    `yaml:"max_size"`        // 单位：字节
-	AllowedTypes  []string `yaml:"allowed_types"`   // 允许的文件类型
-	CompressQuality int    `yaml:"compress_quality"` // 图片压缩质量 1-100
+	Storage         string           `yaml:"storage"`          // 存储类型：local（默认）或 s3
+	Path            string           `yaml:"path"`             // 本地存储目录
+	MaxSize         int64            `yaml:"max_size"`         // 图片最大大小，单位：字节
+	AllowedTypes    []string         `yaml:"allowed_types"`    // 允许的图片类型（MIME类型，按文件内容识别）
+	CompressQuality int              `yaml:"compress_quality"` // 图片压缩质量 1-100
+	VariantWidths   []int            `yaml:"variant_widths"`   // 上传时生成的图片宽度变体，同时作为 /img/{宽度}/ 缩放接口的宽度白名单
+	ImageCacheDir   string           `yaml:"image_cache_dir"`  // 缩放图片的磁盘缓存目录
+	KeepCopyright   bool             `yaml:"keep_copyright"`   // 去除图片元数据时保留作者和版权信息（EXIF Artist/Copyright）
+	S3              S3Config         `yaml:"s3"`               // S3兼容对象存储配置（storage为s3时使用）
+	Attachment      AttachmentConfig `yaml:"attachment"`       // 文章附件（PDF、压缩包等）上传策略
+}
+
+// AttachmentConfig 附件上传配置
+type AttachmentConfig struct {
+	MaxSize      int64    `yaml:"max_size"`      // 单位：字节
+	AllowedTypes []string `yaml:"allowed_types"` // 允许的文件类型（MIME类型，按文件内容识别）
+}
+
+// S3Config S3兼容对象存储配置
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint"`   // 服务地址，如 https://s3.amazonaws.com、http://minio:9000
+	Region    string `yaml:"region"`     // 区域，为空时使用 us-east-1
+	Bucket    string `yaml:"bucket"`     // 存储桶
+	AccessKey string `yaml:"access_key"` // 访问密钥ID
+	SecretKey string `yaml:"secret_key"` // 访问密钥
+	PathStyle bool   `yaml:"path_style"` // 使用路径风格访问（MinIO等自建服务需要开启）
+	Prefix    string `yaml:"prefix"`     // 对象key前缀
+	PublicURL string `yaml:"public_url"` // 对外访问地址（存储桶公开读或CDN），为空时由服务端代理读取
 }
 
 // LogConfig 日志配置
@@ -98,6 +129,54 @@ type CORSConfig struct {
 	MaxAge           int      `yaml:"max_age"`
 }
 
+// SiteConfig 站点配置
+type SiteConfig struct {
+	URL      string `yaml:"url"`      // 站点对外访问地址（用于生成RSS等绝对链接，为空时使用请求的Host）
+	Language string `yaml:"language"` // 站点语言，如 zh-CN
+}
+
+// SearchConfig 全文检索配置
+type SearchConfig struct {
+	Tokenizer string `yaml:"tokenizer"` // 分词器：cjk_bigram（默认）/simple，更换后需重建检索分词
+}
+
+// CrawlerConfig 爬虫任务配置
+type CrawlerConfig struct {
+	StaleTimeout time.Duration `yaml:"stale_timeout"` // 运行中的任务超过该时长没有心跳则标记为失败（任务注册时可单独指定，默认10分钟）
+	ImportAuthor string        `yaml:"import_author"` // 爬虫注册的导入映射规则没有由管理员指定作者时，导入文章使用的作者（管理员用户名）
+}
+
+// VisitConfig 访问记录写入配置
+// 访问记录先进入内存队列，由后台协程攒批写入数据库
+type VisitConfig struct {
+	QueueSize     int           `yaml:"queue_size"`     // 队列容量，队列已满时拒绝新的访问记录（默认10000）
+	BatchSize     int           `yaml:"batch_size"`     // 每批最多写入条数（默认500）
+	FlushInterval time.Duration `yaml:"flush_interval"` // 未攒满一批时的最长等待时间（默认2秒）
+	Workers       int           `yaml:"workers"`        // 写入协程数（默认2）
+	DrainTimeout  time.Duration `yaml:"drain_timeout"`  // 关闭服务时等待队列写完的最长时间（默认15秒）
+	SessionGap    time.Duration `yaml:"session_gap"`    // 同一指纹两次访问间隔超过该时长视为新的会话（默认30分钟）
+	TruncateIP    bool          `yaml:"truncate_ip"`    // 是否截断写入的客户端IP（IPv4保留前24位，IPv6保留前48位），归属地仍按完整IP解析
+}
+
+// GeoIPConfig 离线IP归属地配置
+type GeoIPConfig struct {
+	DBPath   string `yaml:"db_path"`  // MaxMind格式（mmdb）的City或Country数据库文件路径，为空时不解析访问地区
+	Language string `yaml:"language"` // 地名语言（默认zh-CN，数据库中没有该语言时使用英文）
+}
+
+// SchedulerConfig 定时任务配置
+// cron表达式带秒字段，为空或为0时使用默认值
+type SchedulerConfig struct {
+	LogRetentionDays     int           `yaml:"log_retention_days"`     // 系统日志保留天数（默认90天）
+	BackupSchedule       string        `yaml:"backup_schedule"`        // 数据备份（默认每天凌晨3点）
+	BackupRetentionCount int           `yaml:"backup_retention_count"` // 保留的备份数量（默认10个）
+	SitemapSchedule      string        `yaml:"sitemap_schedule"`       // 重建Sitemap（默认每小时整点）
+	MediaSchedule        string        `yaml:"media_schedule"`         // 扫描媒体引用（默认每天凌晨4点）
+	RemoveOrphanMedia    bool          `yaml:"remove_orphan_media"`    // 是否自动删除未引用的文件（默认只报告）
+	OrphanMediaMinAge    time.Duration `yaml:"orphan_media_min_age"`   // 上传超过该时长的未引用文件才会删除（默认7天）
+	VisitRollupSchedule  string        `yaml:"visit_rollup_schedule"`  // 汇总前一天的访问统计（默认每天凌晨0点30分）
+}
+
 // Load 加载配置文件
 func Load() (*Config, error) {
 	// 获取配置文件路径
@@ -168,6 +247,27 @@ func overrideFromEnv(cfg *Config) {
 	if masterKey := os.Getenv("CRYPTO_MASTER_KEY"); masterKey != "" {
 		cfg.Crypto.MasterKey = masterKey
 	}
+
+	// 上传存储配置
+	if storage := os.Getenv("UPLOAD_STORAGE"); storage != "" {
+		cfg.Upload.Storage = storage
+	}
+	if accessKey := os.Getenv("S3_ACCESS_KEY"); accessKey != "" {
+		cfg.Upload.S3.AccessKey = accessKey
+	}
+	if secretKey := os.Getenv("S3_SECRET_KEY"); secretKey != "" {
+		cfg.Upload.S3.SecretKey = secretKey
+	}
+
+	// 站点配置
+	if siteURL := os.Getenv("SITE_URL"); siteURL != "" {
+		cfg.Site.URL = siteURL
+	}
+
+	// GeoIP配置
+	if dbPath := os.Getenv("GEOIP_DB_PATH"); dbPath != "" {
+		cfg.GeoIP.DBPath = dbPath
+	}
 }
 
 // validate 验证配置
@@ -176,6 +276,9 @@ func validate(cfg *Config) error {
 	if cfg.JWT.Secret == "" {
 		return fmt.Errorf("JWT secret is required")
 	}
+	if cfg.JWT.RefreshExpireTime <= 0 {
+		cfg.JWT.RefreshExpireTime = 7 * 24 * time.Hour
+	}
 
 	// 验证加密主密钥
 	if cfg.Crypto.MasterKey == "" {
@@ -185,6 +288,63 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("crypto master key must be 32 bytes")
 	}
 
+	// 验证上传存储配置
+	if cfg.Upload.Path == "" {
+		cfg.Upload.Path = "./uploads"
+	}
+	if len(cfg.Upload.VariantWidths) == 0 {
+		cfg.Upload.VariantWidths = []int{320, 640, 1280}
+	}
+	if cfg.Upload.ImageCacheDir == "" {
+		cfg.Upload.ImageCacheDir = "./cache/images"
+	}
+	if cfg.Upload.MaxSize <= 0 {
+		cfg.Upload.MaxSize = 10 * 1024 * 1024
+	}
+	if len(cfg.Upload.AllowedTypes) == 0 {
+		cfg.Upload.AllowedTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+	}
+	if cfg.Upload.Attachment.MaxSize <= 0 {
+		cfg.Upload.Attachment.MaxSize = 20 * 1024 * 1024
+	}
+	if len(cfg.Upload.Attachment.AllowedTypes) == 0 {
+		cfg.Upload.Attachment.AllowedTypes = []string{"application/pdf", "application/zip"}
+	}
+	switch cfg.Upload.Storage {
+	case "", "local":
+	case "s3":
+		if cfg.Upload.S3.Endpoint == "" || cfg.Upload.S3.Bucket == "" {
+			return fmt.Errorf("s3 endpoint and bucket are required")
+		}
+	default:
+		return fmt.Errorf("unknown upload storage: %s", cfg.Upload.Storage)
+	}
+
+	// 爬虫任务配置
+	if cfg.Crawler.StaleTimeout <= 0 {
+		cfg.Crawler.StaleTimeout = 10 * time.Minute
+	}
+
+	// 访问记录写入配置
+	if cfg.Visit.QueueSize <= 0 {
+		cfg.Visit.QueueSize = 10000
+	}
+	if cfg.Visit.BatchSize <= 0 {
+		cfg.Visit.BatchSize = 500
+	}
+	if cfg.Visit.FlushInterval <= 0 {
+		cfg.Visit.FlushInterval = 2 * time.Second
+	}
+	if cfg.Visit.Workers <= 0 {
+		cfg.Visit.Workers = 2
+	}
+	if cfg.Visit.DrainTimeout <= 0 {
+		cfg.Visit.DrainTimeout = 15 * time.Second
+	}
+	if cfg.Visit.SessionGap <= 0 {
+		cfg.Visit.SessionGap = 30 * time.Minute
+	}
+
 	// 验证数据库配置
 	if cfg.Database.Host == "" {
 		return fmt.Errorf("database host is required")
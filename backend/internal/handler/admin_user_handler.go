@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// AdminUserHandler 后台账号管理处理器
+type AdminUserHandler struct {
+	adminUserService service.AdminUserService
+}
+
+// NewAdminUserHandler 创建后台账号管理处理器
+func NewAdminUserHandler(adminUserService service.AdminUserService) *AdminUserHandler {
+	return &AdminUserHandler{
+		adminUserService: adminUserService,
+	}
+}
+
+// List 获取账号列表
+// @Summary 获取后台账号列表
+// @Description 分页获取后台账号（仅站长可用）
+// @Tags 账号管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=service.AdminUserListResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/users [get]
+func (h *AdminUserHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	result, err := h.adminUserService.List(page, pageSize)
+	if err != nil {
+		response.InternalServerError(c, "获取账号列表失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Create 创建账号
+// @Summary 创建后台账号
+// @Description 创建后台账号并分配角色（owner/editor/author/viewer），仅站长可用
+// @Tags 账号管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body service.CreateAdminUserRequest true "账号信息"
+// @Success 200 {object} response.Response{data=models.Admin} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 409 {object} response.Response "用户名已存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/users [post]
+func (h *AdminUserHandler) Create(c *gin.Context) {
+	var req service.CreateAdminUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	admin, err := h.adminUserService.Create(&req)
+	if err != nil {
+		h.handleError(c, err, "创建账号失败")
+		return
+	}
+
+	response.Created(c, "创建成功", admin)
+}
+
+// Update 更新账号
+// @Summary 更新后台账号
+// @Description 修改账号邮箱或角色；不能修改自己的角色，也不能降级最后一个站长
+// @Tags 账号管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Param body body service.UpdateAdminUserRequest true "账号信息"
+// @Success 200 {object} response.Response{data=models.Admin} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "账号不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/users/{id} [put]
+func (h *AdminUserHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的账号ID")
+		return
+	}
+
+	var req service.UpdateAdminUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	admin, err := h.adminUserService.Update(uint(id), c.GetUint("userID"), &req)
+	if err != nil {
+		h.handleError(c, err, "更新账号失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "更新成功", admin)
+}
+
+// ResetPassword 重置账号密码
+// @Summary 重置后台账号密码
+// @Description 由站长为指定账号设置新密码，该账号的会话全部失效，下次登录后需要修改密码
+// @Tags 账号管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Param body body service.ResetAdminPasswordRequest true "新密码"
+// @Success 200 {object} response.Response "重置成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "账号不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/users/{id}/password [put]
+func (h *AdminUserHandler) ResetPassword(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的账号ID")
+		return
+	}
+
+	var req service.ResetAdminPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.adminUserService.ResetPassword(uint(id), req.Password); err != nil {
+		h.handleError(c, err, "重置密码失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "重置成功", nil)
+}
+
+// Delete 删除账号
+// @Summary 删除后台账号
+// @Description 删除指定账号；不能删除自己，也不能删除最后一个站长
+// @Tags 账号管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "账号ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "账号不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/users/{id} [delete]
+func (h *AdminUserHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的账号ID")
+		return
+	}
+
+	if err := h.adminUserService.Delete(uint(id), c.GetUint("userID")); err != nil {
+		h.handleError(c, err, "删除账号失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// handleError 统一处理账号管理错误
+func (h *AdminUserHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrAdminNotFound:
+		response.NotFound(c, "账号不存在")
+	case service.ErrAdminExists:
+		response.Error(c, 409, "用户名已存在")
+	case service.ErrInvalidAdminRole:
+		response.BadRequest(c, "无效的角色")
+	case service.ErrUsernameRequired:
+		response.BadRequest(c, "用户名不能为空")
+	case service.ErrPasswordTooShort:
+		response.BadRequest(c, "密码长度至少为6位")
+	case service.ErrCannotDeleteSelf:
+		response.BadRequest(c, "不能删除当前登录的账号")
+	case service.ErrCannotDemoteSelf:
+		response.BadRequest(c, "不能修改当前登录账号的角色")
+	case service.ErrLastOwnerRequired:
+		response.BadRequest(c, "至少需要保留一个站长账号")
+	default:
+		response.InternalServerError(c, message+": "+err.Error())
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/rbac"
 	"github.com/whk-newbie/blog/internal/pkg/response"
 	"github.com/whk-newbie/blog/internal/service"
 )
@@ -23,7 +24,7 @@ func NewArticleHandler(articleService service.ArticleService) *ArticleHandler {
 
 // Create 创建文章
 // @Summary 创建文章
-// @Description 创建新的文章
+// @Description 创建新的文章（content_format为markdown时提交content_source，由服务端渲染为HTML；正文均按白名单清洗）
 // @Tags 文章管理
 // @Accept json
 // @Produce json
@@ -46,6 +47,14 @@ func (h *ArticleHandler) Create(c *gin.Context) {
 
 	article, err := h.articleService.Create(&req, userID.(uint))
 	if err != nil {
+		if err == service.ErrArticleContentRequired || err == service.ErrInvalidContentFormat {
+			response.BadRequest(c, "正文不能为空或格式不支持")
+			return
+		}
+		if err == service.ErrArticleSourceExists {
+			response.BadRequest(c, "该来源URL的文章已存在")
+			return
+		}
 		response.InternalServerError(c, "创建文章失败: "+err.Error())
 		return
 	}
@@ -93,7 +102,7 @@ func (h *ArticleHandler) GetByID(c *gin.Context) {
 
 // GetBySlug 根据Slug获取文章
 // @Summary 根据Slug获取文章
-// @Description 根据Slug获取文章详细信息（公开接口）
+// @Description 根据Slug获取文章详细信息（公开接口），toc 为由正文标题生成的目录
 // @Tags 文章管理
 // @Accept json
 // @Produce json
@@ -139,6 +148,7 @@ func (h *ArticleHandler) GetBySlug(c *gin.Context) {
 // @Success 200 {object} response.Response "更新成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权操作该文章"
 // @Failure 404 {object} response.Response "文章不存在"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /admin/articles/{id} [put]
@@ -150,6 +160,10 @@ func (h *ArticleHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if !authorizeArticle(c, h.articleService, uint(id)) {
+		return
+	}
+
 	var req service.UpdateArticleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "请求参数错误: "+err.Error())
@@ -162,6 +176,10 @@ func (h *ArticleHandler) Update(c *gin.Context) {
 			response.NotFound(c, "文章不存在")
 			return
 		}
+		if err == service.ErrArticleContentRequired || err == service.ErrInvalidContentFormat {
+			response.BadRequest(c, "正文不能为空或格式不支持")
+			return
+		}
 		response.InternalServerError(c, "更新文章失败: "+err.Error())
 		return
 	}
@@ -180,6 +198,7 @@ func (h *ArticleHandler) Update(c *gin.Context) {
 // @Success 200 {object} response.Response "删除成功"
 // @Failure 400 {object} response.Response "参数错误"
 // @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权操作该文章"
 // @Failure 404 {object} response.Response "文章不存在"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /admin/articles/{id} [delete]
@@ -191,6 +210,10 @@ func (h *ArticleHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	if !authorizeArticle(c, h.articleService, uint(id)) {
+		return
+	}
+
 	err = h.articleService.Delete(uint(id))
 	if err != nil {
 		if err == service.ErrArticleNotFound {
@@ -346,6 +369,7 @@ func (h *ArticleHandler) ListPublished(c *gin.Context) {
 // @Success 200 {object} response.Response "发布成功"
 // @Failure 400 {object} response.Response "参数错误"
 // @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权操作该文章"
 // @Failure 404 {object} response.Response "文章不存在"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /admin/articles/{id}/publish [post]
@@ -357,6 +381,10 @@ func (h *ArticleHandler) Publish(c *gin.Context) {
 		return
 	}
 
+	if !authorizeArticle(c, h.articleService, uint(id)) {
+		return
+	}
+
 	err = h.articleService.Publish(uint(id))
 	if err != nil {
 		if err == service.ErrArticleNotFound {
@@ -381,6 +409,7 @@ func (h *ArticleHandler) Publish(c *gin.Context) {
 // @Success 200 {object} response.Response "操作成功"
 // @Failure 400 {object} response.Response "参数错误"
 // @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权操作该文章"
 // @Failure 404 {object} response.Response "文章不存在"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /admin/articles/{id}/unpublish [post]
@@ -392,6 +421,10 @@ func (h *ArticleHandler) Unpublish(c *gin.Context) {
 		return
 	}
 
+	if !authorizeArticle(c, h.articleService, uint(id)) {
+		return
+	}
+
 	err = h.articleService.Unpublish(uint(id))
 	if err != nil {
 		if err == service.ErrArticleNotFound {
@@ -407,7 +440,7 @@ func (h *ArticleHandler) Unpublish(c *gin.Context) {
 
 // Search 搜索文章
 // @Summary 搜索文章
-// @Description 全文搜索已发布的文章
+// @Description 全文搜索已发布的文章，按相关度排序；每条结果的 highlight 为命中片段（已转义，命中词以<mark>包裹）
 // @Tags 文章管理
 // @Accept json
 // @Produce json
@@ -436,3 +469,53 @@ func (h *ArticleHandler) Search(c *gin.Context) {
 
 	response.Success(c, result)
 }
+
+// RebuildSearchIndex 重建全文检索分词
+// @Summary 重建全文检索分词
+// @Description 使用当前配置的分词器重新生成文章的检索分词；更换分词器后应传 all=true 全量重建，否则只处理尚未分词的文章
+// @Tags 文章管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param all query bool false "是否全量重建" default(false)
+// @Success 200 {object} response.Response "重建成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/articles/search-index/rebuild [post]
+func (h *ArticleHandler) RebuildSearchIndex(c *gin.Context) {
+	all := c.Query("all") == "true"
+
+	count, err := h.articleService.RebuildSearchIndex(all)
+	if err != nil {
+		response.InternalServerError(c, "重建检索分词失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "重建成功", gin.H{
+		"count": count,
+	})
+}
+
+// authorizeArticle 校验当前账号能否修改文章（作者只能操作自己的文章）
+// 不允许时已写入响应，调用方直接返回即可
+func authorizeArticle(c *gin.Context, articleService service.ArticleService, id uint) bool {
+	if !rbac.OwnsArticleOnly(models.AdminRole(c.GetString("role"))) {
+		return true
+	}
+
+	article, err := articleService.GetByID(id)
+	if err != nil {
+		if err == service.ErrArticleNotFound {
+			response.NotFound(c, "文章不存在")
+			return false
+		}
+		response.InternalServerError(c, "获取文章失败: "+err.Error())
+		return false
+	}
+
+	if article.AuthorID == nil || *article.AuthorID != c.GetUint("userID") {
+		response.Forbidden(c, "只能操作自己的文章")
+		return false
+	}
+	return true
+}
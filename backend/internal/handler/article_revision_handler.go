@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// ArticleRevisionHandler 文章修订处理器
+type ArticleRevisionHandler struct {
+	revisionService service.ArticleRevisionService
+	articleService  service.ArticleService
+}
+
+// NewArticleRevisionHandler 创建文章修订处理器
+func NewArticleRevisionHandler(revisionService service.ArticleRevisionService, articleService service.ArticleService) *ArticleRevisionHandler {
+	return &ArticleRevisionHandler{
+		revisionService: revisionService,
+		articleService:  articleService,
+	}
+}
+
+// List 获取文章修订列表
+// @Summary 获取文章修订列表
+// @Description 获取指定文章的修订历史（按版本倒序，不包含正文）
+// @Tags 文章修订
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=service.ArticleRevisionListResponse} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "文章不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/articles/{id}/revisions [get]
+func (h *ArticleRevisionHandler) List(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的文章ID")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	result, err := h.revisionService.List(uint(articleID), page, pageSize)
+	if err != nil {
+		if err == service.ErrArticleNotFound {
+			response.NotFound(c, "文章不存在")
+			return
+		}
+		response.InternalServerError(c, "获取修订列表失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetByID 获取修订详情
+// @Summary 获取修订详情
+// @Description 获取指定修订的完整快照（包含正文）
+// @Tags 文章修订
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param revision_id path int true "修订ID"
+// @Success 200 {object} response.Response{data=models.ArticleRevision} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "修订不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/articles/{id}/revisions/{revision_id} [get]
+func (h *ArticleRevisionHandler) GetByID(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的文章ID")
+		return
+	}
+
+	revisionID, err := strconv.ParseUint(c.Param("revision_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的修订ID")
+		return
+	}
+
+	revision, err := h.revisionService.GetByID(uint(articleID), uint(revisionID))
+	if err != nil {
+		if err == service.ErrRevisionNotFound {
+			response.NotFound(c, "修订不存在")
+			return
+		}
+		response.InternalServerError(c, "获取修订失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, revision)
+}
+
+// Diff 比较两个修订
+// @Summary 比较两个修订
+// @Description 比较同一文章的两个修订，返回字段变化和正文逐行差异
+// @Tags 文章修订
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param from query int true "起始修订ID"
+// @Param to query int true "目标修订ID"
+// @Success 200 {object} response.Response{data=service.ArticleRevisionDiffResponse} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "修订不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/articles/{id}/revisions/diff [get]
+func (h *ArticleRevisionHandler) Diff(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的文章ID")
+		return
+	}
+
+	fromID, err := strconv.ParseUint(c.Query("from"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的起始修订ID")
+		return
+	}
+
+	toID, err := strconv.ParseUint(c.Query("to"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的目标修订ID")
+		return
+	}
+
+	result, err := h.revisionService.Diff(uint(articleID), uint(fromID), uint(toID))
+	if err != nil {
+		if err == service.ErrRevisionNotFound {
+			response.NotFound(c, "修订不存在")
+			return
+		}
+		response.InternalServerError(c, "比较修订失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Restore 恢复到指定修订
+// @Summary 恢复到指定修订
+// @Description 将文章内容恢复到指定修订（保留当前发布状态），恢复操作会生成新的修订
+// @Tags 文章修订
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "文章ID"
+// @Param revision_id path int true "修订ID"
+// @Success 200 {object} response.Response{data=models.Article} "恢复成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权操作该文章"
+// @Failure 404 {object} response.Response "文章或修订不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/articles/{id}/revisions/{revision_id}/restore [post]
+func (h *ArticleRevisionHandler) Restore(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的文章ID")
+		return
+	}
+
+	revisionID, err := strconv.ParseUint(c.Param("revision_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的修订ID")
+		return
+	}
+
+	if !authorizeArticle(c, h.articleService, uint(articleID)) {
+		return
+	}
+
+	article, err := h.revisionService.Restore(uint(articleID), uint(revisionID))
+	if err != nil {
+		switch err {
+		case service.ErrArticleNotFound:
+			response.NotFound(c, "文章不存在")
+		case service.ErrRevisionNotFound:
+			response.NotFound(c, "修订不存在")
+		default:
+			response.InternalServerError(c, "恢复修订失败: "+err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "恢复成功", article)
+}
@@ -1,6 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	"github.com/whk-newbie/blog/internal/pkg/response"
 	"github.com/whk-newbie/blog/internal/service"
@@ -24,6 +29,27 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required" example:"123456"`
 }
 
+// LoginTwoFactorRequest 两步登录请求
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required" example:"123456"` // TOTP验证码或恢复码
+}
+
+// RefreshTokenRequest 刷新Token请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// EnableTwoFactorRequest 启用两步验证请求
+type EnableTwoFactorRequest struct {
+	Code string `json:"code" binding:"required,len=6" example:"123456"`
+}
+
+// ConfirmPasswordRequest 确认密码请求
+type ConfirmPasswordRequest struct {
+	Password string `json:"password" binding:"required" example:"123456"`
+}
+
 // ChangePasswordRequest 修改密码请求
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required" example:"123456"`
@@ -40,6 +66,7 @@ type ChangePasswordRequest struct {
 // @Success 200 {object} response.Response "登录成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "用户名或密码错误"
+// @Failure 429 {object} response.Response "失败次数过多，暂时锁定"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -49,22 +76,69 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	loginResp, err := h.authService.Login(req.Username, req.Password)
+	loginResp, err := h.authService.Login(req.Username, req.Password, c.ClientIP())
 	if err != nil {
 		if err == service.ErrInvalidCredentials {
 			response.Unauthorized(c, "用户名或密码错误")
 			return
 		}
+		if respondLoginLocked(c, err) {
+			return
+		}
 		response.InternalServerError(c, "登录失败: "+err.Error())
 		return
 	}
 
+	if loginResp.TwoFactorRequired {
+		response.SuccessWithMessage(c, "请输入两步验证码", loginResp)
+		return
+	}
+
+	response.SuccessWithMessage(c, "登录成功", loginResp)
+}
+
+// LoginTwoFactor 两步登录
+// @Summary 两步验证登录
+// @Description 使用登录接口返回的challenge_token和身份验证器App中的6位验证码（或恢复码）完成登录
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param body body LoginTwoFactorRequest true "验证信息"
+// @Success 200 {object} response.Response "登录成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "验证码错误或挑战已失效"
+// @Failure 429 {object} response.Response "失败次数过多，暂时锁定"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /auth/login/2fa [post]
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	loginResp, err := h.authService.LoginTwoFactor(req.ChallengeToken, req.Code, c.ClientIP())
+	if err != nil {
+		if respondLoginLocked(c, err) {
+			return
+		}
+		switch err {
+		case service.ErrInvalidChallenge:
+			response.Unauthorized(c, "登录验证已失效，请重新登录")
+		case service.ErrInvalidTwoFactorCode:
+			response.Unauthorized(c, "验证码错误")
+		default:
+			response.InternalServerError(c, "登录失败: "+err.Error())
+		}
+		return
+	}
+
 	response.SuccessWithMessage(c, "登录成功", loginResp)
 }
 
 // ChangePassword 修改密码
 // @Summary 修改密码
-// @Description 修改当前登录用户的密码
+// @Description 修改当前登录用户的密码，修改后该账号在其他设备上的登录会话全部失效
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -89,7 +163,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.ChangePassword(userID.(uint), req.OldPassword, req.NewPassword)
+	err := h.authService.ChangePassword(userID.(uint), c.GetString("sessionID"), req.OldPassword, req.NewPassword)
 	if err != nil {
 		switch err {
 		case service.ErrInvalidCredentials:
@@ -125,42 +199,232 @@ func (h *AuthHandler) VerifyToken(c *gin.Context) {
 	response.Success(c, gin.H{
 		"user_id":  userID,
 		"username": username,
+		"role":     c.GetString("role"),
 		"valid":    true,
 	})
 }
 
 // RefreshToken 刷新Token
 // @Summary 刷新Token
-// @Description 使用旧Token获取新Token
+// @Description 使用刷新Token换取新的访问Token；刷新Token每次使用后轮换，旧刷新Token再次使用会导致整个会话被吊销
 // @Tags 认证
 // @Accept json
 // @Produce json
-// @Security BearerAuth
+// @Param body body RefreshTokenRequest true "刷新Token"
 // @Success 200 {object} response.Response "刷新成功"
-// @Failure 401 {object} response.Response "Token无效或已过期"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "刷新Token无效或已过期"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	// 从请求头获取Token
-	token := c.GetHeader("Authorization")
-	if token == "" {
-		response.Unauthorized(c, "缺少Token")
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
 		return
 	}
 
-	// 移除"Bearer "前缀
-	if len(token) > 7 && token[:7] == "Bearer " {
-		token = token[7:]
+	loginResp, err := h.authService.RefreshToken(req.RefreshToken)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidRefreshToken:
+			response.Unauthorized(c, "登录已过期，请重新登录")
+		case service.ErrRefreshTokenReused:
+			response.Unauthorized(c, "登录凭证已被使用，为安全起见请重新登录")
+		default:
+			response.InternalServerError(c, "Token刷新失败: "+err.Error())
+		}
+		return
 	}
 
-	newToken, err := h.authService.RefreshToken(token)
+	response.Success(c, loginResp)
+}
+
+// Logout 退出登录
+// @Summary 退出登录
+// @Description 吊销当前会话的访问Token和刷新Token
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response "退出成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.authService.Logout(c.GetString("sessionID")); err != nil {
+		response.InternalServerError(c, "退出登录失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "已退出登录", nil)
+}
+
+// LogoutAll 退出所有会话
+// @Summary 退出所有会话
+// @Description 吊销当前账号在所有设备上的登录会话（包括当前会话）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response "退出成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	revoked, err := h.authService.LogoutAll(c.GetUint("userID"))
 	if err != nil {
-		response.Unauthorized(c, "Token刷新失败: "+err.Error())
+		response.InternalServerError(c, "退出所有会话失败: "+err.Error())
 		return
 	}
 
-	response.Success(c, gin.H{
-		"token": newToken,
+	response.SuccessWithMessage(c, "已退出所有会话", gin.H{
+		"revoked": revoked,
 	})
 }
 
+// SetupTwoFactor 设置两步验证
+// @Summary 设置两步验证
+// @Description 生成TOTP密钥和otpauth://地址（前端据此生成二维码），需调用启用接口验证后才生效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=service.TwoFactorSetupResponse} "生成成功"
+// @Failure 400 {object} response.Response "已启用两步验证"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /auth/2fa/setup [post]
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	result, err := h.authService.SetupTwoFactor(c.GetUint("userID"))
+	if err != nil {
+		h.handleTwoFactorError(c, err, "设置两步验证失败")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// EnableTwoFactor 启用两步验证
+// @Summary 启用两步验证
+// @Description 使用身份验证器App中的验证码确认并启用两步验证，返回一次性恢复码（只显示这一次）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body EnableTwoFactorRequest true "验证码"
+// @Success 200 {object} response.Response{data=service.TwoFactorRecoveryCodesResponse} "启用成功"
+// @Failure 400 {object} response.Response "请求参数错误或验证码错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /auth/2fa/enable [post]
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req EnableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := h.authService.EnableTwoFactor(c.GetUint("userID"), req.Code)
+	if err != nil {
+		h.handleTwoFactorError(c, err, "启用两步验证失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "两步验证已启用，请妥善保存恢复码", result)
+}
+
+// DisableTwoFactor 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 重新输入登录密码后关闭当前账号的两步验证，其他设备上的登录会话全部失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body ConfirmPasswordRequest true "登录密码"
+// @Success 200 {object} response.Response "关闭成功"
+// @Failure 400 {object} response.Response "请求参数错误或未启用两步验证"
+// @Failure 401 {object} response.Response "未授权或密码错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /auth/2fa/disable [post]
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req ConfirmPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(c.GetUint("userID"), c.GetString("sessionID"), req.Password); err != nil {
+		h.handleTwoFactorError(c, err, "关闭两步验证失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "两步验证已关闭", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 重新输入登录密码后生成新的恢复码，旧恢复码全部失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body ConfirmPasswordRequest true "登录密码"
+// @Success 200 {object} response.Response{data=service.TwoFactorRecoveryCodesResponse} "生成成功"
+// @Failure 400 {object} response.Response "请求参数错误或未启用两步验证"
+// @Failure 401 {object} response.Response "未授权或密码错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /auth/2fa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req ConfirmPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := h.authService.RegenerateRecoveryCodes(c.GetUint("userID"), req.Password)
+	if err != nil {
+		h.handleTwoFactorError(c, err, "生成恢复码失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "恢复码已重新生成，请妥善保存", result)
+}
+
+// handleTwoFactorError 统一处理两步验证设置错误
+func (h *AuthHandler) handleTwoFactorError(c *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrInvalidCredentials:
+		response.Unauthorized(c, "密码错误")
+	case service.ErrInvalidTwoFactorCode:
+		response.BadRequest(c, "验证码错误")
+	case service.ErrTwoFactorAlreadyEnabled:
+		response.BadRequest(c, "两步验证已启用")
+	case service.ErrTwoFactorNotEnabled:
+		response.BadRequest(c, "未启用两步验证")
+	case service.ErrTwoFactorNotSetup:
+		response.BadRequest(c, "请先生成两步验证密钥")
+	default:
+		response.InternalServerError(c, message+": "+err.Error())
+	}
+}
+
+// respondLoginLocked 登录被锁定时返回429并设置Retry-After，其他错误返回false
+func respondLoginLocked(c *gin.Context, err error) bool {
+	var locked *service.LoginLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+
+	seconds := int(math.Ceil(locked.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	response.TooManyRequests(c, fmt.Sprintf("登录失败次数过多，请%s后再试", formatRetryAfter(seconds)))
+	return true
+}
+
+// formatRetryAfter 将剩余秒数格式化为可读文本
+func formatRetryAfter(seconds int) string {
+	if seconds < 60 {
+		return fmt.Sprintf("%d秒", seconds)
+	}
+	return fmt.Sprintf("%d分钟", (seconds+59)/60)
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// ClientStatsHandler 客户端统计处理器
+type ClientStatsHandler struct {
+	clientStatsService service.ClientStatsService
+}
+
+// NewClientStatsHandler 创建客户端统计处理器
+func NewClientStatsHandler(clientStatsService service.ClientStatsService) *ClientStatsHandler {
+	return &ClientStatsHandler{
+		clientStatsService: clientStatsService,
+	}
+}
+
+// GetClientStats 获取客户端分布概览
+// @Summary 获取客户端分布概览
+// @Description 按访问记录统计浏览器、操作系统和设备类型（desktop/mobile/tablet/bot）的分布，name为空表示无法识别。结束日期包含当天
+// @Tags 统计
+// @Produce json
+// @Security BearerAuth
+// @Param start_date query string false "开始日期 (YYYY-MM-DD)，默认30天前"
+// @Param end_date query string false "结束日期 (YYYY-MM-DD)，默认今天"
+// @Param limit query int false "每个维度返回的数量" default(10)
+// @Success 200 {object} response.Response{data=service.ClientStatsResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/stats/clients [get]
+func (h *ClientStatsHandler) GetClientStats(c *gin.Context) {
+	startDate, endDate := parseStatsDateRange(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	stats, err := h.clientStatsService.GetClientStats(startDate, endDate, limit)
+	if err != nil {
+		response.InternalServerError(c, "获取客户端统计失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, stats)
+}
+
+// GetBreakdown 获取某个维度的客户端分布
+// @Summary 获取客户端分布
+// @Description 按访问记录统计某个维度的分布：browser（浏览器）、browser_version（浏览器及主版本号）、os（操作系统）、os_version（操作系统及版本）、device_type（设备类型）。结束日期包含当天
+// @Tags 统计
+// @Produce json
+// @Security BearerAuth
+// @Param dimension path string true "统计维度" Enums(browser, browser_version, os, os_version, device_type)
+// @Param start_date query string false "开始日期 (YYYY-MM-DD)，默认30天前"
+// @Param end_date query string false "结束日期 (YYYY-MM-DD)，默认今天"
+// @Param limit query int false "返回数量（最多100）" default(10)
+// @Success 200 {object} response.Response{data=[]repository.ClientStat} "获取成功"
+// @Failure 400 {object} response.Response "不支持的统计维度"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/stats/clients/{dimension} [get]
+func (h *ClientStatsHandler) GetBreakdown(c *gin.Context) {
+	startDate, endDate := parseStatsDateRange(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	stats, err := h.clientStatsService.GetBreakdown(c.Param("dimension"), startDate, endDate, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidClientDimension) {
+			response.BadRequest(c, "不支持的统计维度，可选 browser、browser_version、os、os_version、device_type")
+			return
+		}
+		response.InternalServerError(c, "获取客户端统计失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, stats)
+}
+
+// parseStatsDateRange 解析统计接口的 start_date/end_date 参数（YYYY-MM-DD），格式错误或未提供时为零值
+func parseStatsDateRange(c *gin.Context) (time.Time, time.Time) {
+	var startDate, endDate time.Time
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		if parsed, err := time.Parse("2006-01-02", startDateStr); err == nil {
+			startDate = parsed
+		}
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		if parsed, err := time.Parse("2006-01-02", endDateStr); err == nil {
+			endDate = parsed
+		}
+	}
+
+	return startDate, endDate
+}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// CommentHandler 评论处理器
+type CommentHandler struct {
+	commentService service.CommentService
+}
+
+// NewCommentHandler 创建评论处理器
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// UpdateCommentStatusRequest 更新评论状态请求
+type UpdateCommentStatusRequest struct {
+	Status models.MessageStatus `json:"status" binding:"required"`
+}
+
+// BatchUpdateCommentStatusRequest 批量更新评论状态请求
+type BatchUpdateCommentStatusRequest struct {
+	IDs    []uint               `json:"ids" binding:"required,min=1"`
+	Status models.MessageStatus `json:"status" binding:"required"`
+}
+
+// Create 发表评论
+// @Summary 发表评论
+// @Description 对已发布的文章发表评论或回复，评论需审核后才会公开显示（需提供指纹ID和指纹哈希，按指纹和IP限流）
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Param id path int true "文章ID"
+// @Param body body service.CreateCommentRequest true "评论内容"
+// @Success 201 {object} response.Response "提交成功，等待审核"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "文章不存在"
+// @Failure 429 {object} response.Response "评论过于频繁"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /articles/{id}/comments [post]
+func (h *CommentHandler) Create(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的文章ID")
+		return
+	}
+
+	var req service.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.GetHeader("User-Agent")
+
+	comment, err := h.commentService.Create(uint(articleID), &req)
+	if err != nil {
+		switch err {
+		case service.ErrArticleNotFound:
+			response.NotFound(c, "文章不存在")
+		case service.ErrCommentArticleClosed:
+			response.BadRequest(c, "该文章暂不允许评论")
+		case service.ErrCommentContentRequired:
+			response.BadRequest(c, "评论内容不能为空")
+		case service.ErrCommentParentInvalid:
+			response.BadRequest(c, "回复的评论不存在")
+		case service.ErrCommentFingerprintInvalid:
+			response.BadRequest(c, "无效的指纹ID或指纹哈希")
+		case service.ErrCommentTooFrequent:
+			response.TooManyRequests(c, "评论过于频繁，请稍后再试")
+		default:
+			response.InternalServerError(c, "发表评论失败: "+err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "提交成功，等待审核", gin.H{
+		"id":     comment.ID,
+		"status": comment.Status,
+	})
+}
+
+// ListByArticle 获取文章评论
+// @Summary 获取文章评论
+// @Description 获取文章下已通过审核的评论（树形结构，按顶级评论分页）
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Param id path int true "文章ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=service.CommentTreeResponse} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /articles/{id}/comments [get]
+func (h *CommentHandler) ListByArticle(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的文章ID")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	result, err := h.commentService.ListByArticle(uint(articleID), page, pageSize)
+	if err != nil {
+		response.InternalServerError(c, "获取评论失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// List 获取评论列表（管理员）
+// @Summary 获取评论列表
+// @Description 评论审核队列，支持按状态、文章、指纹和关键词筛选
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param status query string false "审核状态" Enums(pending, approved, spam)
+// @Param article_id query int false "文章ID"
+// @Param fingerprint_id query int false "指纹ID"
+// @Param keyword query string false "搜索关键词"
+// @Success 200 {object} response.Response{data=service.CommentListResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/comments [get]
+func (h *CommentHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	req := &service.CommentListRequest{
+		Page:     page,
+		PageSize: pageSize,
+		Keyword:  c.Query("keyword"),
+	}
+
+	// 解析审核状态
+	if statusStr := c.Query("status"); statusStr != "" {
+		status := models.MessageStatus(statusStr)
+		req.Status = &status
+	}
+
+	// 解析文章ID
+	if articleIDStr := c.Query("article_id"); articleIDStr != "" {
+		if articleID, err := strconv.ParseUint(articleIDStr, 10, 32); err == nil {
+			id := uint(articleID)
+			req.ArticleID = &id
+		}
+	}
+
+	// 解析指纹ID
+	if fingerprintIDStr := c.Query("fingerprint_id"); fingerprintIDStr != "" {
+		if fingerprintID, err := strconv.ParseUint(fingerprintIDStr, 10, 32); err == nil {
+			id := uint(fingerprintID)
+			req.FingerprintID = &id
+		}
+	}
+
+	result, err := h.commentService.List(req)
+	if err != nil {
+		response.InternalServerError(c, "获取评论列表失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// UpdateStatus 更新评论状态
+// @Summary 更新评论状态
+// @Description 审核评论：通过、标记为垃圾或退回待审核
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "评论ID"
+// @Param body body UpdateCommentStatusRequest true "审核状态"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "评论不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/comments/{id}/status [put]
+func (h *CommentHandler) UpdateStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的评论ID")
+		return
+	}
+
+	var req UpdateCommentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.commentService.UpdateStatus(uint(id), req.Status); err != nil {
+		switch err {
+		case service.ErrCommentNotFound:
+			response.NotFound(c, "评论不存在")
+		case service.ErrInvalidCommentStatus:
+			response.BadRequest(c, "无效的审核状态")
+		default:
+			response.InternalServerError(c, "更新评论状态失败: "+err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "更新成功", nil)
+}
+
+// BatchUpdateStatus 批量更新评论状态
+// @Summary 批量更新评论状态
+// @Description 批量审核评论
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body BatchUpdateCommentStatusRequest true "评论ID列表和审核状态"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/comments/batch-status [put]
+func (h *CommentHandler) BatchUpdateStatus(c *gin.Context) {
+	var req BatchUpdateCommentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	affected, err := h.commentService.BatchUpdateStatus(req.IDs, req.Status)
+	if err != nil {
+		if err == service.ErrInvalidCommentStatus {
+			response.BadRequest(c, "无效的审核状态")
+			return
+		}
+		response.InternalServerError(c, "批量更新评论状态失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "更新成功", gin.H{
+		"updated_count": affected,
+	})
+}
+
+// Delete 删除评论
+// @Summary 删除评论
+// @Description 删除评论及其所有回复
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "评论ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "评论不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/comments/{id} [delete]
+func (h *CommentHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的评论ID")
+		return
+	}
+
+	if err := h.commentService.Delete(uint(id)); err != nil {
+		if err == service.ErrCommentNotFound {
+			response.NotFound(c, "评论不存在")
+			return
+		}
+		response.InternalServerError(c, "删除评论失败: "+err.Error())
+		return
+	}
+
+	response.NoContent(c, "删除成功")
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// CrawlImportHandler 采集结果导入处理器
+type CrawlImportHandler struct {
+	crawlService       service.CrawlService
+	crawlImportService service.CrawlImportService
+}
+
+// NewCrawlImportHandler 创建采集结果导入处理器
+func NewCrawlImportHandler(crawlService service.CrawlService, crawlImportService service.CrawlImportService) *CrawlImportHandler {
+	return &CrawlImportHandler{
+		crawlService:       crawlService,
+		crawlImportService: crawlImportService,
+	}
+}
+
+// UpdateMapping 设置导入映射规则（管理员）
+// @Summary 设置导入映射规则
+// @Description 配置任务的采集结果如何映射为文章字段（标题、摘要、正文、标签、分类等），字段名支持用"."访问嵌套对象。未指定作者时使用当前管理员
+// @Tags 爬虫任务
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param task_id path string true "任务ID（数字ID或任务唯一标识）"
+// @Param body body models.CrawlImportMapping true "映射规则"
+// @Success 200 {object} response.Response{data=models.CrawlTask} "设置成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/tasks/{task_id}/import-mapping [put]
+func (h *CrawlImportHandler) UpdateMapping(c *gin.Context) {
+	var mapping models.CrawlImportMapping
+	if err := c.ShouldBindJSON(&mapping); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	task, ok := findCrawlTask(c, h.crawlService)
+	if !ok {
+		return
+	}
+
+	// 获取当前用户ID
+	userID, _ := c.Get("userID")
+
+	task, err := h.crawlImportService.UpdateMapping(task.ID, &mapping, userID.(uint))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImportMapping) {
+			response.BadRequest(c, "导入映射规则不合法: "+err.Error())
+			return
+		}
+		response.InternalServerError(c, "设置导入映射规则失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "设置成功", task)
+}
+
+// DeleteMapping 清除导入映射规则（管理员）
+// @Summary 清除导入映射规则
+// @Description 清除任务的导入映射规则，之后任务完成时不再自动导入
+// @Tags 爬虫任务
+// @Produce json
+// @Security BearerAuth
+// @Param task_id path string true "任务ID（数字ID或任务唯一标识）"
+// @Success 200 {object} response.Response{data=models.CrawlTask} "清除成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/tasks/{task_id}/import-mapping [delete]
+func (h *CrawlImportHandler) DeleteMapping(c *gin.Context) {
+	task, ok := findCrawlTask(c, h.crawlService)
+	if !ok {
+		return
+	}
+
+	task, err := h.crawlImportService.UpdateMapping(task.ID, nil, 0)
+	if err != nil {
+		response.InternalServerError(c, "清除导入映射规则失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "清除成功", task)
+}
+
+// StartImport 导入采集结果（管理员）
+// @Summary 导入采集结果
+// @Description 在后台按映射规则将已完成任务的采集结果导入为草稿文章，来源URL已导入过的结果会跳过。导入进度和结果见任务的 import_summary
+// @Tags 爬虫任务
+// @Produce json
+// @Security BearerAuth
+// @Param task_id path string true "任务ID（数字ID或任务唯一标识）"
+// @Success 200 {object} response.Response "导入已开始"
+// @Failure 400 {object} response.Response "任务未完成或未配置映射规则"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "导入正在进行中"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/tasks/{task_id}/import [post]
+func (h *CrawlImportHandler) StartImport(c *gin.Context) {
+	task, ok := findCrawlTask(c, h.crawlService)
+	if !ok {
+		return
+	}
+
+	if err := h.crawlImportService.StartImport(task.ID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrTaskNotCompleted):
+			response.BadRequest(c, "任务完成后才能导入")
+		case errors.Is(err, service.ErrImportMappingMissing):
+			response.BadRequest(c, "任务未配置导入映射规则")
+		case errors.Is(err, service.ErrImportAuthorRequired):
+			response.BadRequest(c, "导入映射规则未指定作者")
+		case errors.Is(err, service.ErrInvalidImportMapping):
+			response.BadRequest(c, "导入映射规则不合法: "+err.Error())
+		case errors.Is(err, service.ErrImportInProgress):
+			response.Error(c, http.StatusConflict, "导入正在进行中")
+		default:
+			response.InternalServerError(c, "开始导入失败: "+err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "导入已开始", nil)
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// CrawlJobHandler 爬虫作业处理器
+type CrawlJobHandler struct {
+	crawlJobService service.CrawlJobService
+}
+
+// NewCrawlJobHandler 创建爬虫作业处理器
+func NewCrawlJobHandler(crawlJobService service.CrawlJobService) *CrawlJobHandler {
+	return &CrawlJobHandler{
+		crawlJobService: crawlJobService,
+	}
+}
+
+// Create 创建作业
+// @Summary 创建爬虫作业
+// @Description 定义按cron表达式（秒 分 时 日 月 周）定时运行的爬虫作业，每次运行生成一个待执行任务，由爬虫工作节点领取
+// @Tags 爬虫作业
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body service.CrawlJobRequest true "作业信息"
+// @Success 201 {object} response.Response{data=models.CrawlJob} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/jobs [post]
+func (h *CrawlJobHandler) Create(c *gin.Context) {
+	var req service.CrawlJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 获取当前用户ID
+	userID, _ := c.Get("userID")
+
+	job, err := h.crawlJobService.Create(&req, userID.(uint))
+	if err != nil {
+		if h.handleValidationError(c, err) {
+			return
+		}
+		response.InternalServerError(c, "创建作业失败: "+err.Error())
+		return
+	}
+
+	response.Created(c, "创建成功", job)
+}
+
+// List 获取作业列表
+// @Summary 获取爬虫作业列表
+// @Description 获取爬虫作业列表，启用的作业附带下次运行时间
+// @Tags 爬虫作业
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=service.CrawlJobListResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/jobs [get]
+func (h *CrawlJobHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	resp, err := h.crawlJobService.List(page, pageSize)
+	if err != nil {
+		response.InternalServerError(c, "获取作业列表失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, resp)
+}
+
+// GetByID 获取作业详情
+// @Summary 获取爬虫作业详情
+// @Description 根据ID获取爬虫作业详情
+// @Tags 爬虫作业
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "作业ID"
+// @Success 200 {object} response.Response{data=models.CrawlJob} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/jobs/{id} [get]
+func (h *CrawlJobHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的作业ID")
+		return
+	}
+
+	job, err := h.crawlJobService.GetByID(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrCrawlJobNotFound) {
+			response.NotFound(c, "作业不存在")
+			return
+		}
+		response.InternalServerError(c, "获取作业失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, job)
+}
+
+// Update 更新作业
+// @Summary 更新爬虫作业
+// @Description 更新爬虫作业定义，调度立即按新的cron表达式生效；已生成的任务不受影响
+// @Tags 爬虫作业
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "作业ID"
+// @Param body body service.CrawlJobRequest true "作业信息"
+// @Success 200 {object} response.Response{data=models.CrawlJob} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/jobs/{id} [put]
+func (h *CrawlJobHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的作业ID")
+		return
+	}
+
+	var req service.CrawlJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 获取当前用户ID
+	userID, _ := c.Get("userID")
+
+	job, err := h.crawlJobService.Update(uint(id), &req, userID.(uint))
+	if err != nil {
+		if errors.Is(err, service.ErrCrawlJobNotFound) {
+			response.NotFound(c, "作业不存在")
+			return
+		}
+		if h.handleValidationError(c, err) {
+			return
+		}
+		response.InternalServerError(c, "更新作业失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "更新成功", job)
+}
+
+// Delete 删除作业
+// @Summary 删除爬虫作业
+// @Description 删除爬虫作业，已生成的任务保留
+// @Tags 爬虫作业
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "作业ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/jobs/{id} [delete]
+func (h *CrawlJobHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的作业ID")
+		return
+	}
+
+	if err := h.crawlJobService.Delete(uint(id)); err != nil {
+		if errors.Is(err, service.ErrCrawlJobNotFound) {
+			response.NotFound(c, "作业不存在")
+			return
+		}
+		response.InternalServerError(c, "删除作业失败: "+err.Error())
+		return
+	}
+
+	response.NoContent(c, "删除成功")
+}
+
+// Run 立即运行作业
+// @Summary 立即运行爬虫作业
+// @Description 立即为作业生成一个待执行任务。上次的任务未结束且作业不允许重叠时返回409，可用 force=true 强制生成
+// @Tags 爬虫作业
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "作业ID"
+// @Param force query bool false "忽略上次任务是否结束"
+// @Success 201 {object} response.Response{data=models.CrawlTask} "任务已生成"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "作业不存在"
+// @Failure 409 {object} response.Response "上次的任务未结束"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/jobs/{id}/run [post]
+func (h *CrawlJobHandler) Run(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的作业ID")
+		return
+	}
+	force := c.Query("force") == "true"
+
+	task, err := h.crawlJobService.RunJob(uint(id), force)
+	if err != nil {
+		if errors.Is(err, service.ErrCrawlJobNotFound) {
+			response.NotFound(c, "作业不存在")
+			return
+		}
+		if errors.Is(err, service.ErrCrawlJobOverlap) {
+			response.Error(c, http.StatusConflict, "上次生成的任务尚未结束")
+			return
+		}
+		response.InternalServerError(c, "运行作业失败: "+err.Error())
+		return
+	}
+
+	response.Created(c, "任务已生成", task)
+}
+
+// handleValidationError 处理作业定义的校验错误，已写入响应时返回true
+func (h *CrawlJobHandler) handleValidationError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrCrawlJobExists):
+		response.BadRequest(c, "作业名称已存在")
+	case errors.Is(err, service.ErrInvalidCronSpec):
+		response.BadRequest(c, "cron表达式不合法: "+err.Error())
+	case errors.Is(err, service.ErrInvalidResultSchema):
+		response.BadRequest(c, "结果Schema不合法: "+err.Error())
+	case errors.Is(err, service.ErrInvalidImportMapping):
+		response.BadRequest(c, "导入映射规则不合法: "+err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/logger"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// maxCrawlResultBodySize 单批结果请求体的最大大小
+const maxCrawlResultBodySize = 16 << 20
+
+// CrawlResultHandler 爬虫采集结果处理器
+type CrawlResultHandler struct {
+	crawlService       service.CrawlService
+	crawlResultService service.CrawlResultService
+}
+
+// NewCrawlResultHandler 创建爬虫采集结果处理器
+func NewCrawlResultHandler(crawlService service.CrawlService, crawlResultService service.CrawlResultService) *CrawlResultHandler {
+	return &CrawlResultHandler{
+		crawlService:       crawlService,
+		crawlResultService: crawlResultService,
+	}
+}
+
+// SubmitResults 提交采集结果
+// @Summary 提交采集结果
+// @Description 以NDJSON格式（每行一个JSON对象）批量提交采集结果，每批最多1000条；任务注册时声明了result_schema则逐条校验，任一条失败整批不写入
+// @Tags 爬虫任务
+// @Accept application/x-ndjson
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "任务ID"
+// @Param body body string true "NDJSON格式的结果"
+// @Success 201 {object} response.Response{data=service.IngestResultsResponse} "提交成功"
+// @Failure 400 {object} response.Response "请求参数错误或任务已结束"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权访问此任务"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 413 {object} response.Response "请求体太大"
+// @Failure 422 {object} response.Response{data=service.CrawlResultValidationError} "结果校验失败"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /crawler/tasks/{id}/results [post]
+func (h *CrawlResultHandler) SubmitResults(c *gin.Context) {
+	taskID := c.Param("id")
+	if taskID == "" {
+		response.BadRequest(c, "任务ID不能为空")
+		return
+	}
+
+	// 获取Token
+	token, _ := c.Get("crawlerToken")
+	tokenStr := token.(string)
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxCrawlResultBodySize)
+	resp, err := h.crawlResultService.IngestResults(taskID, body, tokenStr)
+	if err != nil {
+		var validationErr *service.CrawlResultValidationError
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &validationErr):
+			response.ErrorWithData(c, http.StatusUnprocessableEntity,
+				fmt.Sprintf("%d 条结果校验失败，本批未写入", validationErr.Invalid), validationErr)
+		case errors.As(err, &maxBytesErr):
+			response.Error(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("请求体不能超过 %dMB", maxCrawlResultBodySize>>20))
+		case errors.Is(err, service.ErrCrawlTaskNotFound):
+			response.NotFound(c, "任务不存在")
+		case errors.Is(err, service.ErrCrawlTaskAccessDenied):
+			response.Forbidden(c, "无权访问此任务")
+		case errors.Is(err, service.ErrTaskAlreadyCompleted), errors.Is(err, service.ErrTaskAlreadyFailed),
+			errors.Is(err, service.ErrTaskAlreadyCancelled):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, service.ErrEmptyResultBatch):
+			response.BadRequest(c, "没有可提交的结果")
+		case errors.Is(err, service.ErrTooManyResults):
+			response.BadRequest(c, fmt.Sprintf("每批最多提交 %d 条结果", service.MaxCrawlResultsPerBatch))
+		default:
+			response.InternalServerError(c, "提交结果失败: "+err.Error())
+		}
+		return
+	}
+
+	response.Created(c, "结果提交成功", resp)
+}
+
+// ListResults 获取采集结果列表（管理员）
+// @Summary 获取采集结果列表
+// @Description 分页获取爬虫采集结果，可按任务、关键词、字段值和提交日期筛选
+// @Tags 爬虫任务
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量（最大100）" default(20)
+// @Param task_id query string false "任务ID（数字ID或任务唯一标识）"
+// @Param keyword query string false "在结果数据中模糊搜索"
+// @Param field query string false "按顶层字段筛选的字段名"
+// @Param value query string false "字段值（与field一起使用，精确匹配）"
+// @Param start_date query string false "开始日期 (YYYY-MM-DD)"
+// @Param end_date query string false "结束日期 (YYYY-MM-DD)"
+// @Success 200 {object} response.Response{data=service.CrawlResultListResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/results [get]
+func (h *CrawlResultHandler) ListResults(c *gin.Context) {
+	req, ok := h.parseFilter(c)
+	if !ok {
+		return
+	}
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil {
+			req.Page = page
+		}
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil {
+			req.PageSize = pageSize
+		}
+	}
+
+	resp, err := h.crawlResultService.ListResults(req)
+	if err != nil {
+		response.InternalServerError(c, "获取结果列表失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, resp)
+}
+
+// ExportResults 导出采集结果（管理员）
+// @Summary 导出采集结果
+// @Description 按筛选条件导出全部采集结果。CSV的列为 id、task_id、created_at 及结果中出现过的全部顶层字段（嵌套值以JSON写入）；JSON为结果数组
+// @Tags 爬虫任务
+// @Produce text/csv,application/json
+// @Security BearerAuth
+// @Param format query string false "导出格式" Enums(csv, json) default(csv)
+// @Param task_id query string false "任务ID（数字ID或任务唯一标识）"
+// @Param keyword query string false "在结果数据中模糊搜索"
+// @Param field query string false "按顶层字段筛选的字段名"
+// @Param value query string false "字段值（与field一起使用，精确匹配）"
+// @Param start_date query string false "开始日期 (YYYY-MM-DD)"
+// @Param end_date query string false "结束日期 (YYYY-MM-DD)"
+// @Success 200 {file} file "导出文件"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /admin/crawler/results/export [get]
+func (h *CrawlResultHandler) ExportResults(c *gin.Context) {
+	format := c.DefaultQuery("format", service.CrawlResultExportCSV)
+	contentType := ""
+	switch format {
+	case service.CrawlResultExportCSV:
+		contentType = "text/csv; charset=utf-8"
+	case service.CrawlResultExportJSON:
+		contentType = "application/json; charset=utf-8"
+	default:
+		response.BadRequest(c, "导出格式只支持 csv 或 json")
+		return
+	}
+
+	req, ok := h.parseFilter(c)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("crawl_results_%s.%s", time.Now().Format("20060102_150405"), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Status(http.StatusOK)
+
+	// 边查询边写入，响应头已发送，出错时只能中断输出并记录日志
+	if err := h.crawlResultService.ExportResults(req, format, c.Writer); err != nil {
+		logger.Error("Failed to export crawl results: %v", err)
+	}
+}
+
+// parseFilter 解析结果筛选参数，失败时已写入响应
+func (h *CrawlResultHandler) parseFilter(c *gin.Context) (*service.CrawlResultListRequest, bool) {
+	req := &service.CrawlResultListRequest{
+		Keyword: c.Query("keyword"),
+		Field:   c.Query("field"),
+		Value:   c.Query("value"),
+	}
+
+	// 任务ID可以是数字ID或任务唯一标识
+	if taskID := c.Query("task_id"); taskID != "" {
+		if id, err := strconv.ParseUint(taskID, 10, 32); err == nil {
+			req.TaskID = uint(id)
+		} else {
+			task, err := h.crawlService.GetTaskByTaskID(taskID)
+			if err != nil {
+				if errors.Is(err, service.ErrCrawlTaskNotFound) {
+					response.NotFound(c, "任务不存在")
+				} else {
+					response.InternalServerError(c, "获取任务失败: "+err.Error())
+				}
+				return nil, false
+			}
+			req.TaskID = task.ID
+		}
+	}
+
+	// 解析日期参数（结束日期包含当天）
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", startDateStr, time.Local)
+		if err != nil {
+			response.BadRequest(c, "开始日期格式错误，应为 YYYY-MM-DD")
+			return nil, false
+		}
+		req.StartTime = &startDate
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", endDateStr, time.Local)
+		if err != nil {
+			response.BadRequest(c, "结束日期格式错误，应为 YYYY-MM-DD")
+			return nil, false
+		}
+		endDate = endDate.AddDate(0, 0, 1)
+		req.EndTime = &endDate
+	}
+
+	return req, true
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -23,7 +25,7 @@ func NewCrawlerHandler(crawlService service.CrawlService) *CrawlerHandler {
 
 // RegisterTask 注册任务
 // @Summary 注册爬虫任务
-// @Description 注册新的爬虫任务
+// @Description 注册新的爬虫任务，可通过 result_schema 声明采集结果的JSON Schema，通过 import_mapping 配置任务完成后将结果导入为草稿文章（不能指定作者，使用服务端配置的导入作者）
 // @Tags 爬虫任务
 // @Accept json
 // @Produce json
@@ -51,6 +53,14 @@ func (h *CrawlerHandler) RegisterTask(c *gin.Context) {
 			response.BadRequest(c, "任务ID已存在")
 			return
 		}
+		if errors.Is(err, service.ErrInvalidResultSchema) {
+			response.BadRequest(c, "结果Schema不合法: "+err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidImportMapping) {
+			response.BadRequest(c, "导入映射规则不合法: "+err.Error())
+			return
+		}
 		response.InternalServerError(c, "注册任务失败: "+err.Error())
 		return
 	}
@@ -60,7 +70,7 @@ func (h *CrawlerHandler) RegisterTask(c *gin.Context) {
 
 // UpdateTaskStatus 更新任务状态
 // @Summary 更新任务状态
-// @Description 更新爬虫任务的状态和进度
+// @Description 更新爬虫任务的状态和进度。任务处于暂停或等待确认控制命令时，上报 running 只更新进度，响应中的 status 为 pausing/resuming/cancelling 表示有待确认的命令；上报 completed 与调用完成接口相同（配置了导入映射规则时会开始导入）
 // @Tags 爬虫任务
 // @Accept json
 // @Produce json
@@ -96,7 +106,7 @@ func (h *CrawlerHandler) UpdateTaskStatus(c *gin.Context) {
 			response.NotFound(c, "任务不存在")
 			return
 		}
-		if err == service.ErrTaskAlreadyCompleted || err == service.ErrTaskAlreadyFailed {
+		if err == service.ErrTaskAlreadyCompleted || err == service.ErrTaskAlreadyFailed || err == service.ErrTaskAlreadyCancelled {
 			response.BadRequest(c, err.Error())
 			return
 		}
@@ -109,7 +119,7 @@ func (h *CrawlerHandler) UpdateTaskStatus(c *gin.Context) {
 
 // CompleteTask 完成任务
 // @Summary 完成任务
-// @Description 标记爬虫任务为已完成
+// @Description 标记爬虫任务为已完成；任务配置了导入映射规则时，在后台将采集结果导入为草稿文章
 // @Tags 爬虫任务
 // @Accept json
 // @Produce json
@@ -145,7 +155,7 @@ func (h *CrawlerHandler) CompleteTask(c *gin.Context) {
 			response.NotFound(c, "任务不存在")
 			return
 		}
-		if err == service.ErrTaskAlreadyCompleted || err == service.ErrTaskAlreadyFailed {
+		if err == service.ErrTaskAlreadyCompleted || err == service.ErrTaskAlreadyFailed || err == service.ErrTaskAlreadyCancelled {
 			response.BadRequest(c, err.Error())
 			return
 		}
@@ -194,7 +204,7 @@ func (h *CrawlerHandler) FailTask(c *gin.Context) {
 			response.NotFound(c, "任务不存在")
 			return
 		}
-		if err == service.ErrTaskAlreadyCompleted || err == service.ErrTaskAlreadyFailed {
+		if err == service.ErrTaskAlreadyCompleted || err == service.ErrTaskAlreadyFailed || err == service.ErrTaskAlreadyCancelled {
 			response.BadRequest(c, err.Error())
 			return
 		}
@@ -205,6 +215,202 @@ func (h *CrawlerHandler) FailTask(c *gin.Context) {
 	response.SuccessWithMessage(c, "任务状态已更新", task)
 }
 
+// ClaimTask 领取任务
+// @Summary 领取待执行任务
+// @Description 工作节点领取最早的待执行任务（由爬虫作业生成）。领取后任务进入运行状态并获得租约，需在租约到期前发送心跳，否则任务会重新排队。没有可领取的任务时 data 为 null
+// @Tags 爬虫任务
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body service.ClaimTaskRequest true "工作节点信息"
+// @Success 200 {object} response.Response{data=models.CrawlTask} "领取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /crawler/tasks/claim [post]
+func (h *CrawlerHandler) ClaimTask(c *gin.Context) {
+	var req service.ClaimTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 获取Token
+	token, _ := c.Get("crawlerToken")
+	tokenStr := token.(string)
+
+	task, err := h.crawlService.ClaimTask(&req, tokenStr)
+	if err != nil {
+		response.InternalServerError(c, "领取任务失败: "+err.Error())
+		return
+	}
+	if task == nil {
+		response.SuccessWithMessage(c, "暂无待执行任务", nil)
+		return
+	}
+
+	response.SuccessWithMessage(c, "领取成功", task)
+}
+
+// Heartbeat 任务心跳
+// @Summary 任务心跳
+// @Description 工作节点定期发送心跳以续约，可附带进度和消息（暂停期间也需继续发送）。任务已被重新排队或由其他节点领取时返回409，工作节点应停止执行该任务。自行注册的任务也可用此接口上报心跳，避免长时间无状态变化时被判定为失联。响应中的 status 为 pausing/resuming/cancelling 表示有待确认的控制命令
+// @Tags 爬虫任务
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param task_id path string true "任务ID"
+// @Param body body service.HeartbeatRequest false "心跳信息"
+// @Success 200 {object} response.Response{data=models.CrawlTask} "续约成功"
+// @Failure 400 {object} response.Response "请求参数错误或任务已结束"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权访问此任务"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "租约已失效"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /crawler/tasks/{task_id}/heartbeat [put]
+func (h *CrawlerHandler) Heartbeat(c *gin.Context) {
+	taskID := c.Param("id")
+	if taskID == "" {
+		response.BadRequest(c, "任务ID不能为空")
+		return
+	}
+
+	var req service.HeartbeatRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "请求参数错误: "+err.Error())
+			return
+		}
+	}
+
+	// 获取Token
+	token, _ := c.Get("crawlerToken")
+	tokenStr := token.(string)
+
+	task, err := h.crawlService.Heartbeat(taskID, &req, tokenStr)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCrawlTaskNotFound):
+			response.NotFound(c, "任务不存在")
+		case errors.Is(err, service.ErrCrawlTaskAccessDenied):
+			response.Forbidden(c, "无权访问此任务")
+		case errors.Is(err, service.ErrCrawlTaskLeaseLost):
+			response.Error(c, http.StatusConflict, "任务租约已失效，请停止执行该任务")
+		case errors.Is(err, service.ErrTaskAlreadyCompleted), errors.Is(err, service.ErrTaskAlreadyFailed),
+			errors.Is(err, service.ErrTaskAlreadyCancelled), errors.Is(err, service.ErrInvalidProgress):
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalServerError(c, "心跳失败: "+err.Error())
+		}
+		return
+	}
+
+	response.Success(c, task)
+}
+
+// AcknowledgeCommand 确认控制命令
+// @Summary 确认控制命令
+// @Description 爬虫执行暂停、恢复或取消后确认命令，任务进入 paused、running 或 cancelled 状态。也可以通过控制通道 /ws/crawler/control 发送 command_ack 消息确认。命令已被新的命令替换时返回409
+// @Tags 爬虫任务
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param task_id path string true "任务ID"
+// @Param body body service.TaskCommandRequest true "确认的命令"
+// @Success 200 {object} response.Response{data=models.CrawlTask} "确认成功"
+// @Failure 400 {object} response.Response "请求参数错误或任务已结束"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "无权访问此任务"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "没有待确认的该命令"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /crawler/tasks/{task_id}/ack [put]
+func (h *CrawlerHandler) AcknowledgeCommand(c *gin.Context) {
+	taskID := c.Param("id")
+	if taskID == "" {
+		response.BadRequest(c, "任务ID不能为空")
+		return
+	}
+
+	var req service.TaskCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	// 获取Token
+	token, _ := c.Get("crawlerToken")
+	tokenStr := token.(string)
+
+	task, err := h.crawlService.AcknowledgeCommand(taskID, req.Command, tokenStr)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCrawlTaskNotFound):
+			response.NotFound(c, "任务不存在")
+		case errors.Is(err, service.ErrCrawlTaskAccessDenied):
+			response.Forbidden(c, "无权访问此任务")
+		case errors.Is(err, service.ErrTaskCommandConflict):
+			response.Error(c, http.StatusConflict, "任务没有待确认的该命令")
+		case errors.Is(err, service.ErrTaskAlreadyCompleted), errors.Is(err, service.ErrTaskAlreadyFailed),
+			errors.Is(err, service.ErrTaskAlreadyCancelled):
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalServerError(c, "确认命令失败: "+err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "确认成功", task)
+}
+
+// SendCommand 下发控制命令（管理员）
+// @Summary 下发控制命令
+// @Description 暂停（pause）、恢复（resume）或取消（cancel）任务。命令保存为任务状态（pausing、resuming、cancelling），通过控制通道推送给爬虫，爬虫确认后进入目标状态；尚未被领取的任务取消时直接结束。任务当前状态不允许该命令时返回409
+// @Tags 爬虫任务
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param task_id path string true "任务ID（数字ID或任务唯一标识）"
+// @Param body body service.TaskCommandRequest true "控制命令"
+// @Success 200 {object} response.Response{data=models.CrawlTask} "命令已下发"
+// @Failure 400 {object} response.Response "请求参数错误或任务已结束"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "任务当前状态不允许该命令"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/crawler/tasks/{task_id}/command [post]
+func (h *CrawlerHandler) SendCommand(c *gin.Context) {
+	var req service.TaskCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	task, ok := findCrawlTask(c, h.crawlService)
+	if !ok {
+		return
+	}
+
+	task, err := h.crawlService.SendCommand(task.ID, req.Command)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCrawlTaskNotFound):
+			response.NotFound(c, "任务不存在")
+		case errors.Is(err, service.ErrTaskCommandConflict):
+			response.Error(c, http.StatusConflict, "任务当前状态不允许该命令")
+		case errors.Is(err, service.ErrInvalidTaskCommand), errors.Is(err, service.ErrTaskAlreadyCompleted),
+			errors.Is(err, service.ErrTaskAlreadyFailed), errors.Is(err, service.ErrTaskAlreadyCancelled):
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalServerError(c, "下发命令失败: "+err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "命令已下发", task)
+}
+
 // ListTasks 获取任务列表（管理员）
 // @Summary 获取任务列表
 // @Description 获取爬虫任务列表（管理员）
@@ -214,7 +420,7 @@ func (h *CrawlerHandler) FailTask(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
-// @Param status query string false "状态筛选" Enums(running, completed, failed)
+// @Param status query string false "状态筛选" Enums(pending, running, pausing, paused, resuming, cancelling, cancelled, completed, failed)
 // @Param task_id query string false "任务ID"
 // @Success 200 {object} response.Response "获取成功"
 // @Failure 401 {object} response.Response "未授权"
@@ -300,3 +506,30 @@ func (h *CrawlerHandler) GetTaskByID(c *gin.Context) {
 
 	response.Success(c, task)
 }
+
+// findCrawlTask 按数字ID或任务唯一标识查找任务，失败时已写入响应
+func findCrawlTask(c *gin.Context, crawlService service.CrawlService) (*models.CrawlTask, bool) {
+	taskIDStr := c.Param("task_id")
+	if taskIDStr == "" {
+		response.BadRequest(c, "任务ID不能为空")
+		return nil, false
+	}
+
+	var task *models.CrawlTask
+	var err error
+	if id, parseErr := strconv.ParseUint(taskIDStr, 10, 32); parseErr == nil {
+		task, err = crawlService.GetTaskByID(uint(id))
+	} else {
+		task, err = crawlService.GetTaskByTaskID(taskIDStr)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrCrawlTaskNotFound) {
+			response.NotFound(c, "任务不存在")
+		} else {
+			response.InternalServerError(c, "获取任务失败: "+err.Error())
+		}
+		return nil, false
+	}
+
+	return task, true
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// FeedHandler 订阅源处理器
+type FeedHandler struct {
+	feedService service.FeedService
+}
+
+// NewFeedHandler 创建订阅源处理器
+func NewFeedHandler(feedService service.FeedService) *FeedHandler {
+	return &FeedHandler{
+		feedService: feedService,
+	}
+}
+
+// RSS 全站RSS订阅
+// @Summary 全站RSS订阅
+// @Description 输出最近发布文章的RSS 2.0订阅源（不包含定时发布且未到时间的文章）
+// @Tags 订阅源
+// @Produce xml
+// @Success 200 {string} string "RSS文档"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /feed.xml [get]
+func (h *FeedHandler) RSS(c *gin.Context) {
+	h.serve(c, &service.FeedRequest{Format: service.FeedFormatRSS})
+}
+
+// Atom 全站Atom订阅
+// @Summary 全站Atom订阅
+// @Description 输出最近发布文章的Atom 1.0订阅源（不包含定时发布且未到时间的文章）
+// @Tags 订阅源
+// @Produce xml
+// @Success 200 {string} string "Atom文档"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /atom.xml [get]
+func (h *FeedHandler) Atom(c *gin.Context) {
+	h.serve(c, &service.FeedRequest{Format: service.FeedFormatAtom})
+}
+
+// CategoryRSS 分类RSS订阅
+// @Summary 分类RSS订阅
+// @Description 输出指定分类下最近发布文章的RSS 2.0订阅源
+// @Tags 订阅源
+// @Produce xml
+// @Param slug path string true "分类Slug"
+// @Success 200 {string} string "RSS文档"
+// @Failure 404 {string} string "分类不存在"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /categories/{slug}/feed.xml [get]
+func (h *FeedHandler) CategoryRSS(c *gin.Context) {
+	h.serve(c, &service.FeedRequest{Format: service.FeedFormatRSS, CategorySlug: c.Param("slug")})
+}
+
+// CategoryAtom 分类Atom订阅
+// @Summary 分类Atom订阅
+// @Description 输出指定分类下最近发布文章的Atom 1.0订阅源
+// @Tags 订阅源
+// @Produce xml
+// @Param slug path string true "分类Slug"
+// @Success 200 {string} string "Atom文档"
+// @Failure 404 {string} string "分类不存在"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /categories/{slug}/atom.xml [get]
+func (h *FeedHandler) CategoryAtom(c *gin.Context) {
+	h.serve(c, &service.FeedRequest{Format: service.FeedFormatAtom, CategorySlug: c.Param("slug")})
+}
+
+// TagRSS 标签RSS订阅
+// @Summary 标签RSS订阅
+// @Description 输出指定标签下最近发布文章的RSS 2.0订阅源
+// @Tags 订阅源
+// @Produce xml
+// @Param slug path string true "标签Slug"
+// @Success 200 {string} string "RSS文档"
+// @Failure 404 {string} string "标签不存在"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /tags/{slug}/feed.xml [get]
+func (h *FeedHandler) TagRSS(c *gin.Context) {
+	h.serve(c, &service.FeedRequest{Format: service.FeedFormatRSS, TagSlug: c.Param("slug")})
+}
+
+// TagAtom 标签Atom订阅
+// @Summary 标签Atom订阅
+// @Description 输出指定标签下最近发布文章的Atom 1.0订阅源
+// @Tags 订阅源
+// @Produce xml
+// @Param slug path string true "标签Slug"
+// @Success 200 {string} string "Atom文档"
+// @Failure 404 {string} string "标签不存在"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /tags/{slug}/atom.xml [get]
+func (h *FeedHandler) TagAtom(c *gin.Context) {
+	h.serve(c, &service.FeedRequest{Format: service.FeedFormatAtom, TagSlug: c.Param("slug")})
+}
+
+// serve 生成并输出订阅源
+func (h *FeedHandler) serve(c *gin.Context, req *service.FeedRequest) {
+	req.BaseURL = requestBaseURL(c)
+	req.FeedPath = c.Request.URL.Path
+
+	data, err := h.feedService.GetFeed(req)
+	if err != nil {
+		switch err {
+		case service.ErrCategoryNotFound:
+			c.String(http.StatusNotFound, "分类不存在")
+		case service.ErrTagNotFound:
+			c.String(http.StatusNotFound, "标签不存在")
+		default:
+			c.String(http.StatusInternalServerError, "生成订阅源失败")
+		}
+		return
+	}
+
+	contentType := "application/rss+xml; charset=utf-8"
+	if req.Format == service.FeedFormatAtom {
+		contentType = "application/atom+xml; charset=utf-8"
+	}
+	c.Header("Cache-Control", "public, max-age=600")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// requestBaseURL 根据请求推断站点地址（兼容反向代理），只在未配置site.url时使用
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	// 只接受http/https，避免客户端在链接中注入其他协议
+	if proto := strings.ToLower(strings.TrimSpace(c.GetHeader("X-Forwarded-Proto"))); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// GeoStatsHandler 访问地区统计处理器
+type GeoStatsHandler struct {
+	geoService service.GeoService
+}
+
+// NewGeoStatsHandler 创建访问地区统计处理器
+func NewGeoStatsHandler(geoService service.GeoService) *GeoStatsHandler {
+	return &GeoStatsHandler{
+		geoService: geoService,
+	}
+}
+
+// GetGeoStats 获取访问地区分布
+// @Summary 获取访问地区分布
+// @Description 按访问记录统计国家/地区、省/州和城市的分布，名称为空表示无法识别。指定 country 时省/州和城市只统计该国家/地区。未配置GeoIP数据库时 enabled 为false，新的访问记录不解析归属地。结束日期包含当天
+// @Tags 统计
+// @Produce json
+// @Security BearerAuth
+// @Param start_date query string false "开始日期 (YYYY-MM-DD)，默认30天前"
+// @Param end_date query string false "结束日期 (YYYY-MM-DD)，默认今天"
+// @Param country query string false "国家/地区代码（ISO 3166-1，如 CN）"
+// @Param limit query int false "每个层级返回的数量（最多100）" default(10)
+// @Success 200 {object} response.Response{data=service.GeoStatsResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/stats/geo [get]
+func (h *GeoStatsHandler) GetGeoStats(c *gin.Context) {
+	startDate, endDate := parseStatsDateRange(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	stats, err := h.geoService.GetGeoStats(startDate, endDate, c.Query("country"), limit)
+	if err != nil {
+		response.InternalServerError(c, "获取地区统计失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, stats)
+}
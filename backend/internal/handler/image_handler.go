@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/logger"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// ImageHandler 图片缩放处理器
+type ImageHandler struct {
+	imageService service.ImageService
+}
+
+// NewImageHandler 创建图片缩放处理器
+func NewImageHandler(imageService service.ImageService) *ImageHandler {
+	return &ImageHandler{
+		imageService: imageService,
+	}
+}
+
+// Resize 获取指定宽度的图片
+// @Summary 图片缩放
+// @Description 按宽度等比缩放上传的图片（不放大），宽度必须在配置的白名单内；结果缓存在服务端磁盘
+// @Tags 上传
+// @Produce image/jpeg,image/png,image/webp
+// @Param w path int true "宽度（如 320、640、1280）"
+// @Param path path string true "文件路径，如 2024/01/xxx.jpg"
+// @Param format query string false "输出格式，默认与原图相同" Enums(webp)
+// @Success 200 {file} file "图片内容"
+// @Failure 400 {string} string "宽度不在白名单或格式不支持"
+// @Failure 404 {string} string "图片不存在"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /img/{w}/{path} [get]
+func (h *ImageHandler) Resize(c *gin.Context) {
+	width, err := strconv.Atoi(c.Param("w"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "无效的宽度")
+		return
+	}
+	key := strings.TrimPrefix(c.Param("path"), "/")
+
+	cachePath, err := h.imageService.Resize(key, width, strings.ToLower(c.Query("format")))
+	if err != nil {
+		switch err {
+		case service.ErrImageWidthNotAllowed:
+			c.String(http.StatusBadRequest, "不支持的宽度")
+		case service.ErrImageUnsupported:
+			c.String(http.StatusBadRequest, "不支持的图片格式")
+		case service.ErrImageNotFound:
+			c.String(http.StatusNotFound, "图片不存在")
+		default:
+			logger.Error("Failed to resize image %s to %d: %v", key, width, err)
+			c.String(http.StatusInternalServerError, "图片处理失败")
+		}
+		return
+	}
+
+	// 对象key包含UUID，内容不会变化，可以长期缓存
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.File(cachePath)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// LoginLockHandler 登录锁定管理处理器
+type LoginLockHandler struct {
+	loginGuard service.LoginGuardService
+}
+
+// NewLoginLockHandler 创建登录锁定管理处理器
+func NewLoginLockHandler(loginGuard service.LoginGuardService) *LoginLockHandler {
+	return &LoginLockHandler{
+		loginGuard: loginGuard,
+	}
+}
+
+// List 获取当前锁定列表
+// @Summary 获取登录锁定列表
+// @Description 获取因连续登录失败而被临时锁定的用户名和IP（仅站长可用）
+// @Tags 账号管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]service.LoginLock} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/login-locks [get]
+func (h *LoginLockHandler) List(c *gin.Context) {
+	locks, err := h.loginGuard.ListLocks()
+	if err != nil {
+		response.InternalServerError(c, "获取锁定列表失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, locks)
+}
+
+// Unlock 解除锁定
+// @Summary 解除登录锁定
+// @Description 解除指定用户名或IP的登录锁定，并清除其失败计数（仅站长可用）
+// @Tags 账号管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body service.UnlockLoginRequest true "锁定对象"
+// @Success 200 {object} response.Response "解除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "锁定不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/login-locks/unlock [post]
+func (h *LoginLockHandler) Unlock(c *gin.Context) {
+	var req service.UnlockLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.loginGuard.Unlock(req.Type, req.Value); err != nil {
+		switch err {
+		case service.ErrInvalidLockType:
+			response.BadRequest(c, "无效的锁定类型")
+		case service.ErrLoginLockNotFound:
+			response.NotFound(c, "锁定不存在或已过期")
+		default:
+			response.InternalServerError(c, "解除锁定失败: "+err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "解除成功", nil)
+}
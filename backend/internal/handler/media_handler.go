@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// defaultOrphanMinAgeDays 未引用文件默认至少上传多少天后才视为孤立文件（避免删除正在编辑、尚未保存的文章中的图片）
+const defaultOrphanMinAgeDays = 7
+
+// MediaHandler 媒体库处理器
+type MediaHandler struct {
+	mediaService service.MediaService
+}
+
+// NewMediaHandler 创建媒体库处理器
+func NewMediaHandler(mediaService service.MediaService) *MediaHandler {
+	return &MediaHandler{
+		mediaService: mediaService,
+	}
+}
+
+// List 获取媒体列表
+// @Summary 获取媒体列表
+// @Description 分页获取上传的文件，支持按文件名、类型、上传者和是否被引用筛选
+// @Tags 媒体库
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param keyword query string false "搜索原始文件名或对象key"
+// @Param mime_type query string false "文件类型前缀，如 image/"
+// @Param uploader_id query int false "上传者ID"
+// @Param unused query bool false "true 只看未被引用的文件，false 只看已被引用的文件"
+// @Success 200 {object} response.Response{data=service.MediaListResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/media [get]
+func (h *MediaHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	req := &service.MediaListRequest{
+		Page:     page,
+		PageSize: pageSize,
+		Keyword:  c.Query("keyword"),
+		MimeType: c.Query("mime_type"),
+	}
+
+	// 解析上传者ID
+	if uploaderIDStr := c.Query("uploader_id"); uploaderIDStr != "" {
+		if uploaderID, err := strconv.ParseUint(uploaderIDStr, 10, 32); err == nil {
+			id := uint(uploaderID)
+			req.UploaderID = &id
+		}
+	}
+
+	// 解析是否被引用
+	if unusedStr := c.Query("unused"); unusedStr != "" {
+		if unused, err := strconv.ParseBool(unusedStr); err == nil {
+			req.Unused = &unused
+		}
+	}
+
+	result, err := h.mediaService.List(req)
+	if err != nil {
+		response.InternalServerError(c, "获取媒体列表失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Get 获取媒体详情
+// @Summary 获取媒体详情
+// @Description 获取文件信息及引用该文件的文章（引用关系以最近一次扫描为准）
+// @Tags 媒体库
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "媒体ID"
+// @Success 200 {object} response.Response{data=service.MediaDetail} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "文件不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/media/{id} [get]
+func (h *MediaHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的媒体ID")
+		return
+	}
+
+	detail, err := h.mediaService.Get(uint(id))
+	if err != nil {
+		h.handleError(c, err, "获取媒体详情失败")
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+// Delete 删除媒体
+// @Summary 删除媒体
+// @Description 删除文件及其记录；仍被文章引用时需要传 force=true
+// @Tags 媒体库
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "媒体ID"
+// @Param force query bool false "仍被引用时强制删除"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "文件不存在"
+// @Failure 409 {object} response.Response "文件仍被文章引用"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/media/{id} [delete]
+func (h *MediaHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的媒体ID")
+		return
+	}
+
+	force, _ := strconv.ParseBool(c.DefaultQuery("force", "false"))
+	if err := h.mediaService.Delete(uint(id), force); err != nil {
+		h.handleError(c, err, "删除文件失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// Scan 扫描文章引用
+// @Summary 扫描媒体引用
+// @Description 解析全部文章的正文和封面，重建文件引用记录
+// @Tags 媒体库
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=service.MediaScanResult} "扫描完成"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/media/scan [post]
+func (h *MediaHandler) Scan(c *gin.Context) {
+	result, err := h.mediaService.ScanReferences()
+	if err != nil {
+		response.InternalServerError(c, "扫描媒体引用失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "扫描完成", result)
+}
+
+// Orphans 获取未引用文件
+// @Summary 获取未引用文件报告
+// @Description 列出上传超过指定天数且未被任何文章引用的文件（以最近一次扫描为准）
+// @Tags 媒体库
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param min_age_days query int false "最少上传天数" default(7)
+// @Success 200 {object} response.Response{data=service.MediaOrphanReport} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/media/orphans [get]
+func (h *MediaHandler) Orphans(c *gin.Context) {
+	report, err := h.mediaService.FindOrphans(h.minAge(c))
+	if err != nil {
+		response.InternalServerError(c, "获取未引用文件失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, report)
+}
+
+// CleanupOrphans 清理未引用文件
+// @Summary 清理未引用文件
+// @Description 先重新扫描引用，再删除上传超过指定天数且未被任何文章引用的文件
+// @Tags 媒体库
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param min_age_days query int false "最少上传天数" default(7)
+// @Success 200 {object} response.Response{data=service.MediaCleanupResult} "清理完成"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/media/orphans/cleanup [post]
+func (h *MediaHandler) CleanupOrphans(c *gin.Context) {
+	// 清理前重新扫描，避免按过期的引用记录误删
+	if _, err := h.mediaService.ScanReferences(); err != nil {
+		response.InternalServerError(c, "扫描媒体引用失败: "+err.Error())
+		return
+	}
+
+	result, err := h.mediaService.RemoveOrphans(h.minAge(c))
+	if err != nil {
+		response.InternalServerError(c, "清理未引用文件失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "清理完成", result)
+}
+
+// minAge 解析最少上传天数
+func (h *MediaHandler) minAge(c *gin.Context) time.Duration {
+	days, err := strconv.Atoi(c.DefaultQuery("min_age_days", strconv.Itoa(defaultOrphanMinAgeDays)))
+	if err != nil || days < 0 {
+		days = defaultOrphanMinAgeDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// handleError 统一处理媒体库错误
+func (h *MediaHandler) handleError(c *gin.Context, err error, message string) {
+	switch err {
+	case service.ErrMediaNotFound:
+		response.NotFound(c, "文件不存在")
+	case service.ErrMediaInUse:
+		response.Error(c, 409, "文件仍被文章引用，如需删除请使用强制删除")
+	default:
+		response.InternalServerError(c, message+": "+err.Error())
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// SitemapHandler Sitemap处理器
+type SitemapHandler struct {
+	sitemapService service.SitemapService
+}
+
+// NewSitemapHandler 创建Sitemap处理器
+func NewSitemapHandler(sitemapService service.SitemapService) *SitemapHandler {
+	return &SitemapHandler{
+		sitemapService: sitemapService,
+	}
+}
+
+// Sitemap 站点地图
+// @Summary 站点地图
+// @Description 输出站点地图；URL数量超过单文件上限时输出sitemapindex，指向 /sitemaps/sitemap-N.xml
+// @Tags SEO
+// @Produce xml
+// @Success 200 {string} string "Sitemap文档"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /sitemap.xml [get]
+func (h *SitemapHandler) Sitemap(c *gin.Context) {
+	data, err := h.sitemapService.GetSitemap(requestBaseURL(c))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "生成站点地图失败")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=600")
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
+
+// Part 站点地图分片
+// @Summary 站点地图分片
+// @Description 输出sitemapindex中的某个分片
+// @Tags SEO
+// @Produce xml
+// @Param file path string true "分片文件名，如 sitemap-1.xml"
+// @Success 200 {string} string "Sitemap文档"
+// @Failure 404 {string} string "分片不存在"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /sitemaps/{file} [get]
+func (h *SitemapHandler) Part(c *gin.Context) {
+	// 文件名格式为 sitemap-N.xml
+	file := c.Param("file")
+	if !strings.HasPrefix(file, "sitemap-") || !strings.HasSuffix(file, ".xml") {
+		c.String(http.StatusNotFound, "分片不存在")
+		return
+	}
+	index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(file, "sitemap-"), ".xml"))
+	if err != nil {
+		c.String(http.StatusNotFound, "分片不存在")
+		return
+	}
+
+	data, err := h.sitemapService.GetPart(index, requestBaseURL(c))
+	if err != nil {
+		if err == service.ErrSitemapPartNotFound {
+			c.String(http.StatusNotFound, "分片不存在")
+			return
+		}
+		c.String(http.StatusInternalServerError, "生成站点地图失败")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=600")
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
+
+// Robots robots.txt
+// @Summary robots.txt
+// @Description 输出系统配置中的robots.txt（未配置时使用默认规则），并自动附加Sitemap地址
+// @Tags SEO
+// @Produce plain
+// @Success 200 {string} string "robots.txt内容"
+// @Router /robots.txt [get]
+func (h *SitemapHandler) Robots(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=600")
+	c.String(http.StatusOK, h.sitemapService.GetRobotsTxt(requestBaseURL(c)))
+}
@@ -169,3 +169,40 @@ func (h *StatsHandler) GetReferrerStats(c *gin.Context) {
 
 	response.Success(c, stats)
 }
+
+// GetVisitIngestStats 获取访问记录写入统计
+// @Summary 获取访问记录写入统计
+// @Description 获取访问记录写入队列的排队数、累计入队/拒绝/写入/失败数等背压指标。dropped 持续增长说明队列容量或写入速度不足
+// @Tags 统计
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=service.VisitIngestStats} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /admin/stats/visit-ingest [get]
+func (h *StatsHandler) GetVisitIngestStats(c *gin.Context) {
+	response.Success(c, h.statsService.GetVisitIngestStats())
+}
+
+// GetSessionStats 获取会话统计
+// @Summary 获取会话统计
+// @Description 将同一指纹的访问按不活动间隔（配置 visit.session_gap，默认30分钟）切分为会话，统计会话数、跳出率、每个会话的平均页面数、平均会话时长以及入口/退出页面。结束日期包含当天
+// @Tags 统计
+// @Produce json
+// @Security BearerAuth
+// @Param start_date query string false "开始日期 (YYYY-MM-DD)"
+// @Param end_date query string false "结束日期 (YYYY-MM-DD)"
+// @Success 200 {object} response.Response{data=service.SessionStatsResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/stats/sessions [get]
+func (h *StatsHandler) GetSessionStats(c *gin.Context) {
+	startDate, endDate := parseStatsDateRange(c)
+
+	stats, err := h.statsService.GetSessionStats(startDate, endDate)
+	if err != nil {
+		response.InternalServerError(c, "获取会话统计失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, stats)
+}
@@ -1,130 +1,193 @@
 package handler
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
-	"os"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/filetype"
 	imgutil "github.com/whk-newbie/blog/internal/pkg/image"
+	"github.com/whk-newbie/blog/internal/pkg/logger"
 	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/pkg/storage"
+	"github.com/whk-newbie/blog/internal/service"
 )
 
 // UploadHandler 上传处理器
 type UploadHandler struct {
-	uploadDir string // 上传目录
-	maxSize   int64  // 最大文件大小（字节）
+	backend          storage.Backend      // 存储后端
+	mediaService     service.MediaService // 媒体库
+	imagePolicy      filetype.Policy      // 图片上传策略
+	attachmentPolicy filetype.Policy      // 附件上传策略
+	variantWidths    []int                // 生成的图片宽度变体
+	keepCopyright    bool                 // 去除元数据时保留作者和版权信息
 }
 
 // NewUploadHandler 创建上传处理器
-func NewUploadHandler(uploadDir string, maxSizeMB int64) *UploadHandler {
-	// 确保上传目录存在
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		panic(fmt.Sprintf("failed to create upload directory: %v", err))
-	}
-
+func NewUploadHandler(backend storage.Backend, mediaService service.MediaService, imagePolicy, attachmentPolicy filetype.Policy, variantWidths []int, keepCopyright bool) *UploadHandler {
 	return &UploadHandler{
-		uploadDir: uploadDir,
-		maxSize:   maxSizeMB * 1024 * 1024, // 转换为字节
+		backend:          backend,
+		mediaService:     mediaService,
+		imagePolicy:      imagePolicy,
+		attachmentPolicy: attachmentPolicy,
+		variantWidths:    variantWidths,
+		keepCopyright:    keepCopyright,
 	}
 }
 
 // UploadImageResponse 上传图片响应
 type UploadImageResponse struct {
+	ID         uint   `json:"id"` // 媒体ID
 	URL        string `json:"url"`
 	Filename   string `json:"filename"`
 	Size       int64  `json:"size"`
 	Width      int    `json:"width,omitempty"`
 	Height     int    `json:"height,omitempty"`
 	Compressed bool   `json:"compressed,omitempty"` // 是否被压缩
+	// 宽度变体（WebP和原图格式）
+	Variants []models.MediaVariant `json:"variants,omitempty"`
+	// 按MIME类型分组的srcset（包含原图），可直接用于 <picture><source type srcset>
+	Srcset map[string]string `json:"srcset,omitempty"`
+}
+
+// UploadAttachmentResponse 上传附件响应
+type UploadAttachmentResponse struct {
+	ID       uint   `json:"id"` // 媒体ID
+	URL      string `json:"url"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"` // 按文件内容识别的类型
 }
 
 // UploadImage 上传图片
 // @Summary 上传图片
-// @Description 上传文章图片
+// @Description 上传文章图片，按EXIF方向旋转并去除元数据（GPS等），生成宽度变体（WebP和原图格式），并记录到媒体库
 // @Tags 上传
 // @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
 // @Param file formData file true "图片文件"
-// @Success 200 {object} response.Response "上传成功"
+// @Success 200 {object} response.Response{data=UploadImageResponse} "上传成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 413 {object} response.Response "文件太大"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /admin/upload/image [post]
 func (h *UploadHandler) UploadImage(c *gin.Context) {
-	// 获取上传的文件
-	file, err := c.FormFile("file")
-	if err != nil {
-		response.BadRequest(c, "请选择要上传的文件")
+	file, data, mimeType, ok := h.readFile(c, h.imagePolicy)
+	if !ok {
 		return
 	}
-
-	// 检查文件大小
-	if file.Size > h.maxSize {
-		response.BadRequest(c, fmt.Sprintf("文件大小不能超过 %dMB", h.maxSize/1024/1024))
+	if !filetype.IsImage(mimeType) {
+		response.BadRequest(c, "只支持图片文件（"+strings.Join(h.imagePolicy.AllowedExtensions(), ", ")+"）")
 		return
 	}
 
-	// 检查文件类型
-	if !h.isImage(file) {
-		response.BadRequest(c, "只支持图片文件（jpg, jpeg, png, gif, webp）")
+	// 生成唯一文件名（扩展名按文件内容确定），按年月组织目录
+	dateDir := time.Now().Format("2006/01")
+	originalKey := fmt.Sprintf("%s/%s%s", dateDir, uuid.New().String(), filetype.Extension(mimeType))
+
+	// 按EXIF方向规范化并去除元数据（GPS坐标、设备信息等），之后的压缩和变体都基于处理后的数据
+	format := strings.TrimPrefix(mimeType, "image/")
+	data, err := imgutil.Sanitize(data, format, imgutil.SanitizeOptions{KeepCopyright: h.keepCopyright})
+	if err != nil {
+		response.BadRequest(c, "文件已损坏或无法解析")
 		return
 	}
 
-	// 生成唯一文件名（先使用原始扩展名）
-	ext := filepath.Ext(file.Filename)
-	originalFilename := fmt.Sprintf("%s%s", uuid.New().String(), ext)
-
-	// 创建日期子目录（按年月组织）
-	dateDir := time.Now().Format("2006/01")
-	targetDir := filepath.Join(h.uploadDir, dateDir)
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		response.InternalServerError(c, "创建目录失败")
+	// 完整解码并检查是否夹带其他内容（多格式文件）
+	if err := imgutil.Validate(data, format); err != nil {
+		h.respondInvalidContent(c, err)
 		return
 	}
 
 	// 先保存原始文件
-	originalPath := filepath.Join(targetDir, originalFilename)
-	if err := c.SaveUploadedFile(file, originalPath); err != nil {
+	ctx := c.Request.Context()
+	if err := h.backend.Put(ctx, originalKey, bytes.NewReader(data), int64(len(data)), mimeType); err != nil {
 		response.InternalServerError(c, "保存文件失败: "+err.Error())
 		return
 	}
 
 	// 压缩图片（如果超过1MB）
-	finalPath, finalSize, err := imgutil.CompressImage(originalPath)
+	finalKey, finalSize, err := imgutil.CompressImage(ctx, h.backend, originalKey)
 	if err != nil {
 		// 压缩失败，使用原文件
-		finalPath = originalPath
-		finalSize = file.Size
+		finalKey = originalKey
+		finalSize = int64(len(data))
 	}
 
-	// 获取最终文件名
-	finalFilename := filepath.Base(finalPath)
-	fileURL := fmt.Sprintf("/uploads/%s/%s", dateDir, finalFilename)
-
-	// 获取图片尺寸
+	// 获取图片尺寸（压缩只转换格式，尺寸与原图一致）
 	width, height := 0, 0
-	if w, h, err := imgutil.GetImageDimensions(finalPath); err == nil {
+	if w, h, err := imgutil.GetImageDimensionsFromReader(bytes.NewReader(data)); err == nil {
 		width, height = w, h
 	}
 
 	// 判断是否被压缩
-	compressed := finalPath != originalPath
+	compressed := finalKey != originalKey
+
+	// 生成宽度变体（失败时保留已生成的部分）
+	variants := make([]models.MediaVariant, 0)
+	generated, err := imgutil.GenerateVariants(ctx, h.backend, originalKey, bytes.NewReader(data), h.variantWidths)
+	if err != nil {
+		logger.Warn("Failed to generate image variants for %s: %v", originalKey, err)
+	}
+	for _, v := range generated {
+		variants = append(variants, models.MediaVariant{
+			Width:    v.Width,
+			Height:   v.Height,
+			Key:      v.Key,
+			URL:      h.backend.URL(v.Key),
+			MimeType: v.MimeType,
+			Size:     v.Size,
+		})
+	}
+	variantsJSON, _ := json.Marshal(variants)
+
+	// 记录到媒体库
+	media := &models.Media{
+		StorageKey:   finalKey,
+		URL:          h.backend.URL(finalKey),
+		OriginalName: file.Filename,
+		MimeType:     mime.TypeByExtension(filepath.Ext(finalKey)),
+		Size:         finalSize,
+		Width:        width,
+		Height:       height,
+		Variants:     variantsJSON,
+	}
+	if userID := c.GetUint("userID"); userID > 0 {
+		media.UploaderID = &userID
+	}
+	if err := h.mediaService.Record(media); err != nil {
+		// 没有记录的文件无法被管理，删除后返回错误
+		for _, v := range variants {
+			_ = h.backend.Delete(ctx, v.Key)
+		}
+		_ = h.backend.Delete(ctx, finalKey)
+		response.InternalServerError(c, "保存文件信息失败: "+err.Error())
+		return
+	}
 
 	response.SuccessWithMessage(c, "上传成功", UploadImageResponse{
-		URL:        fileURL,
+		ID:         media.ID,
+		URL:        media.URL,
 		Filename:   file.Filename,
 		Size:       finalSize,
 		Width:      width,
 		Height:     height,
 		Compressed: compressed,
+		Variants:   variants,
+		Srcset:     buildSrcset(variants, media.URL, media.MimeType, width),
 	})
 }
 
@@ -147,74 +210,190 @@ func (h *UploadHandler) UploadArticleImage(c *gin.Context) {
 	h.UploadImage(c)
 }
 
-// isImage 检查是否为图片文件
-func (h *UploadHandler) isImage(file *multipart.FileHeader) bool {
-	// 检查扩展名
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowedExts := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".gif":  true,
-		".webp": true,
+// UploadAttachment 上传文章附件
+// @Summary 上传附件
+// @Description 上传文章附件（PDF、Zip等），按文件内容识别类型并检查文件结构，拒绝拼接了其他内容的文件；附件记录到媒体库
+// @Tags 上传
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "附件文件"
+// @Success 200 {object} response.Response{data=UploadAttachmentResponse} "上传成功"
+// @Failure 400 {object} response.Response "文件类型不支持或内容不合法"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 413 {object} response.Response "文件太大"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/upload/attachment [post]
+func (h *UploadHandler) UploadAttachment(c *gin.Context) {
+	file, data, mimeType, ok := h.readFile(c, h.attachmentPolicy)
+	if !ok {
+		return
+	}
+
+	var err error
+	if filetype.IsImage(mimeType) {
+		err = imgutil.Validate(data, strings.TrimPrefix(mimeType, "image/"))
+	} else {
+		err = filetype.ValidateDocument(data, mimeType)
+	}
+	if err != nil {
+		h.respondInvalidContent(c, err)
+		return
 	}
 
-	if !allowedExts[ext] {
-		return false
+	key := fmt.Sprintf("%s/%s%s", time.Now().Format("2006/01"), uuid.New().String(), filetype.Extension(mimeType))
+	ctx := c.Request.Context()
+	if err := h.backend.Put(ctx, key, bytes.NewReader(data), int64(len(data)), mimeType); err != nil {
+		response.InternalServerError(c, "保存文件失败: "+err.Error())
+		return
+	}
+
+	// 记录到媒体库
+	media := &models.Media{
+		StorageKey:   key,
+		URL:          h.backend.URL(key),
+		OriginalName: file.Filename,
+		MimeType:     mimeType,
+		Size:         int64(len(data)),
+	}
+	if userID := c.GetUint("userID"); userID > 0 {
+		media.UploaderID = &userID
+	}
+	if err := h.mediaService.Record(media); err != nil {
+		_ = h.backend.Delete(ctx, key)
+		response.InternalServerError(c, "保存文件信息失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "上传成功", UploadAttachmentResponse{
+		ID:       media.ID,
+		URL:      media.URL,
+		Filename: file.Filename,
+		Size:     media.Size,
+		MimeType: mimeType,
+	})
+}
+
+// ServeFile 读取上传的文件
+// @Summary 读取上传文件
+// @Description 从存储后端读取上传的文件（对象存储未配置公开访问地址时使用）
+// @Tags 上传
+// @Produce octet-stream
+// @Param filepath path string true "文件路径，如 2024/01/xxx.webp"
+// @Success 200 {file} file "文件内容"
+// @Failure 404 {string} string "文件不存在"
+// @Failure 500 {string} string "服务器内部错误"
+// @Router /uploads/{filepath} [get]
+func (h *UploadHandler) ServeFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("filepath"), "/")
+
+	reader, err := h.backend.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			c.String(http.StatusNotFound, "文件不存在")
+			return
+		}
+		c.String(http.StatusInternalServerError, "读取文件失败")
+		return
+	}
+	defer reader.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// 文件名包含UUID，内容不会变化，可以长期缓存；禁止浏览器按内容猜测类型
+	c.DataFromReader(http.StatusOK, -1, contentType, reader, map[string]string{
+		"Cache-Control":          "public, max-age=31536000, immutable",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// buildSrcset 按MIME类型生成srcset，原图以其实际宽度加入同格式的srcset
+func buildSrcset(variants []models.MediaVariant, url, mimeType string, width int) map[string]string {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	candidates := make(map[string][]string)
+	for _, v := range variants {
+		candidates[v.MimeType] = append(candidates[v.MimeType], fmt.Sprintf("%s %dw", v.URL, v.Width))
+	}
+	if width > 0 && mimeType != "" {
+		candidates[mimeType] = append(candidates[mimeType], fmt.Sprintf("%s %dw", url, width))
+	}
+
+	srcset := make(map[string]string, len(candidates))
+	for t, list := range candidates {
+		srcset[t] = strings.Join(list, ", ")
+	}
+	return srcset
+}
+
+// readFile 读取上传的文件，检查大小，并按文件内容（魔数）识别类型
+// 类型必须在策略允许的范围内，且扩展名与内容一致；检查失败时已写入响应
+func (h *UploadHandler) readFile(c *gin.Context, policy filetype.Policy) (*multipart.FileHeader, []byte, string, bool) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "请选择要上传的文件")
+		return nil, nil, "", false
+	}
+
+	tooLarge := fmt.Sprintf("文件大小不能超过 %s", formatFileSize(policy.MaxSize))
+	if file.Size > policy.MaxSize {
+		response.Error(c, http.StatusRequestEntityTooLarge, tooLarge)
+		return nil, nil, "", false
 	}
 
-	// 检查MIME类型
 	src, err := file.Open()
 	if err != nil {
-		return false
+		response.InternalServerError(c, "读取文件失败: "+err.Error())
+		return nil, nil, "", false
 	}
 	defer src.Close()
 
-	// 读取文件头（前512字节）
-	buffer := make([]byte, 512)
-	_, err = src.Read(buffer)
-	if err != nil && err != io.EOF {
-		return false
-	}
-
-	// 检测MIME类型
-	contentType := ""
-	if len(buffer) > 0 {
-		// 简单的MIME类型检测
-		if len(buffer) >= 2 {
-			// JPEG
-			if buffer[0] == 0xFF && buffer[1] == 0xD8 {
-				contentType = "image/jpeg"
-			}
-		}
-		if len(buffer) >= 8 {
-			// PNG
-			if buffer[0] == 0x89 && buffer[1] == 0x50 && buffer[2] == 0x4E && buffer[3] == 0x47 {
-				contentType = "image/png"
-			}
-		}
-		if len(buffer) >= 6 {
-			// GIF
-			if buffer[0] == 0x47 && buffer[1] == 0x49 && buffer[2] == 0x46 {
-				contentType = "image/gif"
-			}
-		}
-		if len(buffer) >= 12 {
-			// WebP
-			if buffer[0] == 0x52 && buffer[1] == 0x49 && buffer[2] == 0x46 && buffer[3] == 0x46 &&
-				buffer[8] == 0x57 && buffer[9] == 0x45 && buffer[10] == 0x42 && buffer[11] == 0x50 {
-				contentType = "image/webp"
-			}
-		}
+	data, err := io.ReadAll(io.LimitReader(src, policy.MaxSize+1))
+	if err != nil {
+		response.InternalServerError(c, "读取文件失败: "+err.Error())
+		return nil, nil, "", false
+	}
+	if int64(len(data)) > policy.MaxSize {
+		response.Error(c, http.StatusRequestEntityTooLarge, tooLarge)
+		return nil, nil, "", false
 	}
 
-	allowedTypes := map[string]bool{
-		"image/jpeg": true,
-		"image/jpg":  true,
-		"image/png":  true,
-		"image/gif":  true,
-		"image/webp": true,
+	// 不信任客户端提交的Content-Type，按文件内容识别
+	mimeType := filetype.Detect(data)
+	if mimeType == "" || !policy.Allows(mimeType) {
+		response.BadRequest(c, "只支持以下文件类型："+strings.Join(policy.AllowedExtensions(), ", "))
+		return nil, nil, "", false
 	}
+	if err := filetype.CheckExtension(file.Filename, mimeType); err != nil {
+		response.BadRequest(c, "文件扩展名与文件内容不符")
+		return nil, nil, "", false
+	}
+
+	return file, data, mimeType, true
+}
 
-	return allowedTypes[contentType]
+// respondInvalidContent 文件内容检查失败的响应
+func (h *UploadHandler) respondInvalidContent(c *gin.Context, err error) {
+	if errors.Is(err, filetype.ErrEmbeddedContent) {
+		response.BadRequest(c, "文件包含其他类型的内容")
+		return
+	}
+	response.BadRequest(c, "文件已损坏或无法解析")
+}
+
+// formatFileSize 格式化文件大小（用于提示信息）
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1024*1024 && size%(1024*1024) == 0:
+		return fmt.Sprintf("%dMB", size/1024/1024)
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(size)/1024/1024)
+	default:
+		return fmt.Sprintf("%dKB", size/1024)
+	}
 }
@@ -1,7 +1,12 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
 	"github.com/whk-newbie/blog/internal/pkg/response"
 	"github.com/whk-newbie/blog/internal/service"
 )
@@ -20,14 +25,15 @@ func NewVisitHandler(visitService service.VisitService) *VisitHandler {
 
 // RecordVisit 记录访问
 // @Summary 记录访问
-// @Description 记录访问行为
+// @Description 记录访问行为。访问记录进入写入队列后由后台批量写入，队列已满时返回503。返回的 visit_id 用于页面离开时上报离开信标。配置了GeoIP数据库时按客户端IP记录访问地区
 // @Tags 访问统计
 // @Accept json
 // @Produce json
 // @Param body body service.RecordVisitRequest true "访问信息"
-// @Success 200 {object} response.Response "记录成功"
-// @Failure 400 {object} response.Response "请求参数错误"
+// @Success 200 {object} response.Response{data=service.RecordVisitResponse} "记录成功"
+// @Failure 400 {object} response.Response "请求参数错误（URL超过500个字符，或指纹、文章不存在）"
 // @Failure 500 {object} response.Response "服务器内部错误"
+// @Failure 503 {object} response.Response "访问记录繁忙"
 // @Router /visit [post]
 func (h *VisitHandler) RecordVisit(c *gin.Context) {
 	var req service.RecordVisitRequest
@@ -40,11 +46,66 @@ func (h *VisitHandler) RecordVisit(c *gin.Context) {
 	if req.UserAgent == "" {
 		req.UserAgent = c.GetHeader("User-Agent")
 	}
+	// 客户端IP（经过受信任代理时取X-Forwarded-For），写入前按配置截断并解析归属地
+	req.IP = c.ClientIP()
 
-	if err := h.visitService.RecordVisit(&req); err != nil {
+	resp, err := h.visitService.RecordVisit(&req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidVisit) {
+			response.BadRequest(c, "请求参数错误: "+err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrVisitQueueFull) {
+			c.Header("Retry-After", "1")
+			response.Error(c, http.StatusServiceUnavailable, "访问记录繁忙，请稍后重试")
+			return
+		}
 		response.InternalServerError(c, "记录访问失败: "+err.Error())
 		return
 	}
 
+	response.Success(c, resp)
+}
+
+// Beacon 上报离开信标
+// @Summary 上报离开信标
+// @Description 页面隐藏或离开时上报停留时间和最大滚动深度，可多次上报，只保留最大值。兼容 navigator.sendBeacon：同时接受POST，请求体按JSON解析，不要求Content-Type为application/json
+// @Tags 访问统计
+// @Accept json
+// @Produce json
+// @Param id path string true "访问标识（记录访问时返回的 visit_id）"
+// @Param body body service.VisitBeacon true "停留时间和滚动深度"
+// @Success 200 {object} response.Response "上报成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "访问记录不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /visit/{id}/beacon [put]
+// @Router /visit/{id}/beacon [post]
+func (h *VisitHandler) Beacon(c *gin.Context) {
+	visitKey, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "无效的访问标识")
+		return
+	}
+
+	// sendBeacon发送字符串时Content-Type为text/plain，按JSON解析
+	var beacon service.VisitBeacon
+	if err := c.ShouldBindWith(&beacon, binding.JSON); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.visitService.UpdateBeacon(visitKey.String(), &beacon); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidVisitBeacon):
+			response.BadRequest(c, "停留时间不能为负数，滚动深度应在0-100之间，且至少上报一项")
+		case errors.Is(err, service.ErrVisitNotFound):
+			response.NotFound(c, "访问记录不存在")
+		default:
+			response.InternalServerError(c, "上报离开信标失败: "+err.Error())
+		}
+		return
+	}
+
 	response.Success(c, nil)
 }
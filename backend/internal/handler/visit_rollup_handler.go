@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/pkg/response"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// VisitRollupHandler 访问统计日汇总处理器
+type VisitRollupHandler struct {
+	rollupService service.VisitRollupService
+}
+
+// NewVisitRollupHandler 创建访问统计日汇总处理器
+func NewVisitRollupHandler(rollupService service.VisitRollupService) *VisitRollupHandler {
+	return &VisitRollupHandler{
+		rollupService: rollupService,
+	}
+}
+
+// GetStatus 获取汇总状态
+// @Summary 获取访问统计日汇总状态
+// @Description 获取已汇总的日期范围和最近一次汇总任务的进度。访问统计对 last_day 及以前的日期读取日汇总表，之后的日期（至少包括今天）读取访问记录
+// @Tags 统计
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=service.VisitRollupStatus} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/stats/rollups [get]
+func (h *VisitRollupHandler) GetStatus(c *gin.Context) {
+	status, err := h.rollupService.GetStatus()
+	if err != nil {
+		response.InternalServerError(c, "获取汇总状态失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// Backfill 补齐未汇总的日期
+// @Summary 补齐访问统计日汇总
+// @Description 在后台汇总从最早的访问记录到昨天之间所有尚未汇总的日期，已汇总的日期跳过。进度见汇总状态
+// @Tags 统计
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=service.VisitRollupTask} "汇总已开始"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 409 {object} response.Response "汇总任务正在执行"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/stats/rollups/backfill [post]
+func (h *VisitRollupHandler) Backfill(c *gin.Context) {
+	task, err := h.rollupService.StartBackfill()
+	if err != nil {
+		h.handleStartError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "汇总已开始", task)
+}
+
+// Reaggregate 重新汇总指定日期范围
+// @Summary 重新汇总访问统计
+// @Description 在后台按访问记录重新汇总指定日期范围（含首尾）的日汇总数据，覆盖已有汇总。结束日期晚于昨天时按昨天处理，当天不汇总；开始日期早于最早的访问记录时从最早的访问记录开始。一次最多366天
+// @Tags 统计
+// @Produce json
+// @Security BearerAuth
+// @Param start_date query string true "开始日期 (YYYY-MM-DD)"
+// @Param end_date query string true "结束日期 (YYYY-MM-DD)"
+// @Success 200 {object} response.Response{data=service.VisitRollupTask} "汇总已开始"
+// @Failure 400 {object} response.Response "日期参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 409 {object} response.Response "汇总任务正在执行"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/stats/rollups/reaggregate [post]
+func (h *VisitRollupHandler) Reaggregate(c *gin.Context) {
+	startDate, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		response.BadRequest(c, "开始日期格式错误，应为 YYYY-MM-DD")
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil {
+		response.BadRequest(c, "结束日期格式错误，应为 YYYY-MM-DD")
+		return
+	}
+
+	task, err := h.rollupService.StartReaggregate(startDate, endDate)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRollupRange) {
+			response.BadRequest(c, "开始日期不能晚于结束日期，只能汇总昨天及以前的日期，且一次最多366天")
+			return
+		}
+		h.handleStartError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "汇总已开始", task)
+}
+
+// handleStartError 处理开始汇总任务时的错误
+func (h *VisitRollupHandler) handleStartError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrRollupInProgress) {
+		response.Error(c, http.StatusConflict, "汇总任务正在执行")
+		return
+	}
+	response.InternalServerError(c, "开始汇总失败: "+err.Error())
+}
@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	gorillaWS "github.com/gorilla/websocket"
+	"github.com/whk-newbie/blog/internal/models"
 	"github.com/whk-newbie/blog/internal/pkg/jwt"
+	"github.com/whk-newbie/blog/internal/pkg/logger"
+	"github.com/whk-newbie/blog/internal/service"
 	"github.com/whk-newbie/blog/internal/websocket"
 )
 
@@ -20,15 +24,17 @@ var upgrader = gorillaWS.Upgrader{
 
 // WebSocketHandler WebSocket处理器
 type WebSocketHandler struct {
-	hub        *websocket.Hub
-	jwtManager *jwt.Manager
+	hub          *websocket.Hub
+	jwtManager   *jwt.Manager
+	crawlService service.CrawlService
 }
 
 // NewWebSocketHandler 创建WebSocket处理器
-func NewWebSocketHandler(hub *websocket.Hub, jwtManager *jwt.Manager) *WebSocketHandler {
+func NewWebSocketHandler(hub *websocket.Hub, jwtManager *jwt.Manager, crawlService service.CrawlService) *WebSocketHandler {
 	return &WebSocketHandler{
-		hub:        hub,
-		jwtManager: jwtManager,
+		hub:          hub,
+		jwtManager:   jwtManager,
+		crawlService: crawlService,
 	}
 }
 
@@ -78,3 +84,78 @@ func (h *WebSocketHandler) HandleCrawlerTasks(c *gin.Context) {
 	// 记录用户信息（可选）
 	_ = claims
 }
+
+// HandleCrawlerControl 处理爬虫控制通道WebSocket连接
+// @Summary 爬虫控制通道
+// @Description 爬虫使用Token建立WebSocket连接，接收管理员下发的任务控制命令 {"type":"task_command","data":{"task_id","command","status"}}，command 为 pause/resume/cancel。连接建立时会补发尚未确认的命令。执行后发送 {"type":"command_ack","data":{"task_id","command"}} 确认，服务端回复 command_ack_result
+// @Tags 爬虫任务
+// @Security BearerAuth
+// @Success 101 {object} nil "WebSocket连接成功"
+// @Failure 400 {object} github_com_whk-newbie_blog_internal_pkg_response.Response "参数错误"
+// @Failure 401 {object} github_com_whk-newbie_blog_internal_pkg_response.Response "未授权"
+// @Router /ws/crawler/control [get]
+func (h *WebSocketHandler) HandleCrawlerControl(c *gin.Context) {
+	// 获取Token（由爬虫认证中间件验证）
+	token, _ := c.Get("crawlerToken")
+	tokenStr := token.(string)
+
+	// 升级为WebSocket连接
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "WebSocket升级失败"})
+		return
+	}
+
+	// 创建客户端
+	client := websocket.NewCrawlerClient(h.hub, conn, tokenStr, h.handleCrawlerMessage(tokenStr))
+
+	// 注册客户端
+	h.hub.Register(client)
+
+	// 补发尚未确认的命令（爬虫离线期间下发的命令）
+	tasks, err := h.crawlService.ListPendingCommands(tokenStr)
+	if err != nil {
+		logger.Error("Failed to list pending crawl task commands: %v", err)
+	}
+	for i := range tasks {
+		client.SendJSON(websocket.TaskCommandMessage{
+			Type: "task_command",
+			Data: websocket.TaskCommandData{
+				TaskID:  tasks[i].TaskID,
+				Command: string(tasks[i].Status.PendingCommand()),
+				Status:  string(tasks[i].Status),
+			},
+		})
+	}
+
+	// 启动读写协程
+	go client.WritePump()
+	go client.ReadPump()
+}
+
+// handleCrawlerMessage 处理爬虫发来的命令确认
+func (h *WebSocketHandler) handleCrawlerMessage(token string) websocket.MessageHandler {
+	return func(client *websocket.Client, message []byte) {
+		var msg websocket.CommandAckMessage
+		if err := json.Unmarshal(message, &msg); err != nil || msg.Type != "command_ack" {
+			return
+		}
+
+		result := websocket.CommandAckResultData{
+			TaskID:  msg.Data.TaskID,
+			Command: msg.Data.Command,
+		}
+		task, err := h.crawlService.AcknowledgeCommand(msg.Data.TaskID, models.CrawlTaskCommand(msg.Data.Command), token)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+			result.Status = string(task.Status)
+		}
+
+		client.SendJSON(websocket.CommandAckResultMessage{
+			Type: "command_ack_result",
+			Data: result,
+		})
+	}
+}
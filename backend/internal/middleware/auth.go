@@ -4,8 +4,10 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/whk-newbie/blog/internal/models"
 	"github.com/whk-newbie/blog/internal/pkg/jwt"
 	"github.com/whk-newbie/blog/internal/pkg/logger"
+	"github.com/whk-newbie/blog/internal/pkg/rbac"
 	"github.com/whk-newbie/blog/internal/pkg/response"
 )
 
@@ -47,6 +49,8 @@ func Auth(jwtManager *jwt.Manager) gin.HandlerFunc {
 				response.Unauthorized(c, "Token尚未生效")
 			case jwt.ErrTokenMalformed:
 				response.Unauthorized(c, "Token格式错误")
+			case jwt.ErrTokenRevoked:
+				response.Unauthorized(c, "登录已失效，请重新登录")
 			default:
 				response.Unauthorized(c, "Token无效")
 			}
@@ -54,9 +58,18 @@ func Auth(jwtManager *jwt.Manager) gin.HandlerFunc {
 			return
 		}
 
+		// 升级角色体系之前签发的Token不含角色，需要重新登录
+		if claims.Role == "" {
+			response.Unauthorized(c, "登录信息已过期，请重新登录")
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存入上下文
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Set("sessionID", claims.SessionID)
 		c.Set("claims", claims) // 也设置claims，供某些handler使用
 
 		logger.Info("Auth success - UserID: %d, Username: %s, Path: %s",
@@ -86,6 +99,29 @@ func OptionalAuth(jwtManager *jwt.Manager) gin.HandlerFunc {
 		if err == nil {
 			c.Set("userID", claims.UserID)
 			c.Set("username", claims.Username)
+			c.Set("role", claims.Role)
+		}
+
+		c.Next()
+	}
+}
+
+// Permission 权限中间件（需放在Auth之后），按请求路由校验当前角色是否拥有所需权限
+func Permission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		perm, ok := rbac.RequiredPermission(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+
+		role := c.GetString("role")
+		if !rbac.HasPermission(models.AdminRole(role), perm) {
+			logger.Warn("Permission denied - UserID: %v, Role: %s, Permission: %s, Path: %s",
+				c.Value("userID"), role, perm, c.Request.URL.Path)
+			response.Forbidden(c, "权限不足")
+			c.Abort()
+			return
 		}
 
 		c.Next()
@@ -0,0 +1,13 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// NoSniff 禁止浏览器按内容猜测响应类型（用于用户上传的文件）
+func NoSniff() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Next()
+	}
+}
@@ -3,24 +3,47 @@ package models
 import (
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
+// AdminRole 管理员角色
+type AdminRole string
+
+const (
+	AdminRoleOwner  AdminRole = "owner"  // 站长：全部权限，包括账号管理和系统配置
+	AdminRoleEditor AdminRole = "editor" // 编辑：管理所有文章、分类标签和评论
+	AdminRoleAuthor AdminRole = "author" // 作者：只能编辑自己的文章
+	AdminRoleViewer AdminRole = "viewer" // 访客：只读
+)
+
+// IsValid 是否为有效角色
+func (r AdminRole) IsValid() bool {
+	switch r {
+	case AdminRoleOwner, AdminRoleEditor, AdminRoleAuthor, AdminRoleViewer:
+		return true
+	}
+	return false
+}
+
 // Admin 管理员模型
 type Admin struct {
-	ID                uint           `gorm:"primaryKey" json:"id"`
-	Username          string         `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
-	Password          string         `gorm:"type:varchar(255);not null" json:"-"` // 不在JSON中返回密码
-	Email             string         `gorm:"type:varchar(100)" json:"email"`
-	IsDefaultPassword bool           `gorm:"default:true" json:"is_default_password"` // 是否使用默认密码
-	LastLoginAt       *time.Time     `json:"last_login_at"`
-	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at"`
-	DeletedAt         gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	ID                     uint           `gorm:"primaryKey" json:"id"`
+	Username               string         `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
+	Password               string         `gorm:"type:varchar(255);not null" json:"-"` // 不在JSON中返回密码
+	Email                  string         `gorm:"type:varchar(100)" json:"email"`
+	Role                   AdminRole      `gorm:"type:varchar(20);not null;default:'author'" json:"role"` // 角色
+	IsDefaultPassword      bool           `gorm:"default:true" json:"is_default_password"`                // 是否使用默认密码
+	TwoFactorEnabled       bool           `gorm:"default:false" json:"two_factor_enabled"`                // 是否启用两步验证
+	TwoFactorSecret        string         `gorm:"type:varchar(255)" json:"-"`                             // TOTP密钥（AES-GCM加密，未启用时为待验证的密钥）
+	TwoFactorRecoveryCodes datatypes.JSON `gorm:"type:jsonb" json:"-"`                                    // 未使用的恢复码（SHA-256哈希）
+	LastLoginAt            *time.Time     `json:"last_login_at"`
+	CreatedAt              time.Time      `json:"created_at"`
+	UpdatedAt              time.Time      `json:"updated_at"`
+	DeletedAt              gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 // TableName 指定表名
 func (Admin) TableName() string {
 	return "admins"
 }
-
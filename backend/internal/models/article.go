@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -14,25 +15,44 @@ const (
 	ArticleStatusPublished ArticleStatus = "published" // 已发布
 )
 
+// ContentFormat 正文格式
+type ContentFormat string
+
+const (
+	ContentFormatHTML     ContentFormat = "html"     // 富文本（HTML）
+	ContentFormatMarkdown ContentFormat = "markdown" // Markdown
+)
+
 // Article 文章模型
 type Article struct {
-	ID         uint           `gorm:"primaryKey" json:"id"`
-	Title      string         `gorm:"type:varchar(255);not null" json:"title"`
-	Slug       string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
-	Summary    string         `gorm:"type:text" json:"summary"`                            // 文章摘要
-	Content    string         `gorm:"type:text;not null" json:"content"`                   // 富文本内容（HTML）
-	CoverImage string         `gorm:"type:varchar(500)" json:"cover_image"`                // 封面图片URL
-	CategoryID *uint          `gorm:"index" json:"category_id"`                            // 分类ID
-	Status     ArticleStatus  `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"` // 文章状态
-	PublishAt  *time.Time     `gorm:"index" json:"publish_at"`                             // 发布时间（可预设未来时间）
-	ViewCount  int            `gorm:"default:0;index" json:"view_count"`                   // 浏览次数
-	LikeCount  int            `gorm:"default:0" json:"like_count"`                         // 点赞数（预留）
-	IsTop      bool           `gorm:"default:false;index" json:"is_top"`                   // 是否置顶
-	IsFeatured bool           `gorm:"default:false" json:"is_featured"`                    // 是否推荐
-	AuthorID   *uint          `gorm:"index" json:"author_id"`                              // 作者ID
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	Title         string         `gorm:"type:varchar(255);not null" json:"title"`
+	Slug          string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"slug"`
+	Summary       string         `gorm:"type:text" json:"summary"`                                       // 文章摘要
+	Content       string         `gorm:"type:text;not null" json:"content"`                              // 渲染并清洗后的HTML
+	ContentFormat ContentFormat  `gorm:"type:varchar(20);not null;default:'html'" json:"content_format"` // 正文格式
+	ContentSource string         `gorm:"type:text" json:"content_source,omitempty"`                      // Markdown原文（仅markdown格式）
+	TOC           datatypes.JSON `gorm:"column:toc;type:jsonb" json:"toc,omitempty"`                     // 目录（由正文标题生成）
+	CoverImage    string         `gorm:"type:varchar(500)" json:"cover_image"`                           // 封面图片URL
+	CategoryID    *uint          `gorm:"index" json:"category_id"`                                       // 分类ID
+	Status        ArticleStatus  `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`  // 文章状态
+	PublishAt     *time.Time     `gorm:"index" json:"publish_at"`                                        // 发布时间（可预设未来时间）
+	ViewCount     int            `gorm:"default:0;index" json:"view_count"`                              // 浏览次数
+	LikeCount     int            `gorm:"default:0" json:"like_count"`                                    // 点赞数（预留）
+	IsTop         bool           `gorm:"default:false;index" json:"is_top"`                              // 是否置顶
+	IsFeatured    bool           `gorm:"default:false" json:"is_featured"`                               // 是否推荐
+	AuthorID      *uint          `gorm:"index" json:"author_id"`                                         // 作者ID
+	SourceURL     string         `gorm:"type:varchar(1000)" json:"source_url,omitempty"`                 // 来源URL（从采集结果导入）
+	CrawlTaskID   *uint          `gorm:"index" json:"crawl_task_id,omitempty"`                           // 导入来源的爬虫任务ID
+	SearchTitle   string         `gorm:"type:text" json:"-"`                                             // 分词后的标题（全文检索）
+	SearchSummary string         `gorm:"type:text" json:"-"`                                             // 分词后的摘要（全文检索）
+	SearchContent string         `gorm:"type:text" json:"-"`                                             // 分词后的正文纯文本（全文检索）
+	CommentCount  int64          `gorm:"-" json:"comment_count"`                                         // 已通过的评论数（列表查询时填充）
+	SearchRank    float64        `gorm:"->" json:"search_rank,omitempty"`                                // 搜索相关度（仅搜索结果）
+	Highlight     string         `gorm:"->" json:"highlight,omitempty"`                                  // 搜索命中片段（仅搜索结果，已转义，命中词以<mark>包裹）
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 
 	// 关联
 	Category *Category `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
@@ -58,4 +78,3 @@ type ArticleTag struct {
 func (ArticleTag) TableName() string {
 	return "article_tags"
 }
-
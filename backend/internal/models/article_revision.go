@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// RevisionAction 触发修订快照的操作
+type RevisionAction string
+
+const (
+	RevisionActionCreate    RevisionAction = "create"    // 创建
+	RevisionActionUpdate    RevisionAction = "update"    // 更新
+	RevisionActionPublish   RevisionAction = "publish"   // 发布
+	RevisionActionUnpublish RevisionAction = "unpublish" // 取消发布
+)
+
+// ArticleRevision 文章修订模型（每次保存时的完整快照）
+type ArticleRevision struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	ArticleID     uint           `gorm:"not null;index;uniqueIndex:idx_article_version" json:"article_id"`
+	Version       int            `gorm:"not null;uniqueIndex:idx_article_version" json:"version"` // 版本号（同一文章内递增）
+	Action        RevisionAction `gorm:"type:varchar(20);not null" json:"action"`                 // 触发快照的操作
+	Title         string         `gorm:"type:varchar(255);not null" json:"title"`
+	Slug          string         `gorm:"type:varchar(255);not null" json:"slug"`
+	Summary       string         `gorm:"type:text" json:"summary"`
+	Content       string         `gorm:"type:text;not null" json:"content,omitempty"`
+	ContentFormat ContentFormat  `gorm:"type:varchar(20);not null;default:'html'" json:"content_format"`
+	ContentSource string         `gorm:"type:text" json:"content_source,omitempty"`
+	CoverImage    string         `gorm:"type:varchar(500)" json:"cover_image"`
+	CategoryID    *uint          `json:"category_id"`
+	TagIDs        datatypes.JSON `gorm:"type:jsonb" json:"tag_ids"`               // 标签ID列表
+	Status        ArticleStatus  `gorm:"type:varchar(20);not null" json:"status"` // 快照时的文章状态
+	Note          string         `gorm:"type:varchar(255)" json:"note"`           // 修订备注
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// TableName 指定表名
+func (ArticleRevision) TableName() string {
+	return "article_revisions"
+}
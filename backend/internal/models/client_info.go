@@ -0,0 +1,10 @@
+package models
+
+// ClientInfo 从User-Agent解析出的客户端信息（访问记录和指纹共用）
+type ClientInfo struct {
+	Browser        string `gorm:"column:browser;type:varchar(50)" json:"browser"`                 // 浏览器（爬虫时为爬虫名称）
+	BrowserVersion string `gorm:"column:browser_version;type:varchar(20)" json:"browser_version"` // 浏览器主版本号
+	OS             string `gorm:"column:os;type:varchar(50)" json:"os"`                           // 操作系统
+	OSVersion      string `gorm:"column:os_version;type:varchar(20)" json:"os_version"`           // 操作系统版本
+	DeviceType     string `gorm:"column:device_type;type:varchar(10)" json:"device_type"`         // 设备类型：desktop、mobile、tablet、bot
+}
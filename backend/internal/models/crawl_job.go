@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// 任务租约默认值
+const (
+	DefaultCrawlLeaseSeconds = 300 // 租约时长（秒）
+	DefaultCrawlMaxAttempts  = 3   // 最多领取次数
+)
+
+// CrawlJob 爬虫作业定义（按cron表达式定时生成待执行任务）
+type CrawlJob struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	Name          string         `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"` // 作业名称（同时作为生成任务的名称）
+	Description   string         `gorm:"type:text" json:"description"`
+	CronSpec      string         `gorm:"type:varchar(100);not null" json:"cron_spec"` // cron表达式（秒 分 时 日 月 周）
+	Enabled       bool           `gorm:"not null;default:true" json:"enabled"`        // 是否启用
+	Params        datatypes.JSON `gorm:"type:jsonb" json:"params"`                    // 下发给爬虫的参数（作为任务的metadata）
+	ResultSchema  datatypes.JSON `gorm:"type:jsonb" json:"result_schema,omitempty"`   // 生成任务的结果Schema
+	ImportMapping datatypes.JSON `gorm:"type:jsonb" json:"import_mapping,omitempty"`  // 生成任务的导入映射规则
+	LeaseSeconds  int            `gorm:"not null;default:300" json:"lease_seconds"`   // 任务租约时长（秒）
+	MaxAttempts   int            `gorm:"not null;default:3" json:"max_attempts"`      // 任务最多领取次数
+	AllowOverlap  bool           `gorm:"not null;default:false" json:"allow_overlap"` // 上次的任务未结束时是否仍生成新任务
+	LastRunAt     *time.Time     `json:"last_run_at"`                                 // 最近一次生成任务的时间
+	NextRunAt     *time.Time     `gorm:"-" json:"next_run_at,omitempty"`              // 下次运行时间（启用时由cron表达式计算）
+	CreatedBy     *uint          `gorm:"index" json:"created_by"`                     // 创建者ID
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (CrawlJob) TableName() string {
+	return "crawl_jobs"
+}
@@ -10,9 +10,56 @@ import (
 type CrawlTaskStatus string
 
 const (
-	CrawlTaskStatusRunning   CrawlTaskStatus = "running"   // 运行中
-	CrawlTaskStatusCompleted CrawlTaskStatus = "completed" // 已完成
-	CrawlTaskStatusFailed    CrawlTaskStatus = "failed"    // 失败
+	CrawlTaskStatusPending    CrawlTaskStatus = "pending"    // 待执行（由作业生成，等待工作节点领取）
+	CrawlTaskStatusRunning    CrawlTaskStatus = "running"    // 运行中
+	CrawlTaskStatusCompleted  CrawlTaskStatus = "completed"  // 已完成
+	CrawlTaskStatusFailed     CrawlTaskStatus = "failed"     // 失败
+	CrawlTaskStatusPausing    CrawlTaskStatus = "pausing"    // 已下发暂停命令，等待爬虫确认
+	CrawlTaskStatusPaused     CrawlTaskStatus = "paused"     // 已暂停
+	CrawlTaskStatusResuming   CrawlTaskStatus = "resuming"   // 已下发恢复命令，等待爬虫确认
+	CrawlTaskStatusCancelling CrawlTaskStatus = "cancelling" // 已下发取消命令，等待爬虫确认
+	CrawlTaskStatusCancelled  CrawlTaskStatus = "cancelled"  // 已取消
+)
+
+// ActiveCrawlTaskStatuses 已被爬虫执行且尚未结束的任务状态
+var ActiveCrawlTaskStatuses = []CrawlTaskStatus{
+	CrawlTaskStatusRunning,
+	CrawlTaskStatusPausing,
+	CrawlTaskStatusPaused,
+	CrawlTaskStatusResuming,
+	CrawlTaskStatusCancelling,
+}
+
+// IsActive 任务是否已被爬虫执行且尚未结束
+func (s CrawlTaskStatus) IsActive() bool {
+	for _, status := range ActiveCrawlTaskStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// PendingCommand 等待爬虫确认的控制命令，没有时返回空
+func (s CrawlTaskStatus) PendingCommand() CrawlTaskCommand {
+	switch s {
+	case CrawlTaskStatusPausing:
+		return CrawlTaskCommandPause
+	case CrawlTaskStatusResuming:
+		return CrawlTaskCommandResume
+	case CrawlTaskStatusCancelling:
+		return CrawlTaskCommandCancel
+	}
+	return ""
+}
+
+// CrawlTaskCommand 爬虫任务控制命令
+type CrawlTaskCommand string
+
+const (
+	CrawlTaskCommandPause  CrawlTaskCommand = "pause"  // 暂停
+	CrawlTaskCommandResume CrawlTaskCommand = "resume" // 恢复
+	CrawlTaskCommandCancel CrawlTaskCommand = "cancel" // 取消
 )
 
 // CrawlTask 爬虫任务模型
@@ -28,6 +75,19 @@ type CrawlTask struct {
 	Duration       *int            `json:"duration"`                            // 运行时长（秒）
 	CreatedByToken string          `gorm:"type:varchar(64);index" json:"-"`     // 创建任务的Token（不返回给客户端）
 	Metadata       datatypes.JSON  `gorm:"type:jsonb" json:"metadata"`          // 额外元数据
+	ResultSchema   datatypes.JSON  `gorm:"type:jsonb" json:"result_schema,omitempty"` // 采集结果的JSON Schema
+	ResultCount    int             `gorm:"default:0" json:"result_count"`             // 已提交的结果数
+	ImportMapping  datatypes.JSON  `gorm:"type:jsonb" json:"import_mapping,omitempty"` // 结果导入文章的映射规则（CrawlImportMapping）
+	ImportSummary  datatypes.JSON  `gorm:"type:jsonb" json:"import_summary,omitempty"` // 最近一次导入的结果汇总（CrawlImportSummary）
+	JobID          *uint           `gorm:"index" json:"job_id,omitempty"`              // 生成任务的作业ID（爬虫自行注册的任务为空）
+	WorkerID       string          `gorm:"type:varchar(100)" json:"worker_id,omitempty"` // 领取任务的工作节点
+	LeaseSeconds   *int            `json:"lease_seconds,omitempty"`                    // 租约时长（秒）
+	LeaseExpiresAt *time.Time      `json:"lease_expires_at,omitempty"`                 // 租约到期时间
+	HeartbeatAt    *time.Time      `json:"heartbeat_at,omitempty"`                     // 最近一次心跳时间
+	HeartbeatTimeout *int          `json:"heartbeat_timeout,omitempty"`                // 心跳超时（秒），超时未上报则标记为失败；为空时使用全局配置
+	Attempts       int             `gorm:"default:0" json:"attempts"`                  // 已领取次数
+	ControlRequestedAt *time.Time  `json:"control_requested_at,omitempty"`             // 最近一次下发控制命令的时间
+	ControlAckedAt *time.Time      `json:"control_acked_at,omitempty"`                 // 爬虫最近一次确认控制命令的时间
 	CreatedAt      time.Time       `json:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at"`
 }
@@ -37,3 +97,53 @@ func (CrawlTask) TableName() string {
 	return "crawl_tasks"
 }
 
+
+// CrawlResult 爬虫采集结果模型
+type CrawlResult struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	TaskID    uint           `gorm:"not null;index" json:"task_id"` // 所属任务ID（crawl_tasks.id）
+	Data      datatypes.JSON `gorm:"type:jsonb;not null" json:"data"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// TableName 指定表名
+func (CrawlResult) TableName() string {
+	return "crawl_results"
+}
+
+// CrawlImportStatus 导入状态
+type CrawlImportStatus string
+
+const (
+	CrawlImportStatusRunning   CrawlImportStatus = "running"   // 导入中
+	CrawlImportStatusCompleted CrawlImportStatus = "completed" // 已完成
+	CrawlImportStatusFailed    CrawlImportStatus = "failed"    // 失败
+)
+
+// CrawlImportMapping 采集结果导入文章的映射规则
+// 字段名支持用"."访问嵌套对象，如 "meta.title"
+type CrawlImportMapping struct {
+	TitleField        string        `json:"title_field"`                   // 标题字段（必填）
+	SummaryField      string        `json:"summary_field,omitempty"`       // 摘要字段
+	ContentField      string        `json:"content_field,omitempty"`       // 正文字段
+	ContentFormat     ContentFormat `json:"content_format,omitempty"`      // 正文格式（html/markdown，默认html）
+	TagsField         string        `json:"tags_field,omitempty"`          // 标签字段（字符串数组或逗号分隔的字符串）
+	CategoryField     string        `json:"category_field,omitempty"`      // 分类字段（分类名称或Slug）
+	CoverImageField   string        `json:"cover_image_field,omitempty"`   // 封面图字段
+	SourceURLField    string        `json:"source_url_field,omitempty"`    // 来源URL字段（默认url，用于去重）
+	DefaultCategoryID *uint         `json:"default_category_id,omitempty"` // 未匹配到分类时使用的分类
+	AuthorID          uint          `json:"author_id,omitempty"`           // 导入文章的作者（管理员ID）
+}
+
+// CrawlImportSummary 导入结果汇总
+type CrawlImportSummary struct {
+	Status     CrawlImportStatus `json:"status"`
+	Total      int               `json:"total"`            // 处理的结果数
+	Created    int               `json:"created"`          // 新建的草稿数
+	Duplicated int               `json:"duplicated"`       // 来源URL已导入过而跳过的数量
+	Skipped    int               `json:"skipped"`          // 缺少标题或来源URL而跳过的数量
+	Failed     int               `json:"failed"`           // 创建文章失败的数量
+	Errors     []string          `json:"errors,omitempty"` // 错误信息（最多保留前20条）
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
@@ -12,6 +12,7 @@ type Fingerprint struct {
 	FingerprintHash string         `gorm:"type:varchar(64);uniqueIndex;not null" json:"fingerprint_hash"` // 指纹哈希值（SHA256）
 	FingerprintData datatypes.JSON `gorm:"type:jsonb;not null" json:"fingerprint_data"`                   // 完整指纹信息
 	UserAgent       string         `gorm:"type:text" json:"user_agent"`
+	ClientInfo      `gorm:"embedded"` // 从User-Agent解析出的浏览器、操作系统和设备类型
 	FirstSeenAt     time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"first_seen_at"`
 	LastSeenAt      time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP;index" json:"last_seen_at"`
 	VisitCount      int            `gorm:"default:1" json:"visit_count"` // 访问次数
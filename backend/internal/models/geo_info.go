@@ -0,0 +1,9 @@
+package models
+
+// GeoInfo 从离线数据库解析出的IP归属地（未配置数据库或无法识别时为空）
+type GeoInfo struct {
+	CountryCode string `gorm:"column:country_code;type:varchar(2)" json:"country_code"` // 国家/地区代码（ISO 3166-1）
+	Country     string `gorm:"column:country;type:varchar(100)" json:"country"`         // 国家/地区
+	Region      string `gorm:"column:region;type:varchar(100)" json:"region"`           // 省/州
+	City        string `gorm:"column:city;type:varchar(100)" json:"city"`               // 城市
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// MediaReferenceField 媒体被引用的位置
+type MediaReferenceField string
+
+const (
+	MediaReferenceContent  MediaReferenceField = "content"  // 文章正文
+	MediaReferenceCover    MediaReferenceField = "cover"    // 文章封面
+	MediaReferenceRevision MediaReferenceField = "revision" // 文章修订快照（正文或封面，可恢复）
+)
+
+// Media 媒体模型（上传的文件）
+type Media struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	StorageKey     string         `gorm:"type:varchar(500);uniqueIndex;not null" json:"storage_key"` // 存储后端中的对象key
+	URL            string         `gorm:"column:url;type:varchar(500);not null" json:"url"`          // 访问地址
+	OriginalName   string         `gorm:"type:varchar(255)" json:"original_name"`                    // 原始文件名
+	MimeType       string         `gorm:"type:varchar(100)" json:"mime_type"`
+	Size           int64          `gorm:"not null;default:0" json:"size"` // 文件大小（字节）
+	Width          int            `gorm:"not null;default:0" json:"width"`
+	Height         int            `gorm:"not null;default:0" json:"height"`
+	Variants       datatypes.JSON `gorm:"type:jsonb" json:"variants"`                      // 宽度变体列表（[]MediaVariant）
+	UploaderID     *uint          `gorm:"index" json:"uploader_id"`                        // 上传者ID
+	ReferenceCount int            `gorm:"not null;default:0;index" json:"reference_count"` // 引用该文件的文章数
+	LastScannedAt  *time.Time     `json:"last_scanned_at"`                                 // 最近一次引用扫描时间
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	// 关联
+	Uploader *Admin `gorm:"foreignKey:UploaderID" json:"uploader,omitempty"`
+}
+
+// TableName 指定表名
+func (Media) TableName() string {
+	return "media"
+}
+
+// MediaVariant 图片宽度变体
+type MediaVariant struct {
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Key      string `json:"key"` // 存储中的对象key
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+// VariantList 解析宽度变体列表
+func (m *Media) VariantList() []MediaVariant {
+	var variants []MediaVariant
+	if len(m.Variants) > 0 {
+		_ = json.Unmarshal(m.Variants, &variants)
+	}
+	return variants
+}
+
+// MediaReference 媒体引用模型
+type MediaReference struct {
+	MediaID   uint                `gorm:"primaryKey" json:"media_id"`
+	ArticleID uint                `gorm:"primaryKey" json:"article_id"`
+	Field     MediaReferenceField `gorm:"primaryKey;type:varchar(20)" json:"field"`
+	CreatedAt time.Time           `json:"created_at"`
+
+	// 关联
+	Article *Article `gorm:"foreignKey:ArticleID" json:"article,omitempty"`
+}
+
+// TableName 指定表名
+func (MediaReference) TableName() string {
+	return "media_references"
+}
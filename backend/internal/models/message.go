@@ -4,20 +4,37 @@ import (
 	"time"
 )
 
-// Message 留言记录模型（仅用于统计）
+// MessageStatus 评论审核状态
+type MessageStatus string
+
+const (
+	MessageStatusPending  MessageStatus = "pending"  // 待审核
+	MessageStatusApproved MessageStatus = "approved" // 已通过
+	MessageStatusSpam     MessageStatus = "spam"     // 垃圾评论
+)
+
+// Message 评论/留言模型
 type Message struct {
-	ID            uint      `gorm:"primaryKey" json:"id"`
-	Name          string    `gorm:"type:varchar(100)" json:"name"`
-	Email         string    `gorm:"type:varchar(100);index" json:"email"`
-	FingerprintID *uint     `gorm:"index" json:"fingerprint_id"` // 留言者的浏览器指纹ID
-	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+	ID            uint          `gorm:"primaryKey" json:"id"`
+	ArticleID     *uint         `gorm:"index" json:"article_id"` // 所属文章ID
+	ParentID      *uint         `gorm:"index" json:"parent_id"`  // 父评论ID（为空表示顶级评论）
+	Name          string        `gorm:"type:varchar(100)" json:"name"`
+	Email         string        `gorm:"type:varchar(100);index" json:"email,omitempty"`
+	Content       string        `gorm:"type:text;not null" json:"content"`                               // 评论内容（纯文本）
+	Status        MessageStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"` // 审核状态
+	FingerprintID *uint         `gorm:"index" json:"fingerprint_id"`                                     // 留言者的浏览器指纹ID
+	IPAddress     string        `gorm:"type:varchar(45)" json:"ip_address,omitempty"`
+	UserAgent     string        `gorm:"type:text" json:"user_agent,omitempty"`
+	CreatedAt     time.Time     `gorm:"index" json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
 
 	// 关联
 	Fingerprint *Fingerprint `gorm:"foreignKey:FingerprintID" json:"fingerprint,omitempty"`
+	Article     *Article     `gorm:"foreignKey:ArticleID" json:"article,omitempty"`
+	Replies     []Message    `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
 }
 
 // TableName 指定表名
 func (Message) TableName() string {
 	return "messages"
 }
-
@@ -35,5 +35,6 @@ const (
 	ConfigTypeSalt         = "salt"          // 加密盐
 	ConfigTypeIPBlacklist  = "ip_blacklist"  // IP黑名单
 	ConfigTypeSiteInfo     = "site_info"     // 站点信息(博客标题、备案信息等)
+	ConfigTypeRobotsTxt    = "robots_txt"    // robots.txt 内容
 )
 
@@ -14,7 +14,12 @@ type Visit struct {
 	ArticleID     *uint      `gorm:"index" json:"article_id"` // 如果访问的是文章页
 	VisitTime     time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP;index" json:"visit_time"`
 	StayDuration  *int       `json:"stay_duration"` // 停留时间（秒）
+	ScrollDepth   *int       `json:"scroll_depth"` // 最大滚动深度（百分比）
+	VisitKey      *string    `gorm:"type:uuid;uniqueIndex" json:"visit_key,omitempty"` // 访问标识（离开信标使用）
 	UserAgent     string     `gorm:"type:text" json:"user_agent"`
+	ClientInfo    `gorm:"embedded"` // 从User-Agent解析出的浏览器、操作系统和设备类型
+	IP            string     `gorm:"type:varchar(45)" json:"ip"` // 客户端IP（按配置可能已截断）
+	GeoInfo       `gorm:"embedded"` // IP归属地
 	CreatedAt     time.Time  `json:"created_at"`
 
 	// 关联
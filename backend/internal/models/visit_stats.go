@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// VisitDailyStat 全站访问日汇总模型
+type VisitDailyStat struct {
+	StatDate          time.Time `gorm:"type:date;primaryKey" json:"stat_date"`
+	PV                int64     `gorm:"not null;default:0" json:"pv"`
+	UV                int64     `gorm:"not null;default:0" json:"uv"`                  // 当天去重的浏览器指纹数
+	StayDurationSum   int64     `gorm:"not null;default:0" json:"stay_duration_sum"`   // 停留时间之和（秒）
+	StayDurationCount int64     `gorm:"not null;default:0" json:"stay_duration_count"` // 上报了停留时间的访问数
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (VisitDailyStat) TableName() string {
+	return "visit_daily_stats"
+}
+
+// ArticleDailyStat 文章访问日汇总模型
+type ArticleDailyStat struct {
+	StatDate          time.Time `gorm:"type:date;primaryKey" json:"stat_date"`
+	ArticleID         uint      `gorm:"primaryKey" json:"article_id"`
+	PV                int64     `gorm:"not null;default:0" json:"pv"`
+	UV                int64     `gorm:"not null;default:0" json:"uv"`
+	StayDurationSum   int64     `gorm:"not null;default:0" json:"stay_duration_sum"`
+	StayDurationCount int64     `gorm:"not null;default:0" json:"stay_duration_count"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ArticleDailyStat) TableName() string {
+	return "article_daily_stats"
+}
+
+// VisitPeriodStat 全站访问周期汇总模型（按周/月去重的访客数）
+type VisitPeriodStat struct {
+	PeriodType string    `gorm:"type:varchar(10);primaryKey" json:"period_type"` // weekly, monthly
+	PeriodKey  string    `gorm:"type:varchar(10);primaryKey" json:"period_key"`  // 周期标识，如2024-W05、2024-02
+	UV         int64     `gorm:"not null;default:0" json:"uv"`                   // 周期内去重的浏览器指纹数
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (VisitPeriodStat) TableName() string {
+	return "visit_period_stats"
+}
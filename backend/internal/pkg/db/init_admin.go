@@ -26,7 +26,15 @@ func InitDefaultAdmin(db *gorm.DB) error {
 	admin, err := adminRepo.FindByUsername(DefaultAdminUsername)
 	if err != nil {
 		if errors.Is(err, repository.ErrAdminNotFound) {
-			// 管理员不存在，创建新管理员
+			// 默认管理员可能已被站长删除，只有系统中没有任何账号时才创建
+			count, err := adminRepo.Count()
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				logger.Info("Default admin not found but other admins exist, skipping creation")
+				return nil
+			}
 			return createDefaultAdmin(adminRepo)
 		}
 		return err
@@ -49,6 +57,7 @@ func createDefaultAdmin(adminRepo repository.AdminRepository) error {
 		Username:          DefaultAdminUsername,
 		Password:          hashedPassword,
 		Email:             DefaultAdminEmail,
+		Role:              models.AdminRoleOwner,
 		IsDefaultPassword: true,
 	}
 
@@ -0,0 +1,168 @@
+package diff
+
+import (
+	"strings"
+)
+
+// OpType 差异操作类型
+type OpType string
+
+const (
+	OpEqual  OpType = "equal"  // 未变化
+	OpInsert OpType = "insert" // 新增
+	OpDelete OpType = "delete" // 删除
+)
+
+// Line 差异行
+type Line struct {
+	Type    OpType `json:"type"`
+	Content string `json:"content"`
+	OldLine int    `json:"old_line,omitempty"` // 在旧文本中的行号（从1开始）
+	NewLine int    `json:"new_line,omitempty"` // 在新文本中的行号（从1开始）
+}
+
+// Stats 差异统计
+type Stats struct {
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// Lines 按行比较两段文本，返回逐行差异（Myers算法）
+func Lines(oldText, newText string) ([]Line, Stats) {
+	a := splitLines(oldText)
+	b := splitLines(newText)
+
+	ops := myers(a, b)
+
+	var stats Stats
+	result := make([]Line, 0, len(ops))
+	oldLine, newLine := 0, 0
+	for _, op := range ops {
+		switch op {
+		case OpEqual:
+			result = append(result, Line{Type: OpEqual, Content: a[oldLine], OldLine: oldLine + 1, NewLine: newLine + 1})
+			oldLine++
+			newLine++
+		case OpDelete:
+			result = append(result, Line{Type: OpDelete, Content: a[oldLine], OldLine: oldLine + 1})
+			oldLine++
+			stats.Deletions++
+		case OpInsert:
+			result = append(result, Line{Type: OpInsert, Content: b[newLine], NewLine: newLine + 1})
+			newLine++
+			stats.Insertions++
+		}
+	}
+
+	return result, stats
+}
+
+// splitLines 拆分文本为行（统一换行符）
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// myers 计算从a到b的最短编辑脚本
+func myers(a, b []string) []OpType {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace []frontier
+
+	for d := 0; d <= max; d++ {
+		// 只保存本轮会访问到的对角线区间，内存占用为O(D²)而不是O(D·(N+M))
+		lo := offset - d - 1
+		if lo < 0 {
+			lo = 0
+		}
+		hi := offset + d + 2
+		if hi > len(v) {
+			hi = len(v)
+		}
+		snapshot := make([]int, hi-lo)
+		copy(snapshot, v[lo:hi])
+		trace = append(trace, frontier{lo: lo, v: snapshot})
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // 向下移动（插入）
+			} else {
+				x = v[offset+k-1] + 1 // 向右移动（删除）
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset)
+			}
+		}
+	}
+
+	return nil
+}
+
+// frontier 某一轮搜索开始时各对角线能到达的最远x坐标
+type frontier struct {
+	lo int
+	v  []int
+}
+
+// at 读取对角线下标i处的值
+func (f frontier) at(i int) int {
+	return f.v[i-f.lo]
+}
+
+// backtrack 根据搜索轨迹回溯出编辑操作序列
+func backtrack(trace []frontier, a, b []string, offset int) []OpType {
+	x, y := len(a), len(b)
+	var ops []OpType
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		f := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && f.at(offset+k-1) < f.at(offset+k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := f.at(offset + prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, OpEqual)
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, OpInsert)
+			} else {
+				ops = append(ops, OpDelete)
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	// 反转为正序
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
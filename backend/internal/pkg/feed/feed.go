@@ -0,0 +1,153 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Feed 订阅源（与输出格式无关的通用描述）
+type Feed struct {
+	Title       string
+	Link        string // 站点地址
+	FeedURL     string // 订阅源自身地址
+	Description string
+	Author      string // 默认作者（Atom要求订阅源或条目必须有作者）
+	Language    string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item 订阅条目
+type Item struct {
+	ID         string // 全局唯一标识（通常为文章链接）
+	Title      string
+	Link       string
+	Summary    string
+	Content    string // HTML正文
+	Author     string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+	Image      string // 封面图片地址
+}
+
+// RSS 生成RSS 2.0文档
+func RSS(f *Feed) ([]byte, error) {
+	channel := rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		Language:      f.Language,
+		LastBuildDate: formatRFC822(f.Updated),
+		Generator:     "blog",
+		AtomLink: &rssAtomLink{
+			Href: f.FeedURL,
+			Rel:  "self",
+			Type: "application/rss+xml",
+		},
+	}
+
+	for _, item := range f.Items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        &rssGUID{Value: item.ID, IsPermaLink: item.ID == item.Link},
+			Description: cdata(item.Summary),
+			Creator:     item.Author,
+			Categories:  item.Categories,
+			PubDate:     formatRFC822(item.Published),
+		}
+		if item.Content != "" {
+			ri.Content = cdata(item.Content)
+		}
+		if item.Image != "" {
+			ri.Enclosure = &rssEnclosure{URL: item.Image, Type: imageMIMEType(item.Image), Length: "0"}
+		}
+		channel.Items = append(channel.Items, ri)
+	}
+
+	doc := rssDocument{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		DCNS:      "http://purl.org/dc/elements/1.1/",
+		Channel:   channel,
+	}
+
+	return marshal(doc)
+}
+
+// Atom 生成Atom 1.0文档
+func Atom(f *Feed) ([]byte, error) {
+	doc := atomFeed{
+		NS:       "http://www.w3.org/2005/Atom",
+		Lang:     f.Language,
+		ID:       f.FeedURL,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  formatRFC3339(f.Updated),
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate", Type: "text/html"},
+			{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"},
+		},
+		Generator: "blog",
+	}
+	if f.Author != "" {
+		doc.Author = &atomPerson{Name: f.Author}
+	}
+
+	for _, item := range f.Items {
+		updated := item.Updated
+		if updated.IsZero() {
+			updated = item.Published
+		}
+
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+			Published: formatRFC3339(item.Published),
+			Updated:   formatRFC3339(updated),
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "html", Body: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Body: item.Content}
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshal(doc)
+}
+
+// marshal 序列化并添加XML声明
+func marshal(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// formatRFC822 RSS使用的时间格式
+func formatRFC822(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+// formatRFC3339 Atom使用的时间格式
+func formatRFC3339(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,203 @@
+package filetype
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// 支持识别的文件类型
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	GIF  = "image/gif"
+	WebP = "image/webp"
+	PDF  = "application/pdf"
+	Zip  = "application/zip"
+)
+
+var (
+	ErrUnknownType       = errors.New("unknown file type")
+	ErrExtensionMismatch = errors.New("file extension does not match content")
+	ErrEmbeddedContent   = errors.New("file contains embedded content of another type")
+	ErrCorrupted         = errors.New("file is corrupted")
+)
+
+// extensions 各类型的扩展名，第一个为保存时使用的扩展名
+var extensions = map[string][]string{
+	JPEG: {".jpg", ".jpeg"},
+	PNG:  {".png"},
+	GIF:  {".gif"},
+	WebP: {".webp"},
+	PDF:  {".pdf"},
+	Zip:  {".zip"},
+}
+
+// aliases 配置中常见的类型别名
+var aliases = map[string]string{
+	"image/jpg":                    JPEG,
+	"image/pjpeg":                  JPEG,
+	"application/x-zip-compressed": Zip,
+	"application/x-pdf":            PDF,
+}
+
+// markupSignatures 图片中出现即视为夹带了可被浏览器执行的内容
+var markupSignatures = [][]byte{
+	[]byte("<script"),
+	[]byte("<html"),
+	[]byte("<body"),
+	[]byte("<iframe"),
+	[]byte("<svg"),
+	[]byte("<?php"),
+	[]byte("javascript:"),
+}
+
+// Detect 根据文件头（魔数）识别文件类型，无法识别时返回空字符串
+// 只识别支持的类型，不信任客户端提交的扩展名和Content-Type
+func Detect(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return JPEG
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return PNG
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return GIF
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return WebP
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return PDF
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return Zip
+	default:
+		return ""
+	}
+}
+
+// Normalize 规范化MIME类型（小写、去掉参数、处理别名）
+func Normalize(mimeType string) string {
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = strings.TrimSpace(mimeType[:i])
+	}
+	if alias, ok := aliases[mimeType]; ok {
+		return alias
+	}
+	return mimeType
+}
+
+// Extension 类型对应的扩展名（用于生成保存的文件名）
+func Extension(mimeType string) string {
+	if exts := extensions[mimeType]; len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// IsImage 是否为图片类型
+func IsImage(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "image/")
+}
+
+// CheckExtension 检查文件名的扩展名与识别出的类型是否一致
+func CheckExtension(filename, mimeType string) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, allowed := range extensions[mimeType] {
+		if ext == allowed {
+			return nil
+		}
+	}
+	return ErrExtensionMismatch
+}
+
+// ContainsMarkup 检查数据中是否夹带HTML/脚本（图片与网页的多格式文件）
+// 只应传入元数据、注释等文本块，压缩后的像素数据中随机出现特征串的概率不可忽略
+func ContainsMarkup(data []byte) bool {
+	lower := bytes.ToLower(data)
+	for _, sig := range markupSignatures {
+		if bytes.Contains(lower, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDocument 检查非图片文件（PDF、Zip）的结构是否完整
+// 文件头必须在开头（由 Detect 保证），这里检查文件尾部，拒绝截断或拼接了其他内容的文件
+func ValidateDocument(data []byte, mimeType string) error {
+	switch mimeType {
+	case PDF:
+		// %%EOF 必须出现在文件末尾（允许少量换行等空白），避免在PDF后拼接其他文件
+		tail := data
+		if len(tail) > 1024 {
+			tail = tail[len(tail)-1024:]
+		}
+		i := bytes.LastIndex(tail, []byte("%%EOF"))
+		if i < 0 {
+			return ErrCorrupted
+		}
+		if len(bytes.TrimSpace(tail[i+5:])) > 0 {
+			return ErrEmbeddedContent
+		}
+		return nil
+	case Zip:
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil || len(reader.File) == 0 || len(data) < 30 {
+			return ErrCorrupted
+		}
+		// 第一个文件条目必须从文件开头开始，避免在其他文件后拼接压缩包
+		headerLen := 30 + int64(binary.LittleEndian.Uint16(data[26:28])) + int64(binary.LittleEndian.Uint16(data[28:30]))
+		if offset, err := reader.File[0].DataOffset(); err != nil || offset != headerLen {
+			return ErrEmbeddedContent
+		}
+		// 目录结束记录（含注释）之后不能再有数据
+		end := bytes.LastIndex(data, []byte("PK\x05\x06"))
+		if end < 0 || end+22 > len(data) {
+			return ErrCorrupted
+		}
+		if end+22+int(binary.LittleEndian.Uint16(data[end+20:end+22])) != len(data) {
+			return ErrEmbeddedContent
+		}
+		return nil
+	default:
+		return ErrUnknownType
+	}
+}
+
+// Policy 上传策略（大小和类型限制）
+type Policy struct {
+	MaxSize      int64 // 最大文件大小（字节）
+	allowedTypes map[string]bool
+}
+
+// NewPolicy 创建上传策略，类型为MIME类型（如 image/png）
+func NewPolicy(maxSize int64, allowedTypes []string) Policy {
+	allowed := make(map[string]bool, len(allowedTypes))
+	for _, t := range allowedTypes {
+		allowed[Normalize(t)] = true
+	}
+	return Policy{
+		MaxSize:      maxSize,
+		allowedTypes: allowed,
+	}
+}
+
+// Allows 是否允许该类型
+func (p Policy) Allows(mimeType string) bool {
+	return p.allowedTypes[mimeType]
+}
+
+// AllowedExtensions 允许的扩展名（用于提示信息）
+func (p Policy) AllowedExtensions() []string {
+	result := make([]string, 0)
+	for _, t := range []string{JPEG, PNG, GIF, WebP, PDF, Zip} {
+		if p.allowedTypes[t] {
+			for _, ext := range extensions[t] {
+				result = append(result, strings.TrimPrefix(ext, "."))
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package geoip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// 默认地名语言，数据库中没有指定语言时回退到英文
+const (
+	DefaultLanguage  = "zh-CN"
+	fallbackLanguage = "en"
+)
+
+// Location IP归属地，无法识别的字段为空
+type Location struct {
+	CountryCode string // 国家/地区代码（ISO 3166-1，如 CN、US）
+	Country     string // 国家/地区名称
+	Region      string // 省/州（一级行政区）
+	City        string // 城市
+}
+
+// Reader 离线IP归属地查询，读取MaxMind格式（mmdb）的City或Country数据库
+// 零值或nil的Reader不做查询，Lookup始终返回nil
+type Reader struct {
+	db       *maxminddb.Reader
+	language string
+}
+
+// names 数据库中按语言索引的名称
+type names map[string]string
+
+// cityRecord 数据库记录中用到的字段（Country数据库没有subdivisions和city）
+type cityRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+		Names   names  `maxminddb:"names"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+		Names   names  `maxminddb:"names"`
+	} `maxminddb:"registered_country"`
+	Subdivisions []struct {
+		Names names `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names names `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Open 打开数据库文件，language为地名语言（如 zh-CN、en），为空时使用DefaultLanguage
+func Open(path, language string) (*Reader, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	language = strings.TrimSpace(language)
+	if language == "" {
+		language = DefaultLanguage
+	}
+
+	return &Reader{db: db, language: language}, nil
+}
+
+// Enabled 是否已加载数据库
+func (r *Reader) Enabled() bool {
+	return r != nil && r.db != nil
+}
+
+// DatabaseType 数据库类型（如 GeoLite2-City），未加载时为空
+func (r *Reader) DatabaseType() string {
+	if !r.Enabled() {
+		return ""
+	}
+	return r.db.Metadata.DatabaseType
+}
+
+// Lookup 查询IP归属地，未加载数据库、IP无效或数据库中没有该IP时返回nil
+func (r *Reader) Lookup(ip string) (*Location, error) {
+	if !r.Enabled() {
+		return nil, nil
+	}
+
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil || parsed.IsLoopback() || parsed.IsPrivate() || parsed.IsUnspecified() {
+		return nil, nil
+	}
+
+	var record cityRecord
+	if err := r.db.Lookup(parsed, &record); err != nil {
+		return nil, err
+	}
+
+	loc := &Location{
+		CountryCode: record.Country.ISOCode,
+		Country:     r.name(record.Country.Names),
+		City:        r.name(record.City.Names),
+	}
+	// 部分网段只有注册国家
+	if loc.CountryCode == "" {
+		loc.CountryCode = record.RegisteredCountry.ISOCode
+		loc.Country = r.name(record.RegisteredCountry.Names)
+	}
+	if len(record.Subdivisions) > 0 {
+		loc.Region = r.name(record.Subdivisions[0].Names)
+	}
+
+	if loc.CountryCode == "" && loc.Country == "" {
+		return nil, nil
+	}
+	return loc, nil
+}
+
+// Close 关闭数据库
+func (r *Reader) Close() error {
+	if !r.Enabled() {
+		return nil
+	}
+	return r.db.Close()
+}
+
+// name 按配置的语言取名称，没有时回退到英文
+func (r *Reader) name(n names) string {
+	if name := n[r.language]; name != "" {
+		return name
+	}
+	return n[fallbackLanguage]
+}
+
+// TruncateIP 截断IP以保护隐私：IPv4保留前24位，IPv6保留前48位，IP无效时返回空字符串
+func TruncateIP(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
@@ -1,17 +1,21 @@
 package image
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/chai2010/webp"
 	"github.com/disintegration/imaging"
+	"github.com/whk-newbie/blog/internal/pkg/storage"
 )
 
 const (
@@ -23,56 +27,67 @@ const (
 	WebPExtension = ".webp"
 )
 
-// CompressImage 压缩图片
-// 如果图片大小超过阈值，则压缩并转换为WebP格式
-// 返回压缩后的文件路径和文件大小
-func CompressImage(srcPath string) (string, int64, error) {
-	// 检查源文件大小
-	fileInfo, err := os.Stat(srcPath)
+// CompressImage 压缩存储中的图片
+// 如果图片大小超过阈值，则压缩并转换为WebP格式写回存储，并删除原对象
+// 返回最终的对象key和文件大小
+func CompressImage(ctx context.Context, backend storage.Backend, key string) (string, int64, error) {
+	// 读取原图
+	reader, err := backend.Get(ctx, key)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to stat source file: %w", err)
+		return "", 0, fmt.Errorf("failed to read source image: %w", err)
 	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read source image: %w", err)
+	}
+	originalSize := int64(len(data))
 
 	// 如果文件小于阈值，不需要压缩
-	if fileInfo.Size() < CompressThreshold {
-		return srcPath, fileInfo.Size(), nil
+	if originalSize < CompressThreshold {
+		return key, originalSize, nil
 	}
 
-	// 打开源图片
-	src, err := imaging.Open(srcPath)
+	// 解码源图片
+	src, err := imaging.Decode(bytes.NewReader(data))
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to open image: %w", err)
+		return "", 0, fmt.Errorf("failed to decode image: %w", err)
 	}
 
-	// 生成WebP文件路径
-	ext := filepath.Ext(srcPath)
-	webpPath := strings.TrimSuffix(srcPath, ext) + WebPExtension
-
-	// 保存为WebP格式
-	err = saveAsWebP(src, webpPath, CompressQuality)
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to save compressed image: %w", err)
+	// 编码为WebP格式
+	var buf bytes.Buffer
+	if err := encodeWebP(&buf, src, CompressQuality); err != nil {
+		return "", 0, fmt.Errorf("failed to encode compressed image: %w", err)
 	}
+	compressedSize := int64(buf.Len())
 
-	// 获取压缩后的文件大小
-	compressedInfo, err := os.Stat(webpPath)
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to stat compressed file: %w", err)
+	// 如果压缩后的文件比原文件大，保留原文件
+	if compressedSize >= originalSize {
+		return key, originalSize, nil
 	}
 
-	// 如果压缩后的文件比原文件大，保留原文件
-	if compressedInfo.Size() >= fileInfo.Size() {
-		os.Remove(webpPath)
-		return srcPath, fileInfo.Size(), nil
+	// 生成WebP对象key并写回存储
+	webpKey := strings.TrimSuffix(key, path.Ext(key)) + WebPExtension
+	if err := backend.Put(ctx, webpKey, &buf, compressedSize, "image/webp"); err != nil {
+		return "", 0, fmt.Errorf("failed to save compressed image: %w", err)
 	}
 
-	// 删除原文件，使用压缩后的文件
-	if err := os.Remove(srcPath); err != nil {
-		// 如果删除失败，保留两个文件，但返回压缩后的文件路径
-		return webpPath, compressedInfo.Size(), nil
+	// 删除原文件，使用压缩后的文件（原图本身是WebP时已被覆盖，无需删除）
+	if webpKey != key {
+		// 如果删除失败，保留两个文件，但返回压缩后的文件
+		_ = backend.Delete(ctx, key)
 	}
 
-	return webpPath, compressedInfo.Size(), nil
+	return webpKey, compressedSize, nil
+}
+
+// GetImageDimensionsFromReader 从Reader读取图片尺寸（只解析文件头）
+func GetImageDimensionsFromReader(reader io.Reader) (width, height int, err error) {
+	cfg, _, err := image.DecodeConfig(reader)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
 }
 
 // CompressImageFromReader 从Reader压缩图片
@@ -186,6 +201,11 @@ func saveAsWebP(img image.Image, path string, quality int) error {
 	}
 	defer file.Close()
 
+	return encodeWebP(file, img, quality)
+}
+
+// encodeWebP 将图片编码为WebP格式写入writer
+func encodeWebP(w io.Writer, img image.Image, quality int) error {
 	// 使用webp库编码为WebP格式
 	// quality参数范围是0-100，我们传入80
 	options := &webp.Options{
@@ -193,8 +213,7 @@ func saveAsWebP(img image.Image, path string, quality int) error {
 		Quality:  float32(quality),
 	}
 
-	err = webp.Encode(file, img, options)
-	if err != nil {
+	if err := webp.Encode(w, img, options); err != nil {
 		return fmt.Errorf("failed to encode webp: %w", err)
 	}
 
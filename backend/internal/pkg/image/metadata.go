@@ -0,0 +1,674 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/chai2010/webp"
+	"github.com/disintegration/imaging"
+)
+
+// ReencodeQuality 按EXIF方向旋转后重新编码的质量（高于压缩质量，尽量减少二次压缩损失）
+const ReencodeQuality = 92
+
+// EXIF标签
+const (
+	exifTagOrientation = 0x0112
+	exifTagArtist      = 0x013B
+	exifTagCopyright   = 0x8298
+)
+
+var errInvalidImageData = errors.New("invalid image data")
+
+// SanitizeOptions 元数据处理选项
+type SanitizeOptions struct {
+	// 保留版权和作者信息（EXIF Artist/Copyright，PNG Author/Copyright文本块），其余元数据仍然去除
+	KeepCopyright bool
+}
+
+// exifInfo 从EXIF中读取的信息
+type exifInfo struct {
+	orientation int
+	artist      []byte
+	copyright   []byte
+}
+
+// Sanitize 按EXIF方向规范化图片并去除元数据（EXIF/GPS、XMP、IPTC、文本注释等）
+// 颜色相关的数据（ICC配置、Adobe颜色变换标记）会保留；GIF只去除注释和应用扩展（保留循环播放设置），未知格式原样返回
+// 方向为正常时直接删除元数据块，不重新编码，避免画质损失
+func Sanitize(data []byte, format string, opts SanitizeOptions) ([]byte, error) {
+	switch format {
+	case FormatJPEG:
+		return sanitizeJPEG(data, opts)
+	case FormatPNG:
+		return sanitizePNG(data, opts)
+	case FormatWebP:
+		return sanitizeWebP(data, opts)
+	case FormatGIF:
+		return sanitizeGIF(data)
+	default:
+		return data, nil
+	}
+}
+
+// sanitizeJPEG 去除JPEG的APP1~APP15（保留ICC配置和Adobe标记）及注释段
+func sanitizeJPEG(data []byte, opts SanitizeOptions) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errInvalidImageData
+	}
+
+	var info exifInfo
+	var app0, kept, icc [][]byte
+	pos := 2
+	for {
+		// 跳过填充字节
+		for pos < len(data) && data[pos] == 0xFF && pos+1 < len(data) && data[pos+1] == 0xFF {
+			pos++
+		}
+		if pos+2 > len(data) || data[pos] != 0xFF {
+			return nil, errInvalidImageData
+		}
+		marker := data[pos+1]
+
+		// 扫描数据开始，之后的内容原样保留
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		// 没有长度字段的标记
+		if (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 {
+			kept = append(kept, data[pos:pos+2])
+			pos += 2
+			continue
+		}
+
+		if pos+4 > len(data) {
+			return nil, errInvalidImageData
+		}
+		// 长度字段包含自身的2个字节
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 {
+			return nil, errInvalidImageData
+		}
+		end := pos + 2 + length
+		if end > len(data) {
+			return nil, errInvalidImageData
+		}
+		segment := data[pos:end]
+		payload := segment[4:]
+
+		switch {
+		case marker == 0xE0:
+			// JFIF/JFXX
+			app0 = append(app0, segment)
+		case marker == 0xE1:
+			// EXIF（读取方向和版权信息后丢弃）、XMP
+			if bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+				info = parseExif(payload[6:])
+			}
+		case marker == 0xE2:
+			// 只保留ICC配置（FlashPix、MPF等丢弃）
+			if bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00")) {
+				icc = append(icc, segment)
+			}
+		case marker == 0xEE:
+			// Adobe颜色变换标记，影响CMYK/YCCK解码
+			kept = append(kept, segment)
+		case marker >= 0xE3 && marker <= 0xEF, marker == 0xFE:
+			// 其他APP段（含APP13 IPTC）和注释
+		default:
+			kept = append(kept, segment)
+		}
+		pos = end
+	}
+
+	var extra [][]byte
+	if opts.KeepCopyright {
+		if tiff := buildExif(info); tiff != nil {
+			extra = append(extra, jpegSegment(0xE1, append([]byte("Exif\x00\x00"), tiff...)))
+		}
+	}
+
+	// 需要旋转时重新编码，再补回ICC配置和版权信息
+	if info.orientation > 1 && info.orientation <= 8 {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, applyOrientation(img, info.orientation), &jpeg.Options{Quality: ReencodeQuality}); err != nil {
+			return nil, err
+		}
+		encoded := buf.Bytes()
+		out := make([]byte, 0, len(encoded)+1024)
+		out = append(out, encoded[:2]...)
+		for _, segment := range append(extra, icc...) {
+			out = append(out, segment...)
+		}
+		return append(out, encoded[2:]...), nil
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	for _, group := range [][][]byte{app0, extra, icc, kept} {
+		for _, segment := range group {
+			out = append(out, segment...)
+		}
+	}
+	return append(out, data[pos:]...), nil
+}
+
+// jpegSegment 组装JPEG段
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := make([]byte, 4, 4+len(payload))
+	segment[0], segment[1] = 0xFF, marker
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// pngSignature PNG文件头
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngStrippedChunks 需要去除的PNG元数据块
+var pngStrippedChunks = map[string]bool{
+	"tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true,
+}
+
+// pngColorChunks 重新编码后需要补回的颜色相关块
+var pngColorChunks = map[string]bool{
+	"iCCP": true, "sRGB": true, "gAMA": true, "cHRM": true, "pHYs": true,
+}
+
+// sanitizePNG 去除PNG的文本、EXIF和时间块
+func sanitizePNG(data []byte, opts SanitizeOptions) ([]byte, error) {
+	chunks, err := splitPNG(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var info exifInfo
+	kept := make([][]byte, 0, len(chunks))
+	var preserved [][]byte // 重新编码时需要补回的块
+	for _, chunk := range chunks {
+		chunkType := string(chunk[4:8])
+		body := chunk[8 : len(chunk)-4]
+
+		if chunkType == "eXIf" {
+			info = parseExif(body)
+		}
+		if pngStrippedChunks[chunkType] {
+			if opts.KeepCopyright && chunkType != "eXIf" && chunkType != "tIME" && isCopyrightKeyword(body) {
+				kept = append(kept, chunk)
+				preserved = append(preserved, chunk)
+			}
+			continue
+		}
+		if pngColorChunks[chunkType] {
+			preserved = append(preserved, chunk)
+		}
+		kept = append(kept, chunk)
+	}
+
+	if info.orientation > 1 && info.orientation <= 8 {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, applyOrientation(img, info.orientation)); err != nil {
+			return nil, err
+		}
+		encoded, err := splitPNG(buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		// 补回的块放在IHDR之后
+		kept = append([][]byte{encoded[0]}, append(preserved, encoded[1:]...)...)
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for _, chunk := range kept {
+		out = append(out, chunk...)
+	}
+	return out, nil
+}
+
+// splitPNG 拆分PNG块（每个元素包含长度、类型、数据和CRC）
+func splitPNG(data []byte) ([][]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errInvalidImageData
+	}
+	chunks := make([][]byte, 0)
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+12 > len(data) {
+			return nil, errInvalidImageData
+		}
+		end := pos + 12 + int(binary.BigEndian.Uint32(data[pos:pos+4]))
+		if end > len(data) || end < pos {
+			return nil, errInvalidImageData
+		}
+		chunks = append(chunks, data[pos:end])
+		if string(data[pos+4:pos+8]) == "IEND" {
+			break
+		}
+		pos = end
+	}
+	if len(chunks) == 0 || string(chunks[0][4:8]) != "IHDR" {
+		return nil, errInvalidImageData
+	}
+	return chunks, nil
+}
+
+// isCopyrightKeyword PNG文本块的关键字是否为作者或版权
+func isCopyrightKeyword(body []byte) bool {
+	keyword := body
+	if i := bytes.IndexByte(body, 0); i >= 0 {
+		keyword = body[:i]
+	}
+	return string(keyword) == "Author" || string(keyword) == "Copyright"
+}
+
+// WebP VP8X扩展头中的标记位
+const (
+	webpFlagAlpha = 0x10
+	webpFlagEXIF  = 0x08
+	webpFlagXMP   = 0x04
+	webpFlagAnim  = 0x02
+)
+
+// sanitizeWebP 去除WebP的EXIF和XMP块
+func sanitizeWebP(data []byte, opts SanitizeOptions) ([]byte, error) {
+	chunks, err := splitWebP(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var info exifInfo
+	animated := false
+	kept := make([][]byte, 0, len(chunks))
+	for _, chunk := range chunks {
+		switch string(chunk[:4]) {
+		case "EXIF":
+			info = parseExif(webpChunkBody(chunk))
+		case "XMP ":
+		case "VP8X":
+			// VP8X数据固定为10字节（标记位、保留位、宽高）
+			body := webpChunkBody(chunk)
+			if len(body) < 10 {
+				return nil, errInvalidImageData
+			}
+			animated = body[0]&webpFlagAnim != 0
+			kept = append(kept, chunk)
+		default:
+			kept = append(kept, chunk)
+		}
+	}
+
+	var exif []byte
+	if opts.KeepCopyright {
+		exif = buildExif(info)
+	}
+
+	// 动图不旋转（逐帧处理成本高且少见）
+	if info.orientation > 1 && info.orientation <= 8 && !animated {
+		img, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := encodeWebP(&buf, applyOrientation(img, info.orientation), ReencodeQuality); err != nil {
+			return nil, err
+		}
+		if kept, err = splitWebP(buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	if exif != nil {
+		kept = withWebPExtended(kept)
+		kept = append(kept, webpChunk("EXIF", exif))
+	}
+
+	// 更新VP8X中的元数据标记
+	for i, chunk := range kept {
+		if string(chunk[:4]) == "VP8X" {
+			updated := append([]byte(nil), chunk...)
+			updated[8] &^= webpFlagEXIF | webpFlagXMP
+			if exif != nil {
+				updated[8] |= webpFlagEXIF
+			}
+			kept[i] = updated
+		}
+	}
+
+	size := 4
+	for _, chunk := range kept {
+		size += len(chunk)
+	}
+	out := make([]byte, 0, size+8)
+	out = append(out, "RIFF"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(size))
+	out = append(out, "WEBP"...)
+	for _, chunk := range kept {
+		out = append(out, chunk...)
+	}
+	return out, nil
+}
+
+// splitWebP 拆分WebP的RIFF块（每个元素包含FourCC、长度、数据和填充字节）
+func splitWebP(data []byte) ([][]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errInvalidImageData
+	}
+	chunks := make([][]byte, 0)
+	pos := 12
+	for pos+8 <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2
+		if end > len(data) || end < pos {
+			return nil, errInvalidImageData
+		}
+		chunks = append(chunks, data[pos:end])
+		pos = end
+	}
+	if len(chunks) == 0 {
+		return nil, errInvalidImageData
+	}
+	return chunks, nil
+}
+
+// webpChunkBody WebP块的数据部分
+func webpChunkBody(chunk []byte) []byte {
+	size := int(binary.LittleEndian.Uint32(chunk[4:8]))
+	return chunk[8 : 8+size]
+}
+
+// webpChunk 组装WebP块
+func webpChunk(fourCC string, body []byte) []byte {
+	chunk := make([]byte, 0, 8+len(body)+1)
+	chunk = append(chunk, fourCC...)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(body)))
+	chunk = append(chunk, body...)
+	if len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// withWebPExtended 简单格式（只有VP8/VP8L块）转换为扩展格式，以便附加EXIF块
+func withWebPExtended(chunks [][]byte) [][]byte {
+	if string(chunks[0][:4]) == "VP8X" {
+		return chunks
+	}
+
+	header := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(4+len(chunks[0])))...)
+	header = append(header, "WEBP"...)
+	cfg, err := webp.DecodeConfig(bytes.NewReader(append(header, chunks[0]...)))
+	if err != nil {
+		return chunks
+	}
+
+	body := make([]byte, 10)
+	if string(chunks[0][:4]) == "VP8L" {
+		body[0] = webpFlagAlpha
+	}
+	putUint24(body[4:7], uint32(cfg.Width-1))
+	putUint24(body[7:10], uint32(cfg.Height-1))
+	return append([][]byte{webpChunk("VP8X", body)}, chunks...)
+}
+
+// putUint24 写入24位小端整数
+func putUint24(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+// GIF的块引导符和扩展块标签
+const (
+	gifExtension      = 0x21
+	gifImageSeparator = 0x2C
+	gifTrailer        = 0x3B
+
+	gifCommentLabel     = 0xFE
+	gifApplicationLabel = 0xFF
+)
+
+// gifBlock GIF的扩展块或图像块（从引导符到块结束符的全部字节）
+type gifBlock struct {
+	label byte // 扩展块标签，图像块为gifImageSeparator
+	data  []byte
+}
+
+// splitGIF 拆分GIF为文件头（含逻辑屏幕描述符和全局颜色表）和各数据块，到结束符为止
+func splitGIF(data []byte) ([]byte, []gifBlock, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, nil, errInvalidImageData
+	}
+	pos := 13
+	if data[10]&0x80 != 0 {
+		pos += 3 << (data[10]&0x07 + 1)
+	}
+	if pos > len(data) {
+		return nil, nil, errInvalidImageData
+	}
+	header := data[:pos]
+
+	blocks := make([]gifBlock, 0)
+	for {
+		if pos >= len(data) {
+			return nil, nil, errInvalidImageData
+		}
+		start := pos
+		var label byte
+		switch data[pos] {
+		case gifTrailer:
+			return header, blocks, nil
+		case gifExtension:
+			if pos+2 > len(data) {
+				return nil, nil, errInvalidImageData
+			}
+			label = data[pos+1]
+			pos += 2
+		case gifImageSeparator:
+			// 图像描述符10字节，之后是局部颜色表和LZW最小码长
+			if pos+10 > len(data) {
+				return nil, nil, errInvalidImageData
+			}
+			label = gifImageSeparator
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			pos++
+		default:
+			return nil, nil, errInvalidImageData
+		}
+
+		// 数据子块，以长度为0的子块结束
+		for {
+			if pos >= len(data) {
+				return nil, nil, errInvalidImageData
+			}
+			size := int(data[pos])
+			pos += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+		blocks = append(blocks, gifBlock{label: label, data: data[start:pos]})
+	}
+}
+
+// sanitizeGIF 去除GIF的注释扩展和应用扩展（NETSCAPE2.0/ANIMEXTS1.0循环播放设置除外）
+func sanitizeGIF(data []byte) ([]byte, error) {
+	header, blocks, err := splitGIF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, header...)
+	for _, block := range blocks {
+		switch block.label {
+		case gifCommentLabel:
+			continue
+		case gifApplicationLabel:
+			if !isGIFLoopExtension(block.data) {
+				continue
+			}
+		}
+		out = append(out, block.data...)
+	}
+	return append(out, gifTrailer), nil
+}
+
+// isGIFLoopExtension 应用扩展是否为动画循环设置
+func isGIFLoopExtension(block []byte) bool {
+	// 引导符、标签、长度11，之后是8字节应用标识和3字节认证码
+	if len(block) < 14 || block[2] != 11 {
+		return false
+	}
+	id := string(block[3:14])
+	return id == "NETSCAPE2.0" || id == "ANIMEXTS1.0"
+}
+
+// parseExif 从TIFF格式的EXIF数据中读取方向、作者和版权（只读取IFD0）
+func parseExif(tiff []byte) exifInfo {
+	var info exifInfo
+	if len(tiff) < 8 {
+		return info
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return info
+	}
+	if order.Uint16(tiff[2:4]) != 42 {
+		return info
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return info
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entry : entry+2])
+		typ := order.Uint16(tiff[entry+2 : entry+4])
+		n := int(order.Uint32(tiff[entry+4 : entry+8]))
+
+		switch tag {
+		case exifTagOrientation:
+			if typ == 3 {
+				info.orientation = int(order.Uint16(tiff[entry+8 : entry+10]))
+			}
+		case exifTagArtist, exifTagCopyright:
+			if typ != 2 || n <= 0 {
+				continue
+			}
+			var value []byte
+			if n <= 4 {
+				value = tiff[entry+8 : entry+8+n]
+			} else {
+				offset := int(order.Uint32(tiff[entry+8 : entry+12]))
+				if offset < 0 || offset+n > len(tiff) {
+					continue
+				}
+				value = tiff[offset : offset+n]
+			}
+			value = bytes.TrimRight(value, "\x00 ")
+			if len(value) == 0 {
+				continue
+			}
+			if tag == exifTagArtist {
+				info.artist = append([]byte(nil), value...)
+			} else {
+				info.copyright = append([]byte(nil), value...)
+			}
+		}
+	}
+	return info
+}
+
+// buildExif 生成只包含作者和版权的EXIF（TIFF格式，小端），两者都为空时返回nil
+func buildExif(info exifInfo) []byte {
+	type entry struct {
+		tag   uint16
+		value []byte
+	}
+	entries := make([]entry, 0, 2)
+	// IFD中的标签需要按升序排列
+	if len(info.artist) > 0 {
+		entries = append(entries, entry{exifTagArtist, append(append([]byte(nil), info.artist...), 0)})
+	}
+	if len(info.copyright) > 0 {
+		entries = append(entries, entry{exifTagCopyright, append(append([]byte(nil), info.copyright...), 0)})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	order := binary.LittleEndian
+	dataOffset := 8 + 2 + len(entries)*12 + 4
+	out := make([]byte, 0, dataOffset+64)
+	out = append(out, 'I', 'I')
+	out = order.AppendUint16(out, 42)
+	out = order.AppendUint32(out, 8)
+	out = order.AppendUint16(out, uint16(len(entries)))
+
+	var data []byte
+	for _, e := range entries {
+		out = order.AppendUint16(out, e.tag)
+		out = order.AppendUint16(out, 2) // ASCII
+		out = order.AppendUint32(out, uint32(len(e.value)))
+		if len(e.value) <= 4 {
+			inline := make([]byte, 4)
+			copy(inline, e.value)
+			out = append(out, inline...)
+			continue
+		}
+		out = order.AppendUint32(out, uint32(dataOffset+len(data)))
+		data = append(data, e.value...)
+		if len(data)%2 == 1 {
+			data = append(data, 0)
+		}
+	}
+	out = order.AppendUint32(out, 0) // 没有下一个IFD
+	return append(out, data...)
+}
+
+// applyOrientation 按EXIF方向值变换图片，使其以正常方向显示
+func applyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	default:
+		return img
+	}
+}
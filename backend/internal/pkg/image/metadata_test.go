@@ -0,0 +1,245 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/whk-newbie/blog/internal/pkg/filetype"
+)
+
+// webpFile 用给定的块组装WebP文件
+func webpFile(chunks ...[]byte) []byte {
+	size := 4
+	for _, chunk := range chunks {
+		size += len(chunk)
+	}
+	out := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(size))...)
+	out = append(out, "WEBP"...)
+	for _, chunk := range chunks {
+		out = append(out, chunk...)
+	}
+	return out
+}
+
+func TestSanitizeMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   []byte
+	}{
+		{"jpeg too short", FormatJPEG, []byte{0xFF, 0xD8}},
+		{"jpeg missing SOI", FormatJPEG, []byte{0x00, 0x00, 0xFF, 0xDA}},
+		{"jpeg segment length 0", FormatJPEG, []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x00, 0xFF, 0xDA}},
+		{"jpeg segment length 1", FormatJPEG, []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0xFF, 0xDA}},
+		{"jpeg segment past end", FormatJPEG, []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x20, 0xFF, 0xDA}},
+		{"jpeg truncated length", FormatJPEG, []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00}},
+		{"jpeg no marker", FormatJPEG, []byte{0xFF, 0xD8, 0x12, 0x34, 0x56, 0x78}},
+		{"png bad signature", FormatPNG, []byte("\x89PNX\r\n\x1a\n")},
+		{"png chunk past end", FormatPNG, append(append([]byte(nil), pngSignature...), 0x00, 0x00, 0x10, 0x00, 'I', 'H', 'D', 'R', 0, 0, 0, 0)},
+		{"png first chunk not IHDR", FormatPNG, append(append([]byte(nil), pngSignature...), 0, 0, 0, 0, 'I', 'E', 'N', 'D', 0, 0, 0, 0)},
+		{"webp bad header", FormatWebP, []byte("RIFF\x04\x00\x00\x00WEBX")},
+		{"webp no chunks", FormatWebP, webpFile()},
+		{"webp chunk past end", FormatWebP, webpFile([]byte("VP8X\x20\x00\x00\x00"))},
+		{"webp empty VP8X", FormatWebP, webpFile(webpChunk("VP8X", nil))},
+		{"webp short VP8X", FormatWebP, webpFile(webpChunk("VP8X", []byte{0x08, 0, 0, 0}))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Sanitize(tt.data, tt.format, SanitizeOptions{})
+			if !errors.Is(err, errInvalidImageData) {
+				t.Fatalf("Sanitize() = %v, %v; want errInvalidImageData", out, err)
+			}
+		})
+	}
+}
+
+func TestImageEndMalformedJPEG(t *testing.T) {
+	tests := [][]byte{
+		{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x00, 0xFF, 0xD9},
+		{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0xFF, 0xD9},
+		{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF, 0xFF, 0xD9},
+	}
+	for _, data := range tests {
+		if _, err := imageEnd(data, FormatJPEG); err == nil {
+			t.Errorf("imageEnd(% X) returned no error", data)
+		}
+	}
+}
+
+func TestSanitizeStripsJPEGMetadata(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	// 在SOI之后插入注释段和XMP段
+	data := append([]byte(nil), encoded[:2]...)
+	data = append(data, jpegSegment(0xFE, []byte("secret comment"))...)
+	data = append(data, jpegSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x/>"))...)
+	data = append(data, encoded[2:]...)
+
+	out, err := Sanitize(data, FormatJPEG, SanitizeOptions{})
+	if err != nil {
+		t.Fatalf("Sanitize() error = %v", err)
+	}
+	if bytes.Contains(out, []byte("secret comment")) || bytes.Contains(out, []byte("ns.adobe.com")) {
+		t.Error("metadata segments were not removed")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("sanitized JPEG does not decode: %v", err)
+	}
+}
+
+func TestSanitizeStripsPNGText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := splitPNG(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tEXt块放在IHDR之后（CRC不参与处理，这里留空）
+	text := []byte("Comment\x00secret")
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)))
+	chunk = append(chunk, "tEXt"...)
+	chunk = append(chunk, text...)
+	chunk = append(chunk, 0, 0, 0, 0)
+
+	data := append([]byte(nil), pngSignature...)
+	data = append(data, chunks[0]...)
+	data = append(data, chunk...)
+	for _, c := range chunks[1:] {
+		data = append(data, c...)
+	}
+
+	out, err := Sanitize(data, FormatPNG, SanitizeOptions{})
+	if err != nil {
+		t.Fatalf("Sanitize() error = %v", err)
+	}
+	if bytes.Contains(out, []byte("secret")) {
+		t.Error("tEXt chunk was not removed")
+	}
+	if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+		t.Errorf("sanitized PNG does not decode: %v", err)
+	}
+}
+
+func TestSanitizeStripsGIFExtensions(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Black, color.White})
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{img, img}, Delay: []int{10, 10}}); err != nil {
+		t.Fatal(err)
+	}
+	header, _, err := splitGIF(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 在文件头后插入注释扩展和自定义应用扩展
+	comment := []byte("secret")
+	data := append([]byte(nil), header...)
+	data = append(data, gifExtension, gifCommentLabel, byte(len(comment)))
+	data = append(data, comment...)
+	data = append(data, 0)
+	data = append(data, gifExtension, gifApplicationLabel, 11)
+	data = append(data, "XMP DataXMP"...)
+	data = append(data, 6)
+	data = append(data, "hidden"...)
+	data = append(data, 0)
+	data = append(data, buf.Bytes()[len(header):]...)
+
+	out, err := Sanitize(data, FormatGIF, SanitizeOptions{})
+	if err != nil {
+		t.Fatalf("Sanitize() error = %v", err)
+	}
+	if bytes.Contains(out, []byte("secret")) {
+		t.Error("comment extension was not removed")
+	}
+	if bytes.Contains(out, []byte("XMP DataXMP")) {
+		t.Error("application extension was not removed")
+	}
+	if !bytes.Contains(out, []byte("NETSCAPE2.0")) {
+		t.Error("loop extension was removed")
+	}
+	decoded, err := gif.DecodeAll(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("sanitized GIF does not decode: %v", err)
+	}
+	if len(decoded.Image) != 2 {
+		t.Errorf("sanitized GIF has %d frames, want 2", len(decoded.Image))
+	}
+}
+
+func TestValidateRejectsMarkupInMetadata(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+
+	var jpegBuf bytes.Buffer
+	if err := jpeg.Encode(&jpegBuf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	encoded := jpegBuf.Bytes()
+	jpegData := append([]byte{0xFF, 0xD8}, jpegSegment(0xFE, []byte("<svg onload=alert(1)>"))...)
+	jpegData = append(jpegData, encoded[2:]...)
+
+	var gifBuf bytes.Buffer
+	if err := gif.Encode(&gifBuf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	header, _, err := splitGIF(gifBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment := []byte("<script>alert(1)</script>")
+	gifData := append([]byte(nil), header...)
+	gifData = append(gifData, gifExtension, 0xFE, byte(len(comment)))
+	gifData = append(gifData, comment...)
+	gifData = append(gifData, 0)
+	gifData = append(gifData, gifBuf.Bytes()[len(header):]...)
+
+	tests := []struct {
+		name   string
+		format string
+		data   []byte
+	}{
+		{"jpeg comment", FormatJPEG, jpegData},
+		{"gif comment", FormatGIF, gifData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.data, tt.format); !errors.Is(err, filetype.ErrEmbeddedContent) {
+				t.Fatalf("Validate() = %v; want ErrEmbeddedContent", err)
+			}
+		})
+	}
+}
+
+func TestValidateIgnoresPixelData(t *testing.T) {
+	// 不压缩时像素数据原样写入IDAT块
+	img := image.NewGray(image.Rect(0, 0, 4, 1))
+	copy(img.Pix, "<svg")
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.NoCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("<svg")) {
+		t.Fatal("pixel data is not stored verbatim")
+	}
+
+	if err := Validate(buf.Bytes(), FormatPNG); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
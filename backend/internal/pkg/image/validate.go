@@ -0,0 +1,185 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/disintegration/imaging"
+	"github.com/whk-newbie/blog/internal/pkg/filetype"
+)
+
+// Validate 检查图片能否完整解码，且没有在图片数据之后拼接其他文件或在元数据中夹带网页脚本（多格式文件）
+// 像素数据不做检查，由重新编码和 X-Content-Type-Options: nosniff 防护
+func Validate(data []byte, format string) error {
+	if _, err := imaging.Decode(bytes.NewReader(data)); err != nil {
+		return filetype.ErrCorrupted
+	}
+
+	end, err := imageEnd(data, format)
+	if err != nil {
+		return err
+	}
+	// 部分相机会在文件末尾补零，其他尾随数据一律拒绝
+	if len(bytes.TrimRight(data[end:], "\x00")) > 0 {
+		return filetype.ErrEmbeddedContent
+	}
+
+	blocks, err := metadataBlocks(data[:end], format)
+	if err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		if filetype.ContainsMarkup(block) {
+			return filetype.ErrEmbeddedContent
+		}
+	}
+	return nil
+}
+
+// metadataBlocks 图片中除像素数据以外的块（JPEG的APPn和注释段，PNG、WebP的辅助块，GIF的扩展块）
+func metadataBlocks(data []byte, format string) ([][]byte, error) {
+	var blocks [][]byte
+	switch format {
+	case FormatJPEG:
+		pos := 2
+		for pos+2 <= len(data) {
+			if data[pos] != 0xFF {
+				return nil, filetype.ErrCorrupted
+			}
+			marker := data[pos+1]
+			if marker == 0xFF {
+				pos++
+				continue
+			}
+			if marker == 0xD9 {
+				break
+			}
+			if (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 {
+				pos += 2
+				continue
+			}
+			if pos+4 > len(data) {
+				return nil, filetype.ErrCorrupted
+			}
+			length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+			end := pos + 2 + length
+			if length < 2 || end > len(data) {
+				return nil, filetype.ErrCorrupted
+			}
+			if (marker >= 0xE0 && marker <= 0xEF) || marker == 0xFE {
+				blocks = append(blocks, data[pos:end])
+			}
+			pos = end
+
+			// 跳过扫描数据，0xFF后跟0x00（转义）或RST标记时仍属于扫描数据
+			// 渐进式JPEG的各次扫描之间还可能出现注释段
+			if marker == 0xDA {
+				for pos+1 < len(data) {
+					next := data[pos+1]
+					if data[pos] == 0xFF && next != 0x00 && (next < 0xD0 || next > 0xD7) {
+						break
+					}
+					pos++
+				}
+			}
+		}
+	case FormatPNG:
+		chunks, err := splitPNG(data)
+		if err != nil {
+			return nil, filetype.ErrCorrupted
+		}
+		for _, chunk := range chunks {
+			if chunkType := string(chunk[4:8]); chunkType != "IDAT" && chunkType != "fdAT" {
+				blocks = append(blocks, chunk)
+			}
+		}
+	case FormatWebP:
+		chunks, err := splitWebP(data)
+		if err != nil {
+			return nil, filetype.ErrCorrupted
+		}
+		for _, chunk := range chunks {
+			switch string(chunk[:4]) {
+			case "VP8 ", "VP8L", "ALPH", "ANMF":
+			default:
+				blocks = append(blocks, chunk)
+			}
+		}
+	case FormatGIF:
+		_, gifBlocks, err := splitGIF(data)
+		if err != nil {
+			return nil, filetype.ErrCorrupted
+		}
+		for _, block := range gifBlocks {
+			if block.label != gifImageSeparator {
+				blocks = append(blocks, block.data)
+			}
+		}
+	}
+	return blocks, nil
+}
+
+// imageEnd 图片数据的结束位置
+func imageEnd(data []byte, format string) (int, error) {
+	switch format {
+	case FormatJPEG:
+		// 跳过扫描开始前的各段（段内可能包含缩略图的结束标记），之后第一个EOI即为图片结束
+		pos := 2
+		for pos+4 <= len(data) && data[pos] == 0xFF {
+			marker := data[pos+1]
+			if marker == 0xFF {
+				pos++
+				continue
+			}
+			if marker == 0xDA {
+				break
+			}
+			if (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 {
+				pos += 2
+				continue
+			}
+			length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+			if length < 2 {
+				return 0, filetype.ErrCorrupted
+			}
+			pos += 2 + length
+		}
+		if pos > len(data) {
+			return 0, filetype.ErrCorrupted
+		}
+		i := bytes.Index(data[pos:], []byte{0xFF, 0xD9})
+		if i < 0 {
+			return 0, filetype.ErrCorrupted
+		}
+		return pos + i + 2, nil
+	case FormatPNG:
+		chunks, err := splitPNG(data)
+		if err != nil {
+			return 0, filetype.ErrCorrupted
+		}
+		end := len(pngSignature)
+		for _, chunk := range chunks {
+			end += len(chunk)
+		}
+		return end, nil
+	case FormatWebP:
+		if len(data) < 12 {
+			return 0, filetype.ErrCorrupted
+		}
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		end := 8 + size + size%2
+		if end > len(data) {
+			return 0, filetype.ErrCorrupted
+		}
+		return end, nil
+	case FormatGIF:
+		// GIF以0x3B结尾
+		trimmed := bytes.TrimRight(data, "\x00")
+		if len(trimmed) == 0 || trimmed[len(trimmed)-1] != 0x3B {
+			return 0, filetype.ErrEmbeddedContent
+		}
+		return len(trimmed), nil
+	default:
+		return 0, filetype.ErrUnknownType
+	}
+}
@@ -0,0 +1,162 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/whk-newbie/blog/internal/pkg/storage"
+)
+
+// 图片格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+// DefaultVariantWidths 默认生成的宽度变体
+var DefaultVariantWidths = []int{320, 640, 1280}
+
+// Variant 图片宽度变体
+type Variant struct {
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Key      string `json:"key"`       // 存储中的对象key
+	MimeType string `json:"mime_type"` // image/webp 或原图格式
+	Size     int64  `json:"size"`
+}
+
+// FormatFromKey 根据扩展名判断图片格式
+func FormatFromKey(key string) (string, bool) {
+	switch strings.ToLower(path.Ext(key)) {
+	case ".jpg", ".jpeg":
+		return FormatJPEG, true
+	case ".png":
+		return FormatPNG, true
+	case ".gif":
+		return FormatGIF, true
+	case ".webp":
+		return FormatWebP, true
+	default:
+		return "", false
+	}
+}
+
+// MimeType 图片格式对应的MIME类型
+func MimeType(format string) string {
+	return "image/" + format
+}
+
+// Extension 图片格式对应的扩展名
+func Extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// Resizable 该格式是否支持生成缩放图（GIF缩放会丢失动画，保持原图）
+func Resizable(format string) bool {
+	return format == FormatJPEG || format == FormatPNG || format == FormatWebP
+}
+
+// Resize 按宽度等比缩放，不放大
+func Resize(img image.Image, width int) image.Image {
+	if width <= 0 || img.Bounds().Dx() <= width {
+		return img
+	}
+	return imaging.Resize(img, width, 0, imaging.Lanczos)
+}
+
+// Encode 按格式编码图片
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: CompressQuality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatWebP:
+		return encodeWebP(w, img, CompressQuality)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// NormalizeWidths 去重、去掉非正数并升序排列
+func NormalizeWidths(widths []int) []int {
+	seen := make(map[int]bool, len(widths))
+	result := make([]int, 0, len(widths))
+	for _, w := range widths {
+		if w > 0 && !seen[w] {
+			seen[w] = true
+			result = append(result, w)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+// VariantKey 宽度变体的对象key，如 2024/01/xxx.jpg -> 2024/01/xxx-640w.webp
+func VariantKey(key string, width int, format string) string {
+	return fmt.Sprintf("%s-%dw%s", strings.TrimSuffix(key, path.Ext(key)), width, Extension(format))
+}
+
+// GenerateVariants 为上传的图片生成宽度变体（WebP和原图格式各一份）并写入存储
+// 只生成小于原图宽度的变体；GIF等不支持缩放的格式不生成
+func GenerateVariants(ctx context.Context, backend storage.Backend, key string, r io.Reader, widths []int) ([]Variant, error) {
+	format, ok := FormatFromKey(key)
+	if !ok || !Resizable(format) {
+		return nil, nil
+	}
+
+	src, err := imaging.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	formats := []string{FormatWebP}
+	if format != FormatWebP {
+		formats = append(formats, format)
+	}
+
+	variants := make([]Variant, 0)
+	for _, width := range NormalizeWidths(widths) {
+		if width >= src.Bounds().Dx() {
+			break
+		}
+		resized := Resize(src, width)
+
+		for _, f := range formats {
+			var buf bytes.Buffer
+			if err := Encode(&buf, resized, f); err != nil {
+				return variants, err
+			}
+
+			variantKey := VariantKey(key, width, f)
+			size := int64(buf.Len())
+			if err := backend.Put(ctx, variantKey, &buf, size, MimeType(f)); err != nil {
+				return variants, fmt.Errorf("failed to save variant: %w", err)
+			}
+
+			variants = append(variants, Variant{
+				Width:    width,
+				Height:   resized.Bounds().Dy(),
+				Key:      variantKey,
+				MimeType: MimeType(f),
+				Size:     size,
+			})
+		}
+	}
+
+	return variants, nil
+}
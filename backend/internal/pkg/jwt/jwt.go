@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -12,20 +14,29 @@ var (
 	ErrTokenNotValidYet = errors.New("token not active yet")
 	ErrTokenMalformed   = errors.New("token is malformed")
 	ErrTokenInvalid     = errors.New("token is invalid")
+	ErrTokenRevoked     = errors.New("token has been revoked")
 )
 
 // Claims JWT声明
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"` // 管理员角色
+	SessionID string `json:"sid"`  // 登录会话ID（同一会话轮换刷新Token时保持不变）
 	jwt.RegisteredClaims
 }
 
+// Denylist 已吊销Token查询接口（按jti判断）
+type Denylist interface {
+	IsRevoked(jti string) bool
+}
+
 // Manager JWT管理器
 type Manager struct {
 	secret     []byte
 	expireTime time.Duration
 	issuer     string
+	denylist   Denylist
 }
 
 // NewManager 创建JWT管理器
@@ -37,15 +48,28 @@ func NewManager(secret string, expireTime time.Duration, issuer string) *Manager
 	}
 }
 
-// GenerateToken 生成JWT Token
-func (m *Manager) GenerateToken(userID uint, username string) (string, error) {
+// SetDenylist 设置吊销名单，设置后 ParseToken 会拒绝已吊销或缺少jti的Token
+func (m *Manager) SetDenylist(denylist Denylist) {
+	m.denylist = denylist
+}
+
+// GenerateToken 生成JWT Token，返回Token及其声明（包含jti和过期时间）
+func (m *Manager) GenerateToken(userID uint, username, role, sessionID string) (string, *Claims, error) {
 	now := time.Now()
 	expiresAt := now.Add(m.expireTime)
 
-	claims := Claims{
-		UserID:   userID,
-		Username: username,
+	jti, err := newTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	claims := &Claims{
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
@@ -54,7 +78,11 @@ func (m *Manager) GenerateToken(userID uint, username string) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secret)
+	signed, err := token.SignedString(m.secret)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
 // ParseToken 解析JWT Token
@@ -75,26 +103,26 @@ func (m *Manager) ParseToken(tokenString string) (*Claims, error) {
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if m.denylist != nil && (claims.ID == "" || m.denylist.IsRevoked(claims.ID)) {
+			return nil, ErrTokenRevoked
+		}
 		return claims, nil
 	}
 
 	return nil, ErrTokenInvalid
 }
 
-// RefreshToken 刷新Token
-func (m *Manager) RefreshToken(tokenString string) (string, error) {
-	claims, err := m.ParseToken(tokenString)
-	if err != nil {
-		return "", err
-	}
-
-	// 生成新的token
-	return m.GenerateToken(claims.UserID, claims.Username)
-}
-
 // VerifyToken 验证Token是否有效
 func (m *Manager) VerifyToken(tokenString string) bool {
 	_, err := m.ParseToken(tokenString)
 	return err == nil
 }
 
+// newTokenID 生成随机的jti
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,240 @@
+package markup
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 正文格式
+const (
+	FormatHTML     = "html"     // 富文本（HTML）
+	FormatMarkdown = "markdown" // Markdown
+)
+
+// Heading 目录条目
+type Heading struct {
+	Level int    `json:"level"` // 标题级别（1-6）
+	Text  string `json:"text"`  // 标题文本
+	ID    string `json:"id"`    // 锚点ID
+}
+
+// Result 渲染结果
+type Result struct {
+	HTML string    // 清洗后的HTML
+	TOC  []Heading // 目录（按出现顺序）
+}
+
+var (
+	// anchorIDPattern 允许保留的标题锚点ID
+	anchorIDPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+	// classPattern 允许的class（编辑器与代码高亮使用）
+	classPattern = regexp.MustCompile(`^[\w-]+( [\w-]+)*$`)
+	// colorPattern 允许的颜色值
+	colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|rgba?\([\d\s.,%]+\)|[a-zA-Z]+)$`)
+
+	markdown = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		// 原始HTML交由后续的白名单清洗处理
+		goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+	)
+
+	policy = newPolicy()
+)
+
+// newPolicy 创建白名单清洗策略
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(anchorIDPattern).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("class").Matching(classPattern).Globally()
+	p.AllowStyles("color", "background-color").Matching(colorPattern).Globally()
+	p.AllowStyles("text-align").MatchingEnum("left", "right", "center", "justify").Globally()
+	p.AllowDataURIImages()
+	// GFM任务列表
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
+
+// Render 将正文渲染为清洗后的HTML，并为标题生成锚点和目录
+func Render(format, source string) (*Result, error) {
+	raw := source
+	switch format {
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		if err := markdown.Convert([]byte(source), &buf); err != nil {
+			return nil, err
+		}
+		raw = buf.String()
+	case FormatHTML, "":
+	default:
+		return nil, fmt.Errorf("unsupported content format: %s", format)
+	}
+
+	return anchor(Sanitize(raw))
+}
+
+// Sanitize 按白名单清洗HTML
+func Sanitize(raw string) string {
+	return policy.Sanitize(raw)
+}
+
+// anchor 为h1-h6生成唯一锚点ID并收集目录
+func anchor(content string) (*Result, error) {
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{TOC: []Heading{}}
+	used := make(map[string]int)
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if level := headingLevel(n.DataAtom); level > 0 {
+				text := strings.Join(strings.Fields(textContent(n)), " ")
+				id := getAttr(n, "id")
+				if id == "" {
+					id = slugify(text)
+				}
+				id = uniqueID(id, used)
+				setAttr(n, "id", id)
+				result.TOC = append(result.TOC, Heading{Level: level, Text: text, ID: id})
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		walk(n)
+		if err := html.Render(&buf, n); err != nil {
+			return nil, err
+		}
+	}
+	result.HTML = buf.String()
+
+	return result, nil
+}
+
+// headingLevel 返回标题级别，非标题返回0
+func headingLevel(a atom.Atom) int {
+	switch a {
+	case atom.H1:
+		return 1
+	case atom.H2:
+		return 2
+	case atom.H3:
+		return 3
+	case atom.H4:
+		return 4
+	case atom.H5:
+		return 5
+	case atom.H6:
+		return 6
+	}
+	return 0
+}
+
+// textContent 获取节点的纯文本
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
+
+// slugify 由标题文本生成锚点ID（保留中文等Unicode字母）
+func slugify(text string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			sb.WriteRune(r)
+			dash = false
+		case !dash && sb.Len() > 0:
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	id := strings.TrimRight(sb.String(), "-")
+	if id == "" {
+		id = "section"
+	}
+	return id
+}
+
+// uniqueID 重复的ID追加序号
+func uniqueID(id string, used map[string]int) string {
+	candidate := id
+	for used[candidate] > 0 {
+		candidate = fmt.Sprintf("%s-%d", id, used[id])
+		used[id]++
+	}
+	used[candidate]++
+	return candidate
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// PlainText 提取HTML中的纯文本（跳过script/style），用于全文检索
+func PlainText(content string) string {
+	var sb strings.Builder
+	z := html.NewTokenizer(strings.NewReader(content))
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(sb.String())
+		case html.StartTagToken:
+			if name, _ := z.TagName(); string(name) == "script" || string(name) == "style" {
+				skip++
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); (string(name) == "script" || string(name) == "style") && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				sb.Write(z.Text())
+				sb.WriteByte(' ')
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package rbac
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/whk-newbie/blog/internal/models"
+)
+
+// Permission 权限标识
+type Permission string
+
+const (
+	PermArticleRead      Permission = "article:read"      // 查看文章（含草稿和修订历史）
+	PermArticleWrite     Permission = "article:write"     // 创建、编辑文章
+	PermArticlePublish   Permission = "article:publish"   // 发布、取消发布文章
+	PermArticleDelete    Permission = "article:delete"    // 删除文章
+	PermArticleManage    Permission = "article:manage"    // 文章维护（如重建检索索引）
+	PermTaxonomyWrite    Permission = "taxonomy:write"    // 管理分类和标签
+	PermCommentRead      Permission = "comment:read"      // 查看评论
+	PermCommentWrite     Permission = "comment:write"     // 审核、删除评论
+	PermUploadWrite      Permission = "upload:write"      // 上传文件
+	PermMediaManage      Permission = "media:manage"      // 删除文件、清理未引用文件
+	PermStatsRead        Permission = "stats:read"        // 查看统计
+	PermStatsManage      Permission = "stats:manage"      // 统计维护（如补齐、重新汇总日汇总数据）
+	PermFingerprintRead  Permission = "fingerprint:read"  // 查看访客指纹
+	PermFingerprintWrite Permission = "fingerprint:write" // 修改、删除访客指纹
+	PermCrawlerRead      Permission = "crawler:read"      // 查看爬虫任务
+	PermCrawlerWrite     Permission = "crawler:write"     // 管理爬虫任务
+	PermConfigManage     Permission = "config:manage"     // 系统配置（含密钥等敏感信息）
+	PermLogRead          Permission = "log:read"          // 查看系统日志
+	PermLogWrite         Permission = "log:write"         // 清理系统日志
+	PermBackupManage     Permission = "backup:manage"     // 数据库备份
+	PermUserManage       Permission = "user:manage"       // 管理后台账号
+)
+
+// rolePermissions 各角色拥有的权限（owner拥有全部权限，不在此列出）
+// 作者对文章的写、发布、删除权限只限于自己的文章，由文章处理器校验归属
+var rolePermissions = map[models.AdminRole][]Permission{
+	models.AdminRoleEditor: {
+		PermArticleRead, PermArticleWrite, PermArticlePublish, PermArticleDelete, PermArticleManage,
+		PermTaxonomyWrite,
+		PermCommentRead, PermCommentWrite,
+		PermUploadWrite, PermMediaManage,
+		PermStatsRead, PermStatsManage,
+		PermFingerprintRead,
+		PermCrawlerRead,
+		PermLogRead,
+	},
+	models.AdminRoleAuthor: {
+		PermArticleRead, PermArticleWrite, PermArticlePublish, PermArticleDelete,
+		PermCommentRead,
+		PermUploadWrite,
+		PermStatsRead,
+	},
+	models.AdminRoleViewer: {
+		PermArticleRead,
+		PermCommentRead,
+		PermStatsRead,
+		PermFingerprintRead,
+		PermCrawlerRead,
+		PermLogRead,
+	},
+}
+
+// resourcePermission 资源读写所需的权限
+type resourcePermission struct {
+	read  Permission
+	write Permission
+}
+
+// resources 按 /admin/ 之后的第一段路径划分资源，未登记的资源只有owner可以访问
+var resources = map[string]resourcePermission{
+	"articles":     {read: PermArticleRead, write: PermArticleWrite},
+	"categories":   {read: PermArticleRead, write: PermTaxonomyWrite},
+	"tags":         {read: PermArticleRead, write: PermTaxonomyWrite},
+	"comments":     {read: PermCommentRead, write: PermCommentWrite},
+	"upload":       {read: PermUploadWrite, write: PermUploadWrite},
+	"media":        {read: PermUploadWrite, write: PermMediaManage},
+	"stats":        {read: PermStatsRead, write: PermStatsManage},
+	"fingerprints": {read: PermFingerprintRead, write: PermFingerprintWrite},
+	"crawler":      {read: PermCrawlerRead, write: PermCrawlerWrite},
+	"configs":      {read: PermConfigManage, write: PermConfigManage},
+	"logs":         {read: PermLogRead, write: PermLogWrite},
+	"backups":      {read: PermBackupManage, write: PermBackupManage},
+	"users":        {read: PermUserManage, write: PermUserManage},
+	"login-locks":  {read: PermUserManage, write: PermUserManage},
+}
+
+// HasPermission 判断角色是否拥有指定权限
+func HasPermission(role models.AdminRole, perm Permission) bool {
+	if role == models.AdminRoleOwner {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiredPermission 根据请求方法和路由模板（如 /api/v1/admin/articles/:id）计算所需权限
+// 路由不在 /admin 下时第二个返回值为 false
+func RequiredPermission(method, fullPath string) (Permission, bool) {
+	idx := strings.Index(fullPath, "/admin/")
+	if idx < 0 {
+		return "", false
+	}
+	segments := strings.Split(strings.Trim(fullPath[idx+len("/admin/"):], "/"), "/")
+
+	res, ok := resources[segments[0]]
+	if !ok {
+		return PermUserManage, true
+	}
+
+	if method == http.MethodGet || method == http.MethodHead {
+		return res.read, true
+	}
+
+	// 文章的细分操作
+	if segments[0] == "articles" {
+		last := segments[len(segments)-1]
+		switch {
+		case last == "publish" || last == "unpublish":
+			return PermArticlePublish, true
+		case len(segments) > 1 && segments[1] == "search-index":
+			return PermArticleManage, true
+		case method == http.MethodDelete && len(segments) == 2:
+			return PermArticleDelete, true
+		}
+	}
+
+	return res.write, true
+}
+
+// OwnsArticleOnly 该角色是否只能操作自己的文章
+func OwnsArticleOnly(role models.AdminRole) bool {
+	return role == models.AdminRoleAuthor
+}
@@ -92,3 +92,25 @@ func Exists(keys ...string) (int64, error) {
 func Expire(key string, expiration time.Duration) error {
 	return client.Expire(ctx, key, expiration).Err()
 }
+
+// DelByPattern 按模式删除键（使用SCAN遍历，避免KEYS阻塞）
+func DelByPattern(pattern string) error {
+	iter := client.Scan(ctx, 0, pattern, 100).Iterator()
+	keys := make([]string, 0, 100)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) >= 100 {
+			if err := client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return client.Del(ctx, keys...).Err()
+	}
+	return nil
+}
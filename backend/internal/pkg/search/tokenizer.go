@@ -0,0 +1,257 @@
+package search
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// 可选的分词器
+const (
+	TokenizerCJKBigram = "cjk_bigram" // 中日韩文本按二元组切分，其他按单词切分（默认）
+	TokenizerSimple    = "simple"     // 按非字母数字字符切分，中文整段作为一个词
+)
+
+// 高亮标记：ts_headline 的 StartSel/StopSel 使用私有区字符，避免与正文内容冲突
+const (
+	highlightStart = "\ue000"
+	highlightStop  = "\ue001"
+)
+
+// HeadlineOptions ts_headline 的选项（配合 Tokenizer.Highlight 使用）
+const HeadlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop +
+	`, MaxWords=60, MinWords=20, MaxFragments=2, FragmentDelimiter=" ... "`
+
+// Tokenizer 分词器接口
+// 写入时对文本分词后存入 search_* 列，由数据库触发器以 simple 配置生成 tsvector；
+// 查询时必须使用同一分词器生成 tsquery，否则无法命中
+type Tokenizer interface {
+	// Tokenize 将文本切分为以空格分隔的词元
+	Tokenize(text string) string
+	// Query 将搜索关键词转换为 to_tsquery('simple', ...) 表达式，没有有效词元时返回空字符串
+	Query(keyword string) string
+	// Highlight 将 ts_headline 返回的分词片段还原为可读文本，命中部分用 <mark> 包裹，其余内容做HTML转义
+	Highlight(fragment string) string
+}
+
+// New 根据名称创建分词器，名称为空时使用 cjk_bigram
+func New(name string) (Tokenizer, error) {
+	switch name {
+	case "", TokenizerCJKBigram:
+		return &bigramTokenizer{}, nil
+	case TokenizerSimple:
+		return &simpleTokenizer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown search tokenizer: %s", name)
+	}
+}
+
+// run 连续的同类字符
+type run struct {
+	text string
+	cjk  bool
+}
+
+// splitRuns 将文本切分为单词段和中日韩文字段，其余字符视为分隔符
+func splitRuns(text string) []run {
+	var runs []run
+	var sb strings.Builder
+	cjk := false
+
+	flush := func() {
+		if sb.Len() > 0 {
+			runs = append(runs, run{text: sb.String(), cjk: cjk})
+			sb.Reset()
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			if !cjk {
+				flush()
+			}
+			cjk = true
+			sb.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			if cjk {
+				flush()
+			}
+			cjk = false
+			sb.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return runs
+}
+
+// isCJK 是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// bigrams 将中日韩文字段切分为重叠的二元组，单字直接返回
+func bigrams(s string) []string {
+	runes := []rune(s)
+	if len(runes) < 2 {
+		return []string{s}
+	}
+	grams := make([]string, 0, len(runes)-1)
+	for i := 0; i < len(runes)-1; i++ {
+		grams = append(grams, string(runes[i:i+2]))
+	}
+	return grams
+}
+
+// quote 转义为 tsquery 词元
+func quote(lexeme string) string {
+	return "'" + strings.ReplaceAll(lexeme, "'", "''") + "'"
+}
+
+// bigramTokenizer 中日韩二元组分词器
+type bigramTokenizer struct{}
+
+// Tokenize 中日韩文字段输出重叠二元组，其余按单词输出
+func (t *bigramTokenizer) Tokenize(text string) string {
+	var tokens []string
+	for _, r := range splitRuns(text) {
+		if r.cjk {
+			tokens = append(tokens, bigrams(r.text)...)
+		} else {
+			tokens = append(tokens, r.text)
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// Query 每段关键词内的二元组要求相邻出现（<->），各段之间为与关系；单个汉字按前缀匹配
+func (t *bigramTokenizer) Query(keyword string) string {
+	var terms []string
+	for _, r := range splitRuns(keyword) {
+		if !r.cjk {
+			terms = append(terms, quote(r.text))
+			continue
+		}
+		grams := bigrams(r.text)
+		if len([]rune(r.text)) == 1 {
+			terms = append(terms, quote(r.text)+":*")
+			continue
+		}
+		quoted := make([]string, len(grams))
+		for i, g := range grams {
+			quoted[i] = quote(g)
+		}
+		if len(quoted) == 1 {
+			terms = append(terms, quoted[0])
+		} else {
+			terms = append(terms, "("+strings.Join(quoted, " <-> ")+")")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// Highlight 合并首尾重叠的二元组，还原连续的中日韩文字
+func (t *bigramTokenizer) Highlight(fragment string) string {
+	return restore(fragment, true)
+}
+
+// simpleTokenizer 简单分词器
+type simpleTokenizer struct{}
+
+// Tokenize 按单词和中日韩文字段输出
+func (t *simpleTokenizer) Tokenize(text string) string {
+	runs := splitRuns(text)
+	tokens := make([]string, len(runs))
+	for i, r := range runs {
+		tokens[i] = r.text
+	}
+	return strings.Join(tokens, " ")
+}
+
+// Query 各词之间为与关系
+func (t *simpleTokenizer) Query(keyword string) string {
+	runs := splitRuns(keyword)
+	terms := make([]string, len(runs))
+	for i, r := range runs {
+		terms[i] = quote(r.text)
+	}
+	return strings.Join(terms, " & ")
+}
+
+// Highlight 去掉中日韩文字之间的空格
+func (t *simpleTokenizer) Highlight(fragment string) string {
+	return restore(fragment, false)
+}
+
+// restore 将分词片段还原为带 <mark> 的HTML
+// mergeBigrams 为 true 时，相邻且首尾重叠的两字词元视为同一文字段的二元组
+func restore(fragment string, mergeBigrams bool) string {
+	var chars []rune
+	var marks []bool
+	prevCJK := false
+	var prevLast rune
+
+	for _, token := range strings.Fields(fragment) {
+		marked := strings.Contains(token, highlightStart)
+		token = strings.ReplaceAll(strings.ReplaceAll(token, highlightStart, ""), highlightStop, "")
+		runes := []rune(token)
+		if len(runes) == 0 {
+			continue
+		}
+
+		cjk := true
+		for _, r := range runes {
+			if !isCJK(r) {
+				cjk = false
+				break
+			}
+		}
+
+		switch {
+		case cjk && prevCJK && mergeBigrams && len(runes) == 2 && runes[0] == prevLast:
+			marks[len(marks)-1] = marks[len(marks)-1] || marked
+			chars = append(chars, runes[1])
+			marks = append(marks, marked)
+		case cjk && prevCJK:
+			for _, r := range runes {
+				chars = append(chars, r)
+				marks = append(marks, marked)
+			}
+		default:
+			if len(chars) > 0 {
+				chars = append(chars, ' ')
+				marks = append(marks, false)
+			}
+			for _, r := range runes {
+				chars = append(chars, r)
+				marks = append(marks, marked)
+			}
+		}
+
+		prevCJK = cjk
+		prevLast = runes[len(runes)-1]
+	}
+
+	var sb strings.Builder
+	open := false
+	for i, r := range chars {
+		if marks[i] != open {
+			if marks[i] {
+				sb.WriteString("<mark>")
+			} else {
+				sb.WriteString("</mark>")
+			}
+			open = marks[i]
+		}
+		sb.WriteString(html.EscapeString(string(r)))
+	}
+	if open {
+		sb.WriteString("</mark>")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,104 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+// MaxURLsPerFile 单个Sitemap文件允许的最大URL数（协议上限）
+const MaxURLsPerFile = 50000
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL Sitemap中的一条地址
+type URL struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string  // always/hourly/daily/weekly/monthly/yearly/never
+	Priority   float64 // 0.0 - 1.0，为0时不输出
+}
+
+// IndexEntry Sitemap索引中的一个子文件
+type IndexEntry struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	URLs    []urlXML `xml:"url"`
+}
+
+type urlXML struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	NS       string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapXML `xml:"sitemap"`
+}
+
+type sitemapXML struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// URLSet 生成 <urlset> 文档
+func URLSet(urls []URL) ([]byte, error) {
+	doc := urlSet{NS: xmlns}
+	for _, u := range urls {
+		item := urlXML{
+			Loc:        u.Loc,
+			LastMod:    formatTime(u.LastMod),
+			ChangeFreq: u.ChangeFreq,
+		}
+		if u.Priority > 0 {
+			item.Priority = formatPriority(u.Priority)
+		}
+		doc.URLs = append(doc.URLs, item)
+	}
+	return marshal(doc)
+}
+
+// Index 生成 <sitemapindex> 文档
+func Index(entries []IndexEntry) ([]byte, error) {
+	doc := sitemapIndex{NS: xmlns}
+	for _, e := range entries {
+		doc.Sitemaps = append(doc.Sitemaps, sitemapXML{
+			Loc:     e.Loc,
+			LastMod: formatTime(e.LastMod),
+		})
+	}
+	return marshal(doc)
+}
+
+// marshal 序列化并添加XML声明
+func marshal(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// formatTime 使用W3C Datetime格式
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatPriority 优先级保留一位小数
+func formatPriority(p float64) string {
+	if p > 1 {
+		p = 1
+	}
+	return strconv.FormatFloat(p, 'f', 1, 64)
+}
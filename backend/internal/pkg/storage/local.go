@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Local 本地磁盘存储
+type Local struct {
+	root string // 存储根目录
+}
+
+// NewLocal 创建本地磁盘存储，根目录为空时使用 ./uploads
+func NewLocal(root string) (*Local, error) {
+	if root == "" {
+		root = "./uploads"
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	return &Local{root: root}, nil
+}
+
+// Root 存储根目录（用于静态文件服务）
+func (l *Local) Root() string {
+	return l.root
+}
+
+// Put 写入文件（先写临时文件再重命名，避免读到写了一半的文件）
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	fullPath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	// 与 SaveUploadedFile 保持一致的权限
+	_ = os.Chmod(tmpPath, 0644)
+
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	return nil
+}
+
+// Get 读取文件
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	fullPath, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// Delete 删除文件
+func (l *Local) Delete(ctx context.Context, key string) error {
+	fullPath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// URL 文件访问地址
+func (l *Local) URL(key string) string {
+	return URLPrefix + "/" + key
+}
+
+// path 将key转换为磁盘路径
+func (l *Local) path(key string) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(cleaned)), nil
+}
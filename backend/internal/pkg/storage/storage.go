@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/whk-newbie/blog/internal/config"
+)
+
+// 可选的存储类型
+const (
+	TypeLocal = "local" // 本地磁盘（默认）
+	TypeS3    = "s3"    // S3兼容的对象存储（AWS S3、MinIO等）
+)
+
+// URLPrefix 上传文件对外访问路径前缀
+// 本地存储由静态文件服务提供；S3未配置公开地址时由服务端代理读取
+const URLPrefix = "/uploads"
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Backend 文件存储后端接口
+// key 为以 / 分隔的相对路径（如 2024/01/xxx.webp），不能以 / 开头，也不能包含 ..
+type Backend interface {
+	// Put 写入对象，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，不存在时返回 ErrNotFound，调用方负责关闭
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，不存在时不报错
+	Delete(ctx context.Context, key string) error
+	// URL 对象的访问地址
+	URL(key string) string
+}
+
+// New 根据上传配置创建存储后端，类型为空时使用本地存储
+func New(cfg config.UploadConfig) (Backend, error) {
+	switch cfg.Storage {
+	case "", TypeLocal:
+		return NewLocal(cfg.Path)
+	case TypeS3:
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown upload storage: %s", cfg.Storage)
+	}
+}
+
+// CleanKey 校验并规范化对象key
+func CleanKey(key string) (string, error) {
+	key = strings.TrimSpace(strings.ReplaceAll(key, "\\", "/"))
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,92 @@
+package uaparser
+
+import (
+	"strings"
+
+	"github.com/mssola/useragent"
+)
+
+// 设备类型
+const (
+	DeviceDesktop = "desktop" // 桌面
+	DeviceMobile  = "mobile"  // 手机
+	DeviceTablet  = "tablet"  // 平板
+	DeviceBot     = "bot"     // 爬虫
+)
+
+// Client 从User-Agent解析出的客户端信息，无法识别的字段为空
+type Client struct {
+	Browser        string // 浏览器（爬虫时为爬虫名称）
+	BrowserVersion string // 浏览器主版本号
+	OS             string // 操作系统（Windows、macOS、iOS、Android、Linux等）
+	OSVersion      string // 操作系统版本
+	DeviceType     string // 设备类型（desktop、mobile、tablet、bot）
+}
+
+// Parse 解析User-Agent，ua为空时返回空的Client
+func Parse(ua string) Client {
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return Client{}
+	}
+
+	parsed := useragent.New(ua)
+	browser, version := parsed.Browser()
+	osInfo := parsed.OSInfo()
+
+	client := Client{
+		Browser:        browser,
+		BrowserVersion: majorVersion(version),
+		OS:             osFamily(osInfo.Name, parsed.Platform()),
+		OSVersion:      osInfo.Version,
+		DeviceType:     deviceType(parsed, ua),
+	}
+	// 无法识别时不保留版本号
+	if client.OS == "" {
+		client.OSVersion = ""
+	}
+	return client
+}
+
+// majorVersion 只保留主版本号（如 120.0.6099.109 -> 120），避免分布统计过于分散
+func majorVersion(version string) string {
+	if i := strings.IndexByte(version, '.'); i >= 0 {
+		return version[:i]
+	}
+	return version
+}
+
+// osFamily 将操作系统名称归并为常见的系列
+func osFamily(name, platform string) string {
+	switch {
+	case name == "":
+		return ""
+	case strings.HasPrefix(name, "Windows"):
+		return "Windows"
+	case strings.HasPrefix(name, "Mac OS"):
+		return "macOS"
+	case strings.HasPrefix(name, "iPhone OS"), platform == "iPhone", platform == "iPad", platform == "iPod":
+		return "iOS"
+	case strings.HasPrefix(name, "Android"):
+		return "Android"
+	case strings.HasPrefix(name, "CrOS"):
+		return "Chrome OS"
+	case strings.Contains(name, "Linux"), strings.HasPrefix(name, "Ubuntu"), strings.HasPrefix(name, "Fedora"):
+		return "Linux"
+	}
+	return name
+}
+
+// deviceType 判断设备类型：平板（iPad、不带Mobile的Android）优先于手机
+func deviceType(parsed *useragent.UserAgent, ua string) string {
+	switch {
+	case parsed.Bot():
+		return DeviceBot
+	case strings.Contains(ua, "iPad"), strings.Contains(ua, "Tablet"),
+		strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile"):
+		return DeviceTablet
+	case parsed.Mobile():
+		return DeviceMobile
+	}
+	return DeviceDesktop
+}
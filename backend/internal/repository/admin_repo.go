@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/whk-newbie/blog/internal/models"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -24,12 +25,26 @@ type AdminRepository interface {
 	Update(admin *models.Admin) error
 	// 更新密码
 	UpdatePassword(id uint, password string) error
+	// 重置密码（标记为默认密码，登录后需要修改）
+	ResetPassword(id uint, password string) error
 	// 更新最后登录时间
 	UpdateLastLogin(id uint) error
 	// 标记密码已修改
 	MarkPasswordChanged(id uint) error
 	// 检查管理员是否存在
 	Exists(username string) (bool, error)
+	// 获取管理员列表
+	List(offset, limit int) ([]*models.Admin, int64, error)
+	// 删除管理员
+	Delete(id uint) error
+	// 统计管理员数量
+	Count() (int64, error)
+	// 统计指定角色的管理员数量
+	CountByRole(role models.AdminRole) (int64, error)
+	// 更新两步验证设置
+	UpdateTwoFactor(id uint, enabled bool, secret string, recoveryCodes datatypes.JSON) error
+	// 消耗一个恢复码（原子操作，恢复码不存在时返回false）
+	ConsumeRecoveryCode(id uint, codeHash string) (bool, error)
 }
 
 // adminRepository 管理员仓库实现
@@ -97,6 +112,16 @@ func (r *adminRepository) UpdatePassword(id uint, password string) error {
 		}).Error
 }
 
+// ResetPassword 重置密码
+func (r *adminRepository) ResetPassword(id uint, password string) error {
+	return r.db.Model(&models.Admin{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password":            password,
+			"is_default_password": true,
+		}).Error
+}
+
 // UpdateLastLogin 更新最后登录时间
 func (r *adminRepository) UpdateLastLogin(id uint) error {
 	return r.db.Model(&models.Admin{}).
@@ -118,3 +143,67 @@ func (r *adminRepository) Exists(username string) (bool, error) {
 	return count > 0, err
 }
 
+// List 获取管理员列表
+func (r *adminRepository) List(offset, limit int) ([]*models.Admin, int64, error) {
+	var admins []*models.Admin
+	var total int64
+
+	query := r.db.Model(&models.Admin{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	err := query.Order("id ASC").Find(&admins).Error
+	return admins, total, err
+}
+
+// Delete 删除管理员（软删除）
+func (r *adminRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Admin{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAdminNotFound
+	}
+	return nil
+}
+
+// Count 统计管理员数量
+func (r *adminRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Admin{}).Count(&count).Error
+	return count, err
+}
+
+// CountByRole 统计指定角色的管理员数量
+func (r *adminRepository) CountByRole(role models.AdminRole) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Admin{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+// UpdateTwoFactor 更新两步验证设置
+func (r *adminRepository) UpdateTwoFactor(id uint, enabled bool, secret string, recoveryCodes datatypes.JSON) error {
+	return r.db.Model(&models.Admin{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"two_factor_enabled":        enabled,
+			"two_factor_secret":         secret,
+			"two_factor_recovery_codes": recoveryCodes,
+		}).Error
+}
+
+// ConsumeRecoveryCode 从恢复码列表中移除指定哈希，并发使用同一恢复码时只有一次成功
+func (r *adminRepository) ConsumeRecoveryCode(id uint, codeHash string) (bool, error) {
+	result := r.db.Model(&models.Admin{}).
+		Where("id = ? AND jsonb_exists(two_factor_recovery_codes, ?)", id, codeHash).
+		Update("two_factor_recovery_codes", gorm.Expr("two_factor_recovery_codes - ?::text", codeHash))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/search"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -15,12 +17,13 @@ var (
 
 // ArticleFilter 文章筛选条件
 type ArticleFilter struct {
-	CategoryID *uint
-	TagID      *uint
-	Status     *models.ArticleStatus
-	IsTop      *bool
-	IsFeatured *bool
-	Keyword    string // 搜索关键词
+	CategoryID  *uint
+	TagID       *uint
+	Status      *models.ArticleStatus
+	IsTop       *bool
+	IsFeatured  *bool
+	Keyword     string // 搜索关键词（仅匹配标题，无法生成检索表达式时使用）
+	SearchQuery string // 全文检索表达式（to_tsquery语法，由分词器生成）
 }
 
 // ArticleRepository 文章仓库接口
@@ -43,18 +46,34 @@ type ArticleRepository interface {
 	List(filter *ArticleFilter, offset, limit int) ([]models.Article, int64, error)
 	// 检查Slug是否存在
 	ExistsBySlug(slug string, excludeID uint) (bool, error)
+	// 检查来源URL是否已导入过（包括已删除的文章）
+	ExistsBySourceURL(sourceURL string) (bool, error)
 	// 增加浏览量
 	IncrementViewCount(id uint) error
 	// 更新文章标签关联
 	UpdateTags(articleID uint, tagIDs []uint) error
+	// 在同一事务中保存文章（ID为0时创建）和修订快照，replaceTags为true时同时替换标签关联
+	SaveWithRevision(article *models.Article, tagIDs []uint, replaceTags bool, revision *models.ArticleRevision) error
 	// 获取已发布文章列表（公开访问）
 	ListPublished(filter *ArticleFilter, offset, limit int) ([]models.Article, int64, error)
-	// 全文搜索
-	Search(keyword string, offset, limit int) ([]models.Article, int64, error)
+	// 全文搜索（按相关度排序，并填充SearchRank和Highlight）
+	Search(tsQuery string, offset, limit int) ([]models.Article, int64, error)
+	// 分批获取需要重建检索分词的文章（onlyMissing为true时只返回未分词的文章）
+	ListForSearchIndex(afterID uint, limit int, onlyMissing bool) ([]models.Article, error)
+	// 更新检索分词列（不修改文章的更新时间）
+	UpdateSearchText(id uint, title, summary, content string) error
+	// 分批获取尚未生成目录的文章（正文格式与目录上线之前保存的文章，包括已删除的文章）
+	ListUnrendered(afterID uint, limit int) ([]models.Article, error)
+	// 更新重新渲染后的正文、目录和正文分词列
+	UpdateRenderedContent(id uint, content string, toc datatypes.JSON, searchContent string) error
+	// 分批获取文章正文和封面（用于扫描媒体引用，包括已删除的文章）
+	ListForMediaScan(afterID uint, limit int) ([]models.Article, error)
 	// 获取需要发布的文章（定时发布）
 	GetPendingPublish() ([]models.Article, error)
 	// 获取按日期统计的文章发布数量
 	GetPublishStatsByDate(startDate, endDate time.Time) ([]PublishStat, error)
+	// 获取全部已发布文章的Slug和更新时间（用于生成Sitemap）
+	ListPublishedSlugs() ([]models.Article, error)
 }
 
 // PublishStat 文章发布统计
@@ -194,6 +213,10 @@ func (r *articleRepository) List(filter *ArticleFilter, offset, limit int) ([]mo
 		return nil, 0, err
 	}
 
+	if err := r.fillCommentCounts(articles); err != nil {
+		return nil, 0, err
+	}
+
 	return articles, total, nil
 }
 
@@ -227,6 +250,10 @@ func (r *articleRepository) ListPublished(filter *ArticleFilter, offset, limit i
 		return nil, 0, err
 	}
 
+	if err := r.fillCommentCounts(articles); err != nil {
+		return nil, 0, err
+	}
+
 	return articles, total, nil
 }
 
@@ -257,35 +284,73 @@ func (r *articleRepository) applyFilter(query *gorm.DB, filter *ArticleFilter) *
 		query = query.Where("is_featured = ?", *filter.IsFeatured)
 	}
 
-	if filter.Keyword != "" {
-		keyword := "%" + filter.Keyword + "%"
-		query = query.Where("title LIKE ? OR summary LIKE ? OR content LIKE ?",
-			keyword, keyword, keyword)
+	if filter.SearchQuery != "" {
+		query = query.Where("search_vector @@ to_tsquery('simple', ?)", filter.SearchQuery)
+	} else if filter.Keyword != "" {
+		query = query.Where("title ILIKE ?", "%"+filter.Keyword+"%")
 	}
 
 	return query
 }
 
+// fillCommentCounts 填充文章列表的评论数（仅统计已通过的评论）
+func (r *articleRepository) fillCommentCounts(articles []models.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(articles))
+	for i := range articles {
+		ids[i] = articles[i].ID
+	}
+
+	var rows []struct {
+		ArticleID uint
+		Count     int64
+	}
+	err := r.db.Model(&models.Message{}).
+		Select("article_id, COUNT(*) AS count").
+		Where("article_id IN ? AND status = ?", ids, models.MessageStatusApproved).
+		Group("article_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ArticleID] = row.Count
+	}
+	for i := range articles {
+		articles[i].CommentCount = counts[articles[i].ID]
+	}
+	return nil
+}
+
 // Search 全文搜索
-func (r *articleRepository) Search(keyword string, offset, limit int) ([]models.Article, int64, error) {
+func (r *articleRepository) Search(tsQuery string, offset, limit int) ([]models.Article, int64, error) {
 	var articles []models.Article
 	var total int64
 
-	// 使用PostgreSQL全文搜索
+	// 使用存储的search_vector（由触发器维护）进行全文搜索
 	query := r.db.Model(&models.Article{}).
 		Where("status = ?", models.ArticleStatusPublished).
 		Where("publish_at IS NULL OR publish_at <= ?", time.Now()).
-		Where("to_tsvector('simple', title || ' ' || summary || ' ' || content) @@ plainto_tsquery('simple', ?)", keyword)
+		Where("search_vector @@ to_tsquery('simple', ?)", tsQuery)
 
 	// 获取总数
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// 获取文章列表
-	query = query.Preload("Category").
+	// 获取文章列表（按相关度排序，摘要片段取自分词后的正文）
+	query = query.Select("articles.*, "+
+		"ts_rank(search_vector, to_tsquery('simple', ?)) AS search_rank, "+
+		"ts_headline('simple', COALESCE(search_content, ''), to_tsquery('simple', ?), ?) AS highlight",
+		tsQuery, tsQuery, search.HeadlineOptions).
+		Preload("Category").
 		Preload("Tags").
-		Order("is_top DESC, created_at DESC")
+		Order("search_rank DESC, publish_at DESC")
 
 	if limit > 0 {
 		query = query.Limit(limit).Offset(offset)
@@ -295,6 +360,10 @@ func (r *articleRepository) Search(keyword string, offset, limit int) ([]models.
 		return nil, 0, err
 	}
 
+	if err := r.fillCommentCounts(articles); err != nil {
+		return nil, 0, err
+	}
+
 	return articles, total, nil
 }
 
@@ -309,6 +378,14 @@ func (r *articleRepository) ExistsBySlug(slug string, excludeID uint) (bool, err
 	return count > 0, err
 }
 
+// ExistsBySourceURL 检查来源URL是否已导入过
+// 已软删除的文章也算在内，避免管理员删除后又被重新导入
+func (r *articleRepository) ExistsBySourceURL(sourceURL string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Article{}).Where("source_url = ?", sourceURL).Count(&count).Error
+	return count > 0, err
+}
+
 // IncrementViewCount 增加浏览量
 func (r *articleRepository) IncrementViewCount(id uint) error {
 	return r.db.Model(&models.Article{}).
@@ -320,29 +397,130 @@ func (r *articleRepository) IncrementViewCount(id uint) error {
 func (r *articleRepository) UpdateTags(articleID uint, tagIDs []uint) error {
 	// 开启事务
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// 删除旧的标签关联
-		if err := tx.Where("article_id = ?", articleID).Delete(&models.ArticleTag{}).Error; err != nil {
+		return replaceArticleTags(tx, articleID, tagIDs)
+	})
+}
+
+// SaveWithRevision 在同一事务中保存文章和修订快照
+// 快照写入失败时文章的修改一并回滚，避免文章已变化却缺少对应的修订
+func (r *articleRepository) SaveWithRevision(article *models.Article, tagIDs []uint, replaceTags bool, revision *models.ArticleRevision) error {
+	// 检查Slug是否被其他文章使用
+	exists, err := r.ExistsBySlug(article.Slug, article.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrArticleExists
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if article.ID == 0 {
+			if err := tx.Create(article).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Save(article).Error; err != nil {
 			return err
 		}
 
-		// 创建新的标签关联
-		if len(tagIDs) > 0 {
-			var articleTags []models.ArticleTag
-			for _, tagID := range tagIDs {
-				articleTags = append(articleTags, models.ArticleTag{
-					ArticleID: articleID,
-					TagID:     tagID,
-				})
-			}
-			if err := tx.Create(&articleTags).Error; err != nil {
+		if replaceTags {
+			if err := replaceArticleTags(tx, article.ID, tagIDs); err != nil {
 				return err
 			}
 		}
 
-		return nil
+		revision.ArticleID = article.ID
+		return createRevision(tx, revision)
 	})
 }
 
+// replaceArticleTags 替换文章标签关联
+func replaceArticleTags(tx *gorm.DB, articleID uint, tagIDs []uint) error {
+	// 删除旧的标签关联
+	if err := tx.Where("article_id = ?", articleID).Delete(&models.ArticleTag{}).Error; err != nil {
+		return err
+	}
+
+	// 创建新的标签关联
+	if len(tagIDs) > 0 {
+		var articleTags []models.ArticleTag
+		for _, tagID := range tagIDs {
+			articleTags = append(articleTags, models.ArticleTag{
+				ArticleID: articleID,
+				TagID:     tagID,
+			})
+		}
+		if err := tx.Create(&articleTags).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListPublishedSlugs 获取全部已发布文章的Slug和更新时间（用于生成Sitemap）
+func (r *articleRepository) ListPublishedSlugs() ([]models.Article, error) {
+	var articles []models.Article
+	err := r.db.Select("id", "slug", "publish_at", "updated_at").
+		Where("status = ?", models.ArticleStatusPublished).
+		Where("publish_at IS NULL OR publish_at <= ?", time.Now()).
+		Order("publish_at DESC, id DESC").
+		Find(&articles).Error
+	return articles, err
+}
+
+// ListForSearchIndex 分批获取需要重建检索分词的文章
+func (r *articleRepository) ListForSearchIndex(afterID uint, limit int, onlyMissing bool) ([]models.Article, error) {
+	var articles []models.Article
+	query := r.db.Select("id", "title", "summary", "content").
+		Where("id > ?", afterID)
+	if onlyMissing {
+		query = query.Where("search_content IS NULL")
+	}
+	err := query.Order("id ASC").Limit(limit).Find(&articles).Error
+	return articles, err
+}
+
+// ListForMediaScan 分批获取文章正文和封面
+// 已删除的文章可以恢复，引用的文件同样不能清理
+func (r *articleRepository) ListForMediaScan(afterID uint, limit int) ([]models.Article, error) {
+	var articles []models.Article
+	err := r.db.Unscoped().Select("id", "content", "cover_image").
+		Where("id > ?", afterID).
+		Order("id ASC").Limit(limit).Find(&articles).Error
+	return articles, err
+}
+
+// UpdateSearchText 更新检索分词列（updated_at触发器会忽略只修改分词列的更新）
+func (r *articleRepository) UpdateSearchText(id uint, title, summary, content string) error {
+	return r.db.Model(&models.Article{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"search_title":   title,
+			"search_summary": summary,
+			"search_content": content,
+		}).Error
+}
+
+// ListUnrendered 分批获取尚未生成目录的文章（toc为NULL，新保存的文章至少为JSON null）
+func (r *articleRepository) ListUnrendered(afterID uint, limit int) ([]models.Article, error) {
+	var articles []models.Article
+	err := r.db.Unscoped().Select("id", "content", "content_format", "content_source").
+		Where("id > ? AND toc IS NULL", afterID).
+		Order("id ASC").Limit(limit).Find(&articles).Error
+	return articles, err
+}
+
+// UpdateRenderedContent 更新重新渲染后的正文、目录和正文分词列
+func (r *articleRepository) UpdateRenderedContent(id uint, content string, toc datatypes.JSON, searchContent string) error {
+	return r.db.Unscoped().Model(&models.Article{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"content":        content,
+			"toc":            toc,
+			"search_content": searchContent,
+		}).Error
+}
+
 // GetPendingPublish 获取需要发布的文章（定时发布）
 func (r *articleRepository) GetPendingPublish() ([]models.Article, error) {
 	var articles []models.Article
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("article revision not found")
+)
+
+// ArticleRevisionRepository 文章修订仓库接口
+type ArticleRevisionRepository interface {
+	// 创建修订（自动分配版本号）
+	Create(revision *models.ArticleRevision) error
+	// 根据ID查找修订
+	FindByID(id uint) (*models.ArticleRevision, error)
+	// 获取文章的修订列表（不包含正文）
+	ListByArticleID(articleID uint, offset, limit int) ([]models.ArticleRevision, int64, error)
+	// 分批获取修订的正文和封面（用于扫描媒体引用）
+	ListForMediaScan(afterID uint, limit int) ([]models.ArticleRevision, error)
+}
+
+// articleRevisionRepository 文章修订仓库实现
+type articleRevisionRepository struct {
+	db *gorm.DB
+}
+
+// NewArticleRevisionRepository 创建文章修订仓库
+func NewArticleRevisionRepository(db *gorm.DB) ArticleRevisionRepository {
+	return &articleRevisionRepository{db: db}
+}
+
+// Create 创建修订（自动分配版本号）
+func (r *articleRevisionRepository) Create(revision *models.ArticleRevision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return createRevision(tx, revision)
+	})
+}
+
+// createRevision 在事务中分配版本号并创建修订
+func createRevision(tx *gorm.DB, revision *models.ArticleRevision) error {
+	// 锁定文章行，保证同一文章的版本号串行分配
+	if err := tx.Exec("SELECT id FROM articles WHERE id = ? FOR UPDATE", revision.ArticleID).Error; err != nil {
+		return err
+	}
+
+	var maxVersion int
+	if err := tx.Model(&models.ArticleRevision{}).
+		Where("article_id = ?", revision.ArticleID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&maxVersion).Error; err != nil {
+		return err
+	}
+
+	revision.Version = maxVersion + 1
+	return tx.Create(revision).Error
+}
+
+// FindByID 根据ID查找修订
+func (r *articleRevisionRepository) FindByID(id uint) (*models.ArticleRevision, error) {
+	var revision models.ArticleRevision
+	err := r.db.First(&revision, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// ListByArticleID 获取文章的修订列表（不包含正文）
+func (r *articleRevisionRepository) ListByArticleID(articleID uint, offset, limit int) ([]models.ArticleRevision, int64, error) {
+	var revisions []models.ArticleRevision
+	var total int64
+
+	query := r.db.Model(&models.ArticleRevision{}).Where("article_id = ?", articleID)
+
+	// 获取总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 列表不返回正文，避免响应过大
+	query = query.Omit("content", "content_source").Order("version DESC")
+
+	if limit > 0 {
+		query = query.Limit(limit).Offset(offset)
+	}
+
+	if err := query.Find(&revisions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return revisions, total, nil
+}
+
+// ListForMediaScan 分批获取修订的正文和封面
+func (r *articleRevisionRepository) ListForMediaScan(afterID uint, limit int) ([]models.ArticleRevision, error) {
+	var revisions []models.ArticleRevision
+	err := r.db.Select("id", "article_id", "content", "cover_image").
+		Where("id > ?", afterID).
+		Order("id ASC").Limit(limit).Find(&revisions).Error
+	return revisions, err
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"gorm.io/gorm"
+)
+
+// 需要解析User-Agent的表
+const (
+	ClientInfoTableVisits       = "visits"
+	ClientInfoTableFingerprints = "fingerprints"
+)
+
+var ErrInvalidClientDimension = errors.New("invalid client dimension")
+
+// clientDimensions 客户端分布的统计维度 -> 分组表达式（为空表示无法识别或尚未解析）
+var clientDimensions = map[string]string{
+	"browser":         "COALESCE(browser, '')",
+	"browser_version": "CONCAT_WS(' ', NULLIF(browser, ''), NULLIF(browser_version, ''))",
+	"os":              "COALESCE(os, '')",
+	"os_version":      "CONCAT_WS(' ', NULLIF(os, ''), NULLIF(os_version, ''))",
+	"device_type":     "COALESCE(device_type, '')",
+}
+
+// ClientInfoRepository 客户端信息仓库接口
+type ClientInfoRepository interface {
+	// 按ID顺序获取尚未解析User-Agent的记录（ID大于afterID）
+	ListUnparsed(table string, afterID uint, limit int) ([]UnparsedUserAgent, error)
+	// 批量写入解析结果
+	UpdateClientInfo(table string, rows []ParsedUserAgent) error
+	// 获取区间内访问记录按某个维度的分布（按访问数降序）
+	GetBreakdown(dimension string, startDate, endDate time.Time, limit int) ([]ClientStat, error)
+}
+
+// UnparsedUserAgent 尚未解析User-Agent的记录
+type UnparsedUserAgent struct {
+	ID        uint
+	UserAgent string
+}
+
+// ParsedUserAgent User-Agent解析结果
+type ParsedUserAgent struct {
+	ID         uint
+	ClientInfo models.ClientInfo
+}
+
+// ClientStat 客户端分布统计
+type ClientStat struct {
+	Name     string  `json:"name"`     // 维度取值，为空表示无法识别
+	Visits   int64   `json:"visits"`   // 访问数
+	Visitors int64   `json:"visitors"` // 访客数（去重指纹）
+	Share    float64 `json:"share"`    // 访问数占比（0-1）
+}
+
+// clientInfoRepository 客户端信息仓库实现
+type clientInfoRepository struct {
+	db *gorm.DB
+}
+
+// NewClientInfoRepository 创建客户端信息仓库
+func NewClientInfoRepository(db *gorm.DB) ClientInfoRepository {
+	return &clientInfoRepository{db: db}
+}
+
+// ListUnparsed 获取尚未解析User-Agent的记录
+func (r *clientInfoRepository) ListUnparsed(table string, afterID uint, limit int) ([]UnparsedUserAgent, error) {
+	if err := checkClientInfoTable(table); err != nil {
+		return nil, err
+	}
+
+	var rows []UnparsedUserAgent
+	err := r.db.Table(table).
+		Select("id, COALESCE(user_agent, '') AS user_agent").
+		Where("device_type IS NULL AND id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// UpdateClientInfo 批量写入解析结果（一条UPDATE ... FROM (VALUES ...)）
+func (r *clientInfoRepository) UpdateClientInfo(table string, rows []ParsedUserAgent) error {
+	if err := checkClientInfoTable(table); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(rows))
+	args := make([]interface{}, 0, len(rows)*6)
+	for _, row := range rows {
+		values = append(values, "(?::bigint, ?, ?, ?, ?, ?)")
+		info := row.ClientInfo
+		args = append(args, row.ID, info.Browser, info.BrowserVersion, info.OS, info.OSVersion, info.DeviceType)
+	}
+
+	sql := fmt.Sprintf(`
+		UPDATE %s AS t SET
+			browser = v.browser,
+			browser_version = v.browser_version,
+			os = v.os,
+			os_version = v.os_version,
+			device_type = v.device_type
+		FROM (VALUES %s) AS v(id, browser, browser_version, os, os_version, device_type)
+		WHERE t.id = v.id
+	`, table, strings.Join(values, ", "))
+
+	return r.db.Exec(sql, args...).Error
+}
+
+// GetBreakdown 获取区间内访问记录按某个维度的分布
+func (r *clientInfoRepository) GetBreakdown(dimension string, startDate, endDate time.Time, limit int) ([]ClientStat, error) {
+	expr, ok := clientDimensions[dimension]
+	if !ok {
+		return nil, ErrInvalidClientDimension
+	}
+
+	var stats []ClientStat
+	// 占比的窗口函数在LIMIT之前计算，分母是区间内的全部访问
+	err := r.db.Raw(`
+		SELECT `+expr+` AS name,
+			COUNT(*) AS visits,
+			COUNT(DISTINCT fingerprint_id) AS visitors,
+			COUNT(*)::float8 / SUM(COUNT(*)) OVER () AS share
+		FROM visits
+		WHERE visit_time >= ? AND visit_time < ?
+		GROUP BY 1
+		ORDER BY visits DESC, name ASC
+		LIMIT ?
+	`, startDate, endDate, limit).Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// checkClientInfoTable 表名会拼接进SQL，只允许固定的两张表
+func checkClientInfoTable(table string) error {
+	if table != ClientInfoTableVisits && table != ClientInfoTableFingerprints {
+		return fmt.Errorf("unsupported client info table: %s", table)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+// CommentFilter 评论筛选条件
+type CommentFilter struct {
+	ArticleID     *uint
+	Status        *models.MessageStatus
+	FingerprintID *uint
+	Keyword       string // 搜索关键词（内容/昵称/邮箱）
+}
+
+// CommentRepository 评论仓库接口
+type CommentRepository interface {
+	// 创建评论
+	Create(comment *models.Message) error
+	// 根据ID查找评论
+	FindByID(id uint) (*models.Message, error)
+	// 分页获取文章下指定状态的顶级评论（按时间倒序），返回顶级评论总数
+	ListRoots(articleID uint, status models.MessageStatus, offset, limit int) ([]models.Message, int64, error)
+	// 获取指定评论下指定状态的直接回复（按时间正序）
+	ListReplies(parentIDs []uint, status models.MessageStatus) ([]models.Message, error)
+	// 统计文章下可见的评论数（指定状态，且上级评论均可见）
+	CountVisible(articleID uint, status models.MessageStatus) (int64, error)
+	// 获取评论列表（管理员，带筛选）
+	List(filter *CommentFilter, offset, limit int) ([]models.Message, int64, error)
+	// 更新评论状态
+	UpdateStatus(ids []uint, status models.MessageStatus) (int64, error)
+	// 删除评论（回复级联删除）
+	Delete(id uint) error
+}
+
+// commentRepository 评论仓库实现
+type commentRepository struct {
+	db *gorm.DB
+}
+
+// NewCommentRepository 创建评论仓库
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+// Create 创建评论
+func (r *commentRepository) Create(comment *models.Message) error {
+	return r.db.Create(comment).Error
+}
+
+// FindByID 根据ID查找评论
+func (r *commentRepository) FindByID(id uint) (*models.Message, error) {
+	var comment models.Message
+	err := r.db.First(&comment, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListRoots 分页获取文章下指定状态的顶级评论
+func (r *commentRepository) ListRoots(articleID uint, status models.MessageStatus, offset, limit int) ([]models.Message, int64, error) {
+	var comments []models.Message
+	var total int64
+
+	query := r.db.Model(&models.Message{}).
+		Where("article_id = ? AND status = ? AND parent_id IS NULL", articleID, status)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&comments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+// ListReplies 获取指定评论下指定状态的直接回复
+func (r *commentRepository) ListReplies(parentIDs []uint, status models.MessageStatus) ([]models.Message, error) {
+	var comments []models.Message
+	if len(parentIDs) == 0 {
+		return comments, nil
+	}
+	err := r.db.Where("parent_id IN ? AND status = ?", parentIDs, status).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error
+	return comments, err
+}
+
+// CountVisible 统计文章下可见的评论数（上级评论不可见时，其回复不计入）
+func (r *commentRepository) CountVisible(articleID uint, status models.MessageStatus) (int64, error) {
+	var count int64
+	err := r.db.Raw(`
+		WITH RECURSIVE visible AS (
+			SELECT id FROM messages
+			WHERE article_id = ? AND status = ? AND parent_id IS NULL
+			UNION ALL
+			SELECT m.id FROM messages m
+			JOIN visible v ON m.parent_id = v.id
+			WHERE m.status = ?
+		)
+		SELECT COUNT(*) FROM visible
+	`, articleID, status, status).Scan(&count).Error
+	return count, err
+}
+
+// List 获取评论列表（管理员，带筛选）
+func (r *commentRepository) List(filter *CommentFilter, offset, limit int) ([]models.Message, int64, error) {
+	var comments []models.Message
+	var total int64
+
+	query := r.db.Model(&models.Message{}).Where("article_id IS NOT NULL")
+
+	if filter != nil {
+		if filter.ArticleID != nil {
+			query = query.Where("article_id = ?", *filter.ArticleID)
+		}
+		if filter.Status != nil {
+			query = query.Where("status = ?", *filter.Status)
+		}
+		if filter.FingerprintID != nil {
+			query = query.Where("fingerprint_id = ?", *filter.FingerprintID)
+		}
+		if filter.Keyword != "" {
+			keyword := "%" + filter.Keyword + "%"
+			query = query.Where("content LIKE ? OR name LIKE ? OR email LIKE ?", keyword, keyword, keyword)
+		}
+	}
+
+	// 获取总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 获取评论列表（附带文章标题便于审核）
+	query = query.Preload("Article", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Select("id", "title", "slug")
+	}).Order("created_at DESC")
+
+	if limit > 0 {
+		query = query.Limit(limit).Offset(offset)
+	}
+
+	if err := query.Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return comments, total, nil
+}
+
+// UpdateStatus 更新评论状态
+func (r *commentRepository) UpdateStatus(ids []uint, status models.MessageStatus) (int64, error) {
+	result := r.db.Model(&models.Message{}).
+		Where("id IN ?", ids).
+		Update("status", status)
+	return result.RowsAffected, result.Error
+}
+
+// Delete 删除评论（回复级联删除）
+func (r *commentRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Message{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCrawlJobNotFound = errors.New("crawl job not found")
+	ErrCrawlJobExists   = errors.New("crawl job already exists")
+)
+
+// CrawlJobRepository 爬虫作业仓库接口
+type CrawlJobRepository interface {
+	// 创建作业
+	Create(job *models.CrawlJob) error
+	// 根据ID查找作业
+	FindByID(id uint) (*models.CrawlJob, error)
+	// 更新作业
+	Update(job *models.CrawlJob) error
+	// 删除作业（已生成的任务保留，job_id置空）
+	Delete(id uint) error
+	// 获取作业列表
+	List(offset, limit int) ([]models.CrawlJob, int64, error)
+	// 获取全部启用的作业
+	ListEnabled() ([]models.CrawlJob, error)
+	// 检查作业名称是否存在
+	ExistsByName(name string, excludeID uint) (bool, error)
+	// 记录最近一次运行时间
+	UpdateLastRun(id uint, runAt time.Time) error
+}
+
+// crawlJobRepository 爬虫作业仓库实现
+type crawlJobRepository struct {
+	db *gorm.DB
+}
+
+// NewCrawlJobRepository 创建爬虫作业仓库
+func NewCrawlJobRepository(db *gorm.DB) CrawlJobRepository {
+	return &crawlJobRepository{db: db}
+}
+
+// Create 创建作业
+func (r *crawlJobRepository) Create(job *models.CrawlJob) error {
+	exists, err := r.ExistsByName(job.Name, 0)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrCrawlJobExists
+	}
+
+	return r.db.Create(job).Error
+}
+
+// FindByID 根据ID查找作业
+func (r *crawlJobRepository) FindByID(id uint) (*models.CrawlJob, error) {
+	var job models.CrawlJob
+	err := r.db.First(&job, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCrawlJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Update 更新作业
+func (r *crawlJobRepository) Update(job *models.CrawlJob) error {
+	exists, err := r.ExistsByName(job.Name, job.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrCrawlJobExists
+	}
+
+	// 运行时间由调度器单独更新，这里不覆盖
+	return r.db.Omit("last_run_at").Save(job).Error
+}
+
+// Delete 删除作业
+func (r *crawlJobRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.CrawlJob{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCrawlJobNotFound
+	}
+	return nil
+}
+
+// List 获取作业列表
+func (r *crawlJobRepository) List(offset, limit int) ([]models.CrawlJob, int64, error) {
+	var jobs []models.CrawlJob
+	var total int64
+
+	query := r.db.Model(&models.CrawlJob{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Order("created_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit).Offset(offset)
+	}
+
+	if err := query.Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, total, nil
+}
+
+// ListEnabled 获取全部启用的作业
+func (r *crawlJobRepository) ListEnabled() ([]models.CrawlJob, error) {
+	var jobs []models.CrawlJob
+	err := r.db.Where("enabled = ?", true).Order("id ASC").Find(&jobs).Error
+	return jobs, err
+}
+
+// ExistsByName 检查作业名称是否存在
+func (r *crawlJobRepository) ExistsByName(name string, excludeID uint) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.CrawlJob{}).Where("name = ?", name)
+	if excludeID > 0 {
+		query = query.Where("id != ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// UpdateLastRun 记录最近一次运行时间
+func (r *crawlJobRepository) UpdateLastRun(id uint, runAt time.Time) error {
+	return r.db.Model(&models.CrawlJob{}).Where("id = ?", id).UpdateColumn("last_run_at", runAt).Error
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"gorm.io/gorm"
+)
+
+// CrawlResultFilter 采集结果筛选条件
+type CrawlResultFilter struct {
+	TaskID    uint   // 任务ID（crawl_tasks.id）
+	Keyword   string // 在结果数据中模糊搜索
+	Field     string // 按顶层字段精确筛选：data->>Field = Value
+	Value     string
+	StartTime *time.Time // 提交时间范围
+	EndTime   *time.Time
+}
+
+// CrawlResultRepository 采集结果仓库接口
+type CrawlResultRepository interface {
+	// 批量写入结果，并在同一事务中累加任务的结果数、更新心跳（leaseExpiresAt不为nil时一并续约）
+	CreateBatch(taskID uint, results []models.CrawlResult, leaseExpiresAt *time.Time) error
+	// 获取结果列表（带筛选）
+	List(filter *CrawlResultFilter, offset, limit int) ([]models.CrawlResult, int64, error)
+	// 按ID升序获取afterID之后的结果（用于分批导出）
+	ListAfter(filter *CrawlResultFilter, afterID uint, limit int) ([]models.CrawlResult, error)
+	// 获取结果数据中出现过的顶层字段名（按名称排序）
+	ListFields(filter *CrawlResultFilter) ([]string, error)
+}
+
+// crawlResultRepository 采集结果仓库实现
+type crawlResultRepository struct {
+	db *gorm.DB
+}
+
+// NewCrawlResultRepository 创建采集结果仓库
+func NewCrawlResultRepository(db *gorm.DB) CrawlResultRepository {
+	return &crawlResultRepository{db: db}
+}
+
+// CreateBatch 批量写入结果
+func (r *crawlResultRepository) CreateBatch(taskID uint, results []models.CrawlResult, leaseExpiresAt *time.Time) error {
+	if len(results) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(results, 500).Error; err != nil {
+			return err
+		}
+		// 提交结果同时视为心跳
+		updates := map[string]interface{}{
+			"result_count": gorm.Expr("result_count + ?", len(results)),
+			"heartbeat_at": time.Now(),
+		}
+		if leaseExpiresAt != nil {
+			updates["lease_expires_at"] = *leaseExpiresAt
+		}
+		return tx.Model(&models.CrawlTask{}).Where("id = ?", taskID).UpdateColumns(updates).Error
+	})
+}
+
+// List 获取结果列表（带筛选）
+func (r *crawlResultRepository) List(filter *CrawlResultFilter, offset, limit int) ([]models.CrawlResult, int64, error) {
+	var items []models.CrawlResult
+	var total int64
+
+	query := r.applyFilter(r.db.Model(&models.CrawlResult{}), filter)
+
+	// 获取总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Order("id DESC")
+	if limit > 0 {
+		query = query.Limit(limit).Offset(offset)
+	}
+
+	if err := query.Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// ListAfter 按ID升序获取afterID之后的结果
+func (r *crawlResultRepository) ListAfter(filter *CrawlResultFilter, afterID uint, limit int) ([]models.CrawlResult, error) {
+	var items []models.CrawlResult
+	err := r.applyFilter(r.db.Model(&models.CrawlResult{}), filter).
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// ListFields 获取结果数据中出现过的顶层字段名
+func (r *crawlResultRepository) ListFields(filter *CrawlResultFilter) ([]string, error) {
+	var fields []string
+	err := r.applyFilter(r.db.Model(&models.CrawlResult{}), filter).
+		Where("jsonb_typeof(data) = 'object'").
+		Distinct().
+		Pluck("jsonb_object_keys(data)", &fields).Error
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// applyFilter 应用筛选条件
+func (r *crawlResultRepository) applyFilter(query *gorm.DB, filter *CrawlResultFilter) *gorm.DB {
+	if filter == nil {
+		return query
+	}
+	if filter.TaskID > 0 {
+		query = query.Where("task_id = ?", filter.TaskID)
+	}
+	if filter.Keyword != "" {
+		query = query.Where("data::text ILIKE ?", "%"+filter.Keyword+"%")
+	}
+	if filter.Field != "" {
+		query = query.Where("data->>? = ?", filter.Field, filter.Value)
+	}
+	if filter.StartTime != nil {
+		query = query.Where("created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		query = query.Where("created_at < ?", *filter.EndTime)
+	}
+	return query
+}
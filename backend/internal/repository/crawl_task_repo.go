@@ -2,9 +2,12 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/whk-newbie/blog/internal/models"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -15,6 +18,7 @@ var (
 // CrawlTaskFilter 爬虫任务筛选条件
 type CrawlTaskFilter struct {
 	Status         *models.CrawlTaskStatus
+	Statuses       []models.CrawlTaskStatus
 	TaskID         string
 	CreatedByToken string
 }
@@ -33,6 +37,26 @@ type CrawlTaskRepository interface {
 	List(filter *CrawlTaskFilter, offset, limit int) ([]models.CrawlTask, int64, error)
 	// 检查TaskID是否存在
 	ExistsByTaskID(taskID string) (bool, error)
+	// 更新导入映射规则
+	UpdateImportMapping(id uint, mapping datatypes.JSON) error
+	// 更新导入结果汇总
+	UpdateImportSummary(id uint, summary datatypes.JSON) error
+	// 作业是否有未结束（待执行、运行中或暂停等）的任务
+	HasActiveTaskForJob(jobID uint) (bool, error)
+	// 领取最早的待执行任务（jobIDs为空时不限作业），没有可领取的任务时返回ErrCrawlTaskNotFound
+	ClaimPending(jobIDs []uint, workerID, token string, now time.Time) (*models.CrawlTask, error)
+	// 续约：仅当任务仍在执行（含暂停等控制状态）且由该Token（及工作节点，workerID非空时）持有时更新，返回是否更新成功
+	RenewLease(id uint, token, workerID string, updates map[string]interface{}) (bool, error)
+	// 获取租约已过期的执行中任务
+	ListExpiredLeases(now time.Time) ([]models.CrawlTask, error)
+	// 释放过期租约：仅当任务仍在执行且租约已过期时更新，返回是否更新成功
+	ReleaseExpiredLease(id uint, now time.Time, updates map[string]interface{}, clearResults bool) (bool, error)
+	// 获取心跳超时的执行中任务（不含有租约的任务，任务未指定超时时使用defaultTimeout）
+	ListStale(defaultTimeout time.Duration, now time.Time) ([]models.CrawlTask, error)
+	// 标记心跳超时：仅当任务仍在执行且心跳仍已超时时更新，返回是否更新成功
+	MarkStale(id uint, defaultTimeout time.Duration, now time.Time, updates map[string]interface{}) (bool, error)
+	// 按状态条件更新：仅当任务仍处于fromStatuses之一时更新，返回是否更新成功
+	UpdateIfStatus(id uint, fromStatuses []models.CrawlTaskStatus, updates map[string]interface{}) (bool, error)
 }
 
 // crawlTaskRepository 爬虫任务仓库实现
@@ -86,8 +110,9 @@ func (r *crawlTaskRepository) FindByTaskID(taskID string) (*models.CrawlTask, er
 }
 
 // Update 更新任务
+// 结果数由结果写入时原子累加、导入汇总由后台导入写入，这里都不覆盖，避免并发写入时数据丢失
 func (r *crawlTaskRepository) Update(task *models.CrawlTask) error {
-	return r.db.Save(task).Error
+	return r.db.Omit("result_count", "import_summary").Save(task).Error
 }
 
 // List 获取任务列表（带筛选）
@@ -102,6 +127,9 @@ func (r *crawlTaskRepository) List(filter *CrawlTaskFilter, offset, limit int) (
 		if filter.Status != nil {
 			query = query.Where("status = ?", *filter.Status)
 		}
+		if len(filter.Statuses) > 0 {
+			query = query.Where("status IN ?", filter.Statuses)
+		}
 		if filter.TaskID != "" {
 			query = query.Where("task_id = ?", filter.TaskID)
 		}
@@ -135,3 +163,139 @@ func (r *crawlTaskRepository) ExistsByTaskID(taskID string) (bool, error) {
 	err := r.db.Model(&models.CrawlTask{}).Where("task_id = ?", taskID).Count(&count).Error
 	return count > 0, err
 }
+
+// UpdateImportMapping 更新导入映射规则
+func (r *crawlTaskRepository) UpdateImportMapping(id uint, mapping datatypes.JSON) error {
+	return r.db.Model(&models.CrawlTask{}).Where("id = ?", id).Update("import_mapping", mapping).Error
+}
+
+// UpdateImportSummary 更新导入结果汇总
+func (r *crawlTaskRepository) UpdateImportSummary(id uint, summary datatypes.JSON) error {
+	return r.db.Model(&models.CrawlTask{}).Where("id = ?", id).UpdateColumn("import_summary", summary).Error
+}
+
+// HasActiveTaskForJob 作业是否有未结束的任务
+func (r *crawlTaskRepository) HasActiveTaskForJob(jobID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.CrawlTask{}).
+		Where("job_id = ? AND (status = ? OR status IN ?)", jobID, models.CrawlTaskStatusPending, models.ActiveCrawlTaskStatuses).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ClaimPending 领取最早的待执行任务
+// 使用 FOR UPDATE SKIP LOCKED，多个工作节点同时领取时不会拿到同一个任务
+func (r *crawlTaskRepository) ClaimPending(jobIDs []uint, workerID, token string, now time.Time) (*models.CrawlTask, error) {
+	var task models.CrawlTask
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.CrawlTaskStatusPending)
+		if len(jobIDs) > 0 {
+			query = query.Where("job_id IN ?", jobIDs)
+		}
+		if err := query.Order("created_at ASC, id ASC").First(&task).Error; err != nil {
+			return err
+		}
+
+		leaseSeconds := models.DefaultCrawlLeaseSeconds
+		if task.LeaseSeconds != nil && *task.LeaseSeconds > 0 {
+			leaseSeconds = *task.LeaseSeconds
+		}
+		err := tx.Model(&models.CrawlTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+			"status":           models.CrawlTaskStatusRunning,
+			"progress":         0,
+			"message":          "任务已被领取",
+			"worker_id":        workerID,
+			"created_by_token": token,
+			"start_time":       now,
+			"heartbeat_at":     now,
+			"lease_expires_at": now.Add(time.Duration(leaseSeconds) * time.Second),
+			"attempts":         gorm.Expr("attempts + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.First(&task, task.ID).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCrawlTaskNotFound
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// RenewLease 续约
+func (r *crawlTaskRepository) RenewLease(id uint, token, workerID string, updates map[string]interface{}) (bool, error) {
+	query := r.db.Model(&models.CrawlTask{}).
+		Where("id = ? AND status IN ? AND created_by_token = ?", id, models.ActiveCrawlTaskStatuses, token)
+	if workerID != "" {
+		query = query.Where("worker_id = ?", workerID)
+	}
+	result := query.Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListExpiredLeases 获取租约已过期的执行中任务
+func (r *crawlTaskRepository) ListExpiredLeases(now time.Time) ([]models.CrawlTask, error) {
+	var tasks []models.CrawlTask
+	err := r.db.Where("status IN ? AND lease_expires_at < ?", models.ActiveCrawlTaskStatuses, now).
+		Order("lease_expires_at ASC").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// ReleaseExpiredLease 释放过期租约，clearResults为true时在同一事务中删除本次领取已提交的结果
+// 条件更新避免与刚到达的心跳竞争：工作节点在检查后续约成功时这里不会生效
+func (r *crawlTaskRepository) ReleaseExpiredLease(id uint, now time.Time, updates map[string]interface{}, clearResults bool) (bool, error) {
+	released := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.CrawlTask{}).
+			Where("id = ? AND status IN ? AND lease_expires_at < ?", id, models.ActiveCrawlTaskStatuses, now).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		released = true
+
+		if !clearResults {
+			return nil
+		}
+		return tx.Where("task_id = ?", id).Delete(&models.CrawlResult{}).Error
+	})
+	return released, err
+}
+
+// staleCondition 心跳超时条件：没有心跳时以最近更新时间为准
+const staleCondition = "status IN ? AND lease_expires_at IS NULL AND " +
+	"COALESCE(heartbeat_at, updated_at) + COALESCE(heartbeat_timeout, ?) * INTERVAL '1 second' < ?"
+
+// ListStale 获取心跳超时的执行中任务
+func (r *crawlTaskRepository) ListStale(defaultTimeout time.Duration, now time.Time) ([]models.CrawlTask, error) {
+	var tasks []models.CrawlTask
+	err := r.db.Where(staleCondition, models.ActiveCrawlTaskStatuses, int(defaultTimeout.Seconds()), now).
+		Order("id ASC").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// MarkStale 标记心跳超时
+// 条件更新避免与刚到达的心跳竞争
+func (r *crawlTaskRepository) MarkStale(id uint, defaultTimeout time.Duration, now time.Time, updates map[string]interface{}) (bool, error) {
+	result := r.db.Model(&models.CrawlTask{}).
+		Where("id = ?", id).
+		Where(staleCondition, models.ActiveCrawlTaskStatuses, int(defaultTimeout.Seconds()), now).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateIfStatus 按状态条件更新
+// 条件更新避免与爬虫同时上报的完成、失败等状态竞争
+func (r *crawlTaskRepository) UpdateIfStatus(id uint, fromStatuses []models.CrawlTaskStatus, updates map[string]interface{}) (bool, error) {
+	result := r.db.Model(&models.CrawlTask{}).
+		Where("id = ? AND status IN ?", id, fromStatuses).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
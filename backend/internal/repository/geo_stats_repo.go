@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidGeoLevel = errors.New("invalid geo level")
+
+// 地区分布的统计层级
+const (
+	GeoLevelCountry = "country"
+	GeoLevelRegion  = "region"
+	GeoLevelCity    = "city"
+)
+
+// geoLevelColumns 各层级的分组列（为空表示未配置数据库、IP无法识别或数据库中没有该层级）
+var geoLevelColumns = map[string]string{
+	GeoLevelCountry: "COALESCE(country_code, '') AS country_code, COALESCE(country, '') AS country, '' AS region, '' AS city",
+	GeoLevelRegion:  "COALESCE(country_code, '') AS country_code, COALESCE(country, '') AS country, COALESCE(region, '') AS region, '' AS city",
+	GeoLevelCity:    "COALESCE(country_code, '') AS country_code, COALESCE(country, '') AS country, COALESCE(region, '') AS region, COALESCE(city, '') AS city",
+}
+
+// GeoStatsRepository 访问地区统计仓库接口
+type GeoStatsRepository interface {
+	// 获取区间内访问记录按地区的分布（按访问数降序），countryCode不为空时只统计该国家/地区
+	GetBreakdown(level, countryCode string, startDate, endDate time.Time, limit int) ([]GeoStat, error)
+}
+
+// GeoStat 地区分布统计
+type GeoStat struct {
+	CountryCode string  `json:"country_code"`     // 国家/地区代码，为空表示无法识别
+	Country     string  `json:"country"`          // 国家/地区
+	Region      string  `json:"region,omitempty"` // 省/州（按省/州、城市统计时返回）
+	City        string  `json:"city,omitempty"`   // 城市（按城市统计时返回）
+	Visits      int64   `json:"visits"`           // 访问数
+	Visitors    int64   `json:"visitors"`         // 访客数（去重指纹）
+	Share       float64 `json:"share"`            // 访问数占比（0-1）
+}
+
+// geoStatsRepository 访问地区统计仓库实现
+type geoStatsRepository struct {
+	db *gorm.DB
+}
+
+// NewGeoStatsRepository 创建访问地区统计仓库
+func NewGeoStatsRepository(db *gorm.DB) GeoStatsRepository {
+	return &geoStatsRepository{db: db}
+}
+
+// GetBreakdown 获取区间内访问记录按地区的分布
+func (r *geoStatsRepository) GetBreakdown(level, countryCode string, startDate, endDate time.Time, limit int) ([]GeoStat, error) {
+	columns, ok := geoLevelColumns[level]
+	if !ok {
+		return nil, ErrInvalidGeoLevel
+	}
+
+	where := "visit_time >= ? AND visit_time < ?"
+	args := []interface{}{startDate, endDate}
+	if countryCode != "" {
+		where += " AND country_code = ?"
+		args = append(args, countryCode)
+	}
+	args = append(args, limit)
+
+	var stats []GeoStat
+	// 占比的窗口函数在LIMIT之前计算，分母是区间内（指定国家/地区时为该国家/地区）的全部访问
+	err := r.db.Raw(`
+		SELECT `+columns+`,
+			COUNT(*) AS visits,
+			COUNT(DISTINCT fingerprint_id) AS visitors,
+			COUNT(*)::float8 / SUM(COUNT(*)) OVER () AS share
+		FROM visits
+		WHERE `+where+`
+		GROUP BY 1, 2, 3, 4
+		ORDER BY visits DESC, country_code ASC, region ASC, city ASC
+		LIMIT ?
+	`, args...).Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMediaNotFound = errors.New("media not found")
+)
+
+// MediaFilter 媒体筛选条件
+type MediaFilter struct {
+	Keyword    string // 搜索关键词（原始文件名/对象key）
+	MimeType   string // 文件类型前缀，如 image/
+	UploaderID *uint
+	Unused     *bool // true 只看未被引用的文件，false 只看已被引用的文件
+}
+
+// MediaRepository 媒体仓库接口
+type MediaRepository interface {
+	// 创建媒体记录
+	Create(media *models.Media) error
+	// 根据ID查找媒体
+	FindByID(id uint) (*models.Media, error)
+	// 获取媒体列表（带筛选）
+	List(filter *MediaFilter, offset, limit int) ([]models.Media, int64, error)
+	// 删除媒体记录（引用记录级联删除）
+	Delete(id uint) error
+	// 获取全部媒体的ID、对象key、访问地址和宽度变体（用于引用扫描）
+	ListAll() ([]models.Media, error)
+	// 用扫描结果整体替换引用记录，并更新各媒体的引用数
+	ReplaceReferences(refs []models.MediaReference, scannedAt time.Time) error
+	// 获取媒体的引用记录（附带文章标题）
+	ListReferences(mediaID uint) ([]models.MediaReference, error)
+	// 获取创建时间早于指定时间且未被引用的媒体
+	ListOrphans(before time.Time) ([]models.Media, error)
+}
+
+// mediaRepository 媒体仓库实现
+type mediaRepository struct {
+	db *gorm.DB
+}
+
+// NewMediaRepository 创建媒体仓库
+func NewMediaRepository(db *gorm.DB) MediaRepository {
+	return &mediaRepository{db: db}
+}
+
+// Create 创建媒体记录
+func (r *mediaRepository) Create(media *models.Media) error {
+	return r.db.Create(media).Error
+}
+
+// FindByID 根据ID查找媒体
+func (r *mediaRepository) FindByID(id uint) (*models.Media, error) {
+	var media models.Media
+	err := r.db.Preload("Uploader", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Select("id", "username")
+	}).First(&media, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+	return &media, nil
+}
+
+// List 获取媒体列表（带筛选）
+func (r *mediaRepository) List(filter *MediaFilter, offset, limit int) ([]models.Media, int64, error) {
+	var items []models.Media
+	var total int64
+
+	query := r.db.Model(&models.Media{})
+
+	if filter != nil {
+		if filter.Keyword != "" {
+			keyword := "%" + filter.Keyword + "%"
+			query = query.Where("original_name ILIKE ? OR storage_key ILIKE ?", keyword, keyword)
+		}
+		if filter.MimeType != "" {
+			query = query.Where("mime_type LIKE ?", filter.MimeType+"%")
+		}
+		if filter.UploaderID != nil {
+			query = query.Where("uploader_id = ?", *filter.UploaderID)
+		}
+		if filter.Unused != nil {
+			if *filter.Unused {
+				query = query.Where("reference_count = 0")
+			} else {
+				query = query.Where("reference_count > 0")
+			}
+		}
+	}
+
+	// 获取总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Preload("Uploader", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Select("id", "username")
+	}).Order("created_at DESC, id DESC")
+
+	if limit > 0 {
+		query = query.Limit(limit).Offset(offset)
+	}
+
+	if err := query.Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// Delete 删除媒体记录
+func (r *mediaRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Media{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMediaNotFound
+	}
+	return nil
+}
+
+// ListAll 获取全部媒体的ID、对象key、访问地址和宽度变体
+func (r *mediaRepository) ListAll() ([]models.Media, error) {
+	var items []models.Media
+	err := r.db.Select("id", "storage_key", "url", "variants").Order("id ASC").Find(&items).Error
+	return items, err
+}
+
+// ReplaceReferences 用扫描结果整体替换引用记录
+func (r *mediaRepository) ReplaceReferences(refs []models.MediaReference, scannedAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM media_references").Error; err != nil {
+			return err
+		}
+
+		if len(refs) > 0 {
+			if err := tx.CreateInBatches(refs, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		// 引用数按文章去重统计（同一篇文章正文和封面都引用时只算一次）
+		return tx.Exec(`
+			UPDATE media SET
+				reference_count = COALESCE((
+					SELECT COUNT(DISTINCT article_id) FROM media_references WHERE media_references.media_id = media.id
+				), 0),
+				last_scanned_at = ?`, scannedAt).Error
+	})
+}
+
+// ListReferences 获取媒体的引用记录
+func (r *mediaRepository) ListReferences(mediaID uint) ([]models.MediaReference, error) {
+	var refs []models.MediaReference
+	err := r.db.Where("media_id = ?", mediaID).
+		Preload("Article", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "title", "slug", "status")
+		}).
+		Order("article_id ASC, field ASC").
+		Find(&refs).Error
+	return refs, err
+}
+
+// ListOrphans 获取创建时间早于指定时间且未被引用的媒体（尚未扫描过的不计入）
+func (r *mediaRepository) ListOrphans(before time.Time) ([]models.Media, error) {
+	var items []models.Media
+	err := r.db.Where("reference_count = 0 AND last_scanned_at IS NOT NULL AND created_at < ?", before).
+		Order("created_at ASC").
+		Find(&items).Error
+	return items, err
+}
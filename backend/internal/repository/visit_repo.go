@@ -24,38 +24,18 @@ type VisitFilter struct {
 type VisitRepository interface {
 	// 创建访问记录
 	Create(visit *models.Visit) error
+	// 批量创建访问记录（多行INSERT）
+	CreateBatch(visits []*models.Visit) error
 	// 根据ID查找访问记录
 	FindByID(id uint) (*models.Visit, error)
 	// 获取访问记录列表（带筛选）
 	List(filter *VisitFilter, offset, limit int) ([]models.Visit, int64, error)
-	// 获取PV统计（按日期）
-	GetPVStats(startDate, endDate time.Time, groupBy string) ([]PVStat, error)
-	// 获取UV统计（按日期）
-	GetUVStats(startDate, endDate time.Time, groupBy string) ([]UVStat, error)
-	// 获取平均停留时间（按日期）
-	GetAvgStayDuration(startDate, endDate time.Time, groupBy string) ([]AvgStayDurationStat, error)
-	// 获取热门文章统计
-	GetPopularArticles(limit int, days int) ([]PopularArticle, error)
+	// 按访问标识更新停留时间和滚动深度（只增不减），访问记录不存在时返回false
+	UpdateBeacon(visitKey string, stayDuration, scrollDepth *int) (bool, error)
 	// 获取访问来源统计
 	GetReferrerStats(startDate, endDate time.Time) (*ReferrerStats, error)
-}
-
-// PVStat PV统计
-type PVStat struct {
-	Date string `json:"date"`
-	PV   int64  `json:"pv"`
-}
-
-// UVStat UV统计
-type UVStat struct {
-	Date string `json:"date"`
-	UV   int64  `json:"uv"`
-}
-
-// AvgStayDurationStat 平均停留时间统计
-type AvgStayDurationStat struct {
-	Date            string  `json:"date"`
-	AvgStayDuration float64 `json:"avg_stay_duration"`
+	// 获取会话统计：同一指纹两次访问间隔超过gap视为新的会话
+	GetSessionStats(startDate, endDate time.Time, gap time.Duration, limit int) (*SessionStats, error)
 }
 
 // PopularArticle 热门文章
@@ -81,6 +61,24 @@ type TopReferrer struct {
 	Count    int64  `json:"count"`
 }
 
+// SessionStats 会话统计
+type SessionStats struct {
+	Sessions          int64             `json:"sessions"`            // 会话数
+	Visitors          int64             `json:"visitors"`            // 访客数（去重指纹）
+	PageViews         int64             `json:"page_views"`          // 会话内的页面浏览数
+	Bounces           int64             `json:"bounces"`             // 只浏览了一个页面的会话数
+	TotalDurationSecs float64           `json:"total_duration_secs"` // 会话时长之和（秒）
+	EntryPages        []SessionPageStat `json:"entry_pages"`         // 入口页面
+	ExitPages         []SessionPageStat `json:"exit_pages"`          // 退出页面
+}
+
+// SessionPageStat 会话入口/退出页面统计
+type SessionPageStat struct {
+	URL      string `json:"url"`
+	Sessions int64  `json:"sessions"` // 以该页面开始/结束的会话数
+	Bounces  int64  `json:"bounces"`  // 其中只浏览了该页面的会话数
+}
+
 // visitRepository 访问记录仓库实现
 type visitRepository struct {
 	db *gorm.DB
@@ -96,6 +94,32 @@ func (r *visitRepository) Create(visit *models.Visit) error {
 	return r.db.Create(visit).Error
 }
 
+// visitInsertBatchSize 单条INSERT语句的最大行数，避免超出PostgreSQL的参数个数限制
+const visitInsertBatchSize = 1000
+
+// CreateBatch 批量创建访问记录
+func (r *visitRepository) CreateBatch(visits []*models.Visit) error {
+	if len(visits) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(visits, visitInsertBatchSize).Error
+}
+
+// UpdateBeacon 按访问标识更新停留时间和滚动深度
+func (r *visitRepository) UpdateBeacon(visitKey string, stayDuration, scrollDepth *int) (bool, error) {
+	// 同一页面可能多次上报（切换标签页、离开页面），只保留最大值
+	result := r.db.Model(&models.Visit{}).
+		Where("visit_key = ?", visitKey).
+		Updates(map[string]interface{}{
+			"stay_duration": gorm.Expr("GREATEST(stay_duration, ?)", stayDuration),
+			"scroll_depth":  gorm.Expr("GREATEST(scroll_depth, ?)", scrollDepth),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // FindByID 根据ID查找访问记录
 func (r *visitRepository) FindByID(id uint) (*models.Visit, error) {
 	var visit models.Visit
@@ -165,114 +189,6 @@ func (r *visitRepository) applyFilter(query *gorm.DB, filter *VisitFilter) *gorm
 	return query
 }
 
-// GetPVStats 获取PV统计（按日期）
-func (r *visitRepository) GetPVStats(startDate, endDate time.Time, groupBy string) ([]PVStat, error) {
-	var stats []PVStat
-
-	dateFormat := "YYYY-MM-DD"
-	if groupBy == "weekly" {
-		dateFormat = "YYYY-\"W\"WW"
-	} else if groupBy == "monthly" {
-		dateFormat = "YYYY-MM"
-	}
-
-	query := r.db.Model(&models.Visit{}).
-		Select("TO_CHAR(visit_time, ?) as date, COUNT(*) as pv", dateFormat).
-		Where("visit_time >= ? AND visit_time <= ?", startDate, endDate).
-		Group("date").
-		Order("date ASC")
-
-	if err := query.Scan(&stats).Error; err != nil {
-		return nil, err
-	}
-
-	return stats, nil
-}
-
-// GetUVStats 获取UV统计（按日期）
-func (r *visitRepository) GetUVStats(startDate, endDate time.Time, groupBy string) ([]UVStat, error) {
-	var stats []UVStat
-
-	dateFormat := "YYYY-MM-DD"
-	if groupBy == "weekly" {
-		dateFormat = "YYYY-\"W\"WW"
-	} else if groupBy == "monthly" {
-		dateFormat = "YYYY-MM"
-	}
-
-	query := r.db.Model(&models.Visit{}).
-		Select("TO_CHAR(visit_time, ?) as date, COUNT(DISTINCT fingerprint_id) as uv", dateFormat).
-		Where("visit_time >= ? AND visit_time <= ?", startDate, endDate).
-		Where("fingerprint_id IS NOT NULL").
-		Group("date").
-		Order("date ASC")
-
-	if err := query.Scan(&stats).Error; err != nil {
-		return nil, err
-	}
-
-	return stats, nil
-}
-
-// GetAvgStayDuration 获取平均停留时间（按日期）
-func (r *visitRepository) GetAvgStayDuration(startDate, endDate time.Time, groupBy string) ([]AvgStayDurationStat, error) {
-	var stats []AvgStayDurationStat
-
-	dateFormat := "YYYY-MM-DD"
-	if groupBy == "weekly" {
-		dateFormat = "YYYY-\"W\"WW"
-	} else if groupBy == "monthly" {
-		dateFormat = "YYYY-MM"
-	}
-
-	query := r.db.Model(&models.Visit{}).
-		Select("TO_CHAR(visit_time, ?) as date, AVG(stay_duration) as avg_stay_duration", dateFormat).
-		Where("visit_time >= ? AND visit_time <= ?", startDate, endDate).
-		Where("stay_duration IS NOT NULL").
-		Group("date").
-		Order("date ASC")
-
-	if err := query.Scan(&stats).Error; err != nil {
-		return nil, err
-	}
-
-	return stats, nil
-}
-
-// GetPopularArticles 获取热门文章统计
-func (r *visitRepository) GetPopularArticles(limit int, days int) ([]PopularArticle, error) {
-	var articles []PopularArticle
-
-	startDate := time.Now().AddDate(0, 0, -days)
-
-	query := r.db.Model(&models.Visit{}).
-		Select(`
-			article_id,
-			COUNT(*) as visit_count,
-			AVG(stay_duration) as avg_stay_duration
-		`).
-		Where("visit_time >= ?", startDate).
-		Where("article_id IS NOT NULL").
-		Group("article_id").
-		Order("visit_count DESC").
-		Limit(limit)
-
-	if err := query.Scan(&articles).Error; err != nil {
-		return nil, err
-	}
-
-	// 获取文章标题和浏览量
-	for i := range articles {
-		var article models.Article
-		if err := r.db.Select("title, view_count").First(&article, articles[i].ArticleID).Error; err == nil {
-			articles[i].Title = article.Title
-			articles[i].ViewCount = int64(article.ViewCount)
-		}
-	}
-
-	return articles, nil
-}
-
 // GetReferrerStats 获取访问来源统计
 func (r *visitRepository) GetReferrerStats(startDate, endDate time.Time) (*ReferrerStats, error) {
 	stats := &ReferrerStats{}
@@ -331,3 +247,89 @@ func (r *visitRepository) GetReferrerStats(startDate, endDate time.Time) (*Refer
 
 	return stats, nil
 }
+
+// sessionsSQL 将区间内的访问按指纹切分为会话，每个会话一行
+//
+// 参数依次为：会话间隔（秒）、开始时间、结束时间。区间开始前已开始的会话从区间内第一次访问算起
+const sessionsSQL = `
+	WITH marked AS (
+		SELECT id, fingerprint_id, url, visit_time, stay_duration,
+			CASE WHEN LAG(visit_time) OVER w IS NULL
+				OR visit_time - LAG(visit_time) OVER w > make_interval(secs => ?)
+			THEN 1 ELSE 0 END AS is_start
+		FROM visits
+		WHERE fingerprint_id IS NOT NULL AND visit_time >= ? AND visit_time < ?
+		WINDOW w AS (PARTITION BY fingerprint_id ORDER BY visit_time, id)
+	),
+	numbered AS (
+		SELECT *, SUM(is_start) OVER (PARTITION BY fingerprint_id ORDER BY visit_time, id ROWS UNBOUNDED PRECEDING) AS session_no
+		FROM marked
+	)
+	SELECT fingerprint_id, session_no,
+		COUNT(*) AS pages,
+		(ARRAY_AGG(url ORDER BY visit_time ASC, id ASC))[1] AS entry_url,
+		(ARRAY_AGG(url ORDER BY visit_time DESC, id DESC))[1] AS exit_url,
+		EXTRACT(EPOCH FROM MAX(visit_time) - MIN(visit_time))
+			+ COALESCE((ARRAY_AGG(stay_duration ORDER BY visit_time DESC, id DESC))[1], 0) AS duration
+	FROM numbered
+	GROUP BY fingerprint_id, session_no
+`
+
+// GetSessionStats 获取会话统计
+func (r *visitRepository) GetSessionStats(startDate, endDate time.Time, gap time.Duration, limit int) (*SessionStats, error) {
+	args := []interface{}{gap.Seconds(), startDate, endDate}
+
+	// 汇总
+	var summary struct {
+		Sessions          int64
+		Visitors          int64
+		PageViews         int64
+		Bounces           int64
+		TotalDurationSecs float64
+	}
+	err := r.db.Raw(`
+		SELECT COUNT(*) AS sessions,
+			COUNT(DISTINCT fingerprint_id) AS visitors,
+			COALESCE(SUM(pages), 0)::bigint AS page_views,
+			COUNT(*) FILTER (WHERE pages = 1) AS bounces,
+			COALESCE(SUM(duration), 0)::float8 AS total_duration_secs
+		FROM (`+sessionsSQL+`) s
+	`, args...).Scan(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &SessionStats{
+		Sessions:          summary.Sessions,
+		Visitors:          summary.Visitors,
+		PageViews:         summary.PageViews,
+		Bounces:           summary.Bounces,
+		TotalDurationSecs: summary.TotalDurationSecs,
+	}
+
+	// 入口页面
+	err = r.db.Raw(`
+		SELECT entry_url AS url, COUNT(*) AS sessions, COUNT(*) FILTER (WHERE pages = 1) AS bounces
+		FROM (`+sessionsSQL+`) s
+		GROUP BY entry_url
+		ORDER BY sessions DESC
+		LIMIT ?
+	`, append(args, limit)...).Scan(&stats.EntryPages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 退出页面
+	err = r.db.Raw(`
+		SELECT exit_url AS url, COUNT(*) AS sessions, COUNT(*) FILTER (WHERE pages = 1) AS bounces
+		FROM (`+sessionsSQL+`) s
+		GROUP BY exit_url
+		ORDER BY sessions DESC
+		LIMIT ?
+	`, append(args, limit)...).Scan(&stats.ExitPages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
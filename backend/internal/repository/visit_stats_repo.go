@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// statDateLayout 汇总日期格式
+const statDateLayout = "2006-01-02"
+
+// VisitStatsRepository 访问统计日汇总仓库接口
+//
+// 日期参数均按本地时区的自然日处理（只取年月日），区间为左闭右开
+type VisitStatsRepository interface {
+	// 汇总某一天的访问记录（先删除该天已有的汇总再重新写入，所在周/月已结束时同时更新周期汇总）
+	AggregateDay(day time.Time) error
+	// 获取已汇总的日期范围（没有汇总数据时返回nil）
+	GetAggregatedRange() (first, last *time.Time, err error)
+	// 获取区间内已汇总的日期
+	ListAggregatedDays(startDay, endDay time.Time) ([]time.Time, error)
+	// 获取最早一条访问记录的日期（没有访问记录时返回nil）
+	GetEarliestVisitDay() (*time.Time, error)
+	// 获取区间统计：[startDay, splitDay) 读取日汇总，[splitDay, endDay) 读取访问记录（按周/月统计时UV为周期内去重的访客数）
+	GetPeriodStats(startDay, splitDay, endDay time.Time, groupBy string) ([]VisitPeriodStat, error)
+	// 获取热门文章：[startDay, splitDay) 读取日汇总，splitDay之后读取访问记录
+	GetPopularArticles(startDay, splitDay time.Time, limit int) ([]PopularArticle, error)
+}
+
+// VisitPeriodStat 区间访问统计
+type VisitPeriodStat struct {
+	Date              string `json:"date"`
+	PV                int64  `json:"pv"`
+	UV                int64  `json:"uv"` // 周期内去重的访客数
+	StayDurationSum   int64  `json:"stay_duration_sum"`
+	StayDurationCount int64  `json:"stay_duration_count"`
+}
+
+// visitStatsRepository 访问统计日汇总仓库实现
+type visitStatsRepository struct {
+	db *gorm.DB
+}
+
+// NewVisitStatsRepository 创建访问统计日汇总仓库
+func NewVisitStatsRepository(db *gorm.DB) VisitStatsRepository {
+	return &visitStatsRepository{db: db}
+}
+
+// AggregateDay 汇总某一天的访问记录
+func (r *visitStatsRepository) AggregateDay(day time.Time) error {
+	start := localDay(day)
+	end := start.AddDate(0, 0, 1)
+	statDate := start.Format(statDateLayout)
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM visit_daily_stats WHERE stat_date = ?", statDate).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM article_daily_stats WHERE stat_date = ?", statDate).Error; err != nil {
+			return err
+		}
+
+		// 没有访问的日期也写入一行，作为该天已汇总的标记
+		err := tx.Exec(`
+			INSERT INTO visit_daily_stats (stat_date, pv, uv, stay_duration_sum, stay_duration_count, updated_at)
+			SELECT ?::date, COUNT(*), COUNT(DISTINCT fingerprint_id), COALESCE(SUM(stay_duration), 0), COUNT(stay_duration), NOW()
+			FROM visits
+			WHERE visit_time >= ? AND visit_time < ?
+		`, statDate, start, end).Error
+		if err != nil {
+			return err
+		}
+
+		// 文章已被物理删除的访问记录（article_id已置空）不计入
+		err = tx.Exec(`
+			INSERT INTO article_daily_stats (stat_date, article_id, pv, uv, stay_duration_sum, stay_duration_count, updated_at)
+			SELECT ?::date, article_id, COUNT(*), COUNT(DISTINCT fingerprint_id), COALESCE(SUM(stay_duration), 0), COUNT(stay_duration), NOW()
+			FROM visits
+			WHERE visit_time >= ? AND visit_time < ? AND article_id IS NOT NULL
+			GROUP BY article_id
+		`, statDate, start, end).Error
+		if err != nil {
+			return err
+		}
+
+		// 同一访客在一周/一月内多天访问只算一次，周期UV不能由日汇总相加，周期结束后按访问记录去重
+		today := localDay(time.Now())
+		for _, groupBy := range []string{"weekly", "monthly"} {
+			periodStart, periodEnd, key := periodBounds(start, groupBy)
+			if periodEnd.After(today) {
+				continue
+			}
+			err := tx.Exec(`
+				INSERT INTO visit_period_stats (period_type, period_key, uv, updated_at)
+				SELECT ?, ?, COUNT(DISTINCT fingerprint_id), NOW()
+				FROM visits
+				WHERE visit_time >= ? AND visit_time < ?
+				ON CONFLICT (period_type, period_key) DO UPDATE SET uv = EXCLUDED.uv, updated_at = EXCLUDED.updated_at
+			`, groupBy, key, periodStart, periodEnd).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetAggregatedRange 获取已汇总的日期范围
+func (r *visitStatsRepository) GetAggregatedRange() (*time.Time, *time.Time, error) {
+	var result struct {
+		First *time.Time
+		Last  *time.Time
+	}
+	err := r.db.Raw("SELECT MIN(stat_date) AS first, MAX(stat_date) AS last FROM visit_daily_stats").Scan(&result).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return localDayPtr(result.First), localDayPtr(result.Last), nil
+}
+
+// ListAggregatedDays 获取区间内已汇总的日期
+func (r *visitStatsRepository) ListAggregatedDays(startDay, endDay time.Time) ([]time.Time, error) {
+	var dates []time.Time
+	err := r.db.Raw("SELECT stat_date FROM visit_daily_stats WHERE stat_date >= ? AND stat_date < ? ORDER BY stat_date",
+		localDay(startDay).Format(statDateLayout), localDay(endDay).Format(statDateLayout)).
+		Scan(&dates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]time.Time, 0, len(dates))
+	for _, date := range dates {
+		days = append(days, localDay(date))
+	}
+	return days, nil
+}
+
+// GetEarliestVisitDay 获取最早一条访问记录的日期
+func (r *visitStatsRepository) GetEarliestVisitDay() (*time.Time, error) {
+	var earliest *time.Time
+	if err := r.db.Raw("SELECT MIN(visit_time) FROM visits").Scan(&earliest).Error; err != nil {
+		return nil, err
+	}
+	return localDayPtr(earliest), nil
+}
+
+// GetPeriodStats 获取区间统计
+func (r *visitStatsRepository) GetPeriodStats(startDay, splitDay, endDay time.Time, groupBy string) ([]VisitPeriodStat, error) {
+	var stats []VisitPeriodStat
+
+	dateFormat := "YYYY-MM-DD"
+	if groupBy == "weekly" {
+		dateFormat = "YYYY-\"W\"WW"
+	} else if groupBy == "monthly" {
+		dateFormat = "YYYY-MM"
+	}
+
+	err := r.db.Raw(`
+		SELECT TO_CHAR(d.day, ?) AS date,
+			SUM(d.pv)::bigint AS pv,
+			SUM(d.uv)::bigint AS uv,
+			SUM(d.stay_duration_sum)::bigint AS stay_duration_sum,
+			SUM(d.stay_duration_count)::bigint AS stay_duration_count
+		FROM (
+			SELECT stat_date AS day, pv, uv, stay_duration_sum, stay_duration_count
+			FROM visit_daily_stats
+			WHERE stat_date >= ? AND stat_date < ? AND pv > 0
+			UNION ALL
+			SELECT visit_time::date AS day, COUNT(*), COUNT(DISTINCT fingerprint_id), COALESCE(SUM(stay_duration), 0), COUNT(stay_duration)
+			FROM visits
+			WHERE visit_time >= ? AND visit_time < ?
+			GROUP BY visit_time::date
+		) d
+		GROUP BY date
+		ORDER BY date ASC
+	`, dateFormat,
+		localDay(startDay).Format(statDateLayout), localDay(splitDay).Format(statDateLayout),
+		localDay(splitDay), localDay(endDay)).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一访客在一周/一月内多天访问只算一次，不能用每日UV相加
+	if groupBy == "weekly" || groupBy == "monthly" {
+		if err := r.fillDistinctUV(stats, groupBy, startDay, splitDay, endDay); err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+// fillDistinctUV 填充每个周期去重的访客数
+// 完整落在已汇总日期内的周期读取周期汇总；区间首尾不完整的周期、未结束的周期以及缺少汇总的周期按访问记录去重，只扫描这些周期内的访问记录
+func (r *visitStatsRepository) fillDistinctUV(stats []VisitPeriodStat, groupBy string, startDay, splitDay, endDay time.Time) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	startDay, splitDay, endDay = localDay(startDay), localDay(splitDay), localDay(endDay)
+
+	type period struct {
+		start, end time.Time
+	}
+	periods := make(map[string]period)
+	rolledKeys := make([]string, 0)
+	for day := startDay; day.Before(endDay); {
+		start, end, key := periodBounds(day, groupBy)
+		if !start.Before(startDay) && !end.After(splitDay) {
+			rolledKeys = append(rolledKeys, key)
+		}
+		if start.Before(startDay) {
+			start = startDay
+		}
+		if end.After(endDay) {
+			end = endDay
+		}
+		periods[key] = period{start: start, end: end}
+		day = end
+	}
+
+	uv := make(map[string]int64, len(periods))
+	if len(rolledKeys) > 0 {
+		var rows []struct {
+			PeriodKey string
+			UV        int64
+		}
+		err := r.db.Raw("SELECT period_key, uv FROM visit_period_stats WHERE period_type = ? AND period_key IN ?", groupBy, rolledKeys).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			uv[row.PeriodKey] = row.UV
+		}
+	}
+
+	for i := range stats {
+		key := stats[i].Date
+		if count, ok := uv[key]; ok {
+			stats[i].UV = count
+			continue
+		}
+		p, ok := periods[key]
+		if !ok {
+			continue
+		}
+		var count int64
+		err := r.db.Raw("SELECT COUNT(DISTINCT fingerprint_id) FROM visits WHERE visit_time >= ? AND visit_time < ?", p.start, p.end).
+			Scan(&count).Error
+		if err != nil {
+			return err
+		}
+		stats[i].UV = count
+	}
+	return nil
+}
+
+// periodBounds 获取某天所在周/月的起止日期（左闭右开）和周期标识
+// 周与TO_CHAR的WW一致：从当年1月1日起每7天为一周，年末最后一周不足7天
+func periodBounds(day time.Time, groupBy string) (time.Time, time.Time, string) {
+	day = localDay(day)
+	if groupBy == "monthly" {
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(0, 1, 0), start.Format("2006-01")
+	}
+
+	yearStart := time.Date(day.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	week := (day.YearDay() - 1) / 7
+	start := yearStart.AddDate(0, 0, week*7)
+	end := start.AddDate(0, 0, 7)
+	if nextYear := yearStart.AddDate(1, 0, 0); end.After(nextYear) {
+		end = nextYear
+	}
+	return start, end, fmt.Sprintf("%04d-W%02d", day.Year(), week+1)
+}
+
+// GetPopularArticles 获取热门文章
+func (r *visitStatsRepository) GetPopularArticles(startDay, splitDay time.Time, limit int) ([]PopularArticle, error) {
+	var articles []PopularArticle
+
+	// 已删除文章的标题和浏览量留空
+	err := r.db.Raw(`
+		SELECT s.article_id,
+			COALESCE(a.title, '') AS title,
+			COALESCE(a.view_count, 0) AS view_count,
+			SUM(s.pv)::bigint AS visit_count,
+			COALESCE(SUM(s.stay_duration_sum)::float8 / NULLIF(SUM(s.stay_duration_count), 0), 0) AS avg_stay_duration
+		FROM (
+			SELECT article_id, pv, stay_duration_sum, stay_duration_count
+			FROM article_daily_stats
+			WHERE stat_date >= ? AND stat_date < ?
+			UNION ALL
+			SELECT article_id, COUNT(*), COALESCE(SUM(stay_duration), 0), COUNT(stay_duration)
+			FROM visits
+			WHERE visit_time >= ? AND article_id IS NOT NULL
+			GROUP BY article_id
+		) s
+		LEFT JOIN articles a ON a.id = s.article_id AND a.deleted_at IS NULL
+		GROUP BY s.article_id, a.title, a.view_count
+		ORDER BY visit_count DESC
+		LIMIT ?
+	`, localDay(startDay).Format(statDateLayout), localDay(splitDay).Format(statDateLayout), localDay(splitDay), limit).
+		Scan(&articles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// localDay 取本地时区当天零点（只保留年月日，DATE列读出的UTC零点也按原日期处理）
+func localDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// localDayPtr 同localDay，nil时返回nil
+func localDayPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	day := localDay(*t)
+	return &day
+}
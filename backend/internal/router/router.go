@@ -1,13 +1,20 @@
 package router
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/whk-newbie/blog/internal/config"
 	"github.com/whk-newbie/blog/internal/handler"
 	"github.com/whk-newbie/blog/internal/middleware"
+	"github.com/whk-newbie/blog/internal/pkg/crypto"
 	"github.com/whk-newbie/blog/internal/pkg/db"
+	"github.com/whk-newbie/blog/internal/pkg/filetype"
+	"github.com/whk-newbie/blog/internal/pkg/geoip"
 	"github.com/whk-newbie/blog/internal/pkg/jwt"
 	"github.com/whk-newbie/blog/internal/pkg/logger"
+	"github.com/whk-newbie/blog/internal/pkg/search"
+	"github.com/whk-newbie/blog/internal/pkg/storage"
 	"github.com/whk-newbie/blog/internal/repository"
 	"github.com/whk-newbie/blog/internal/scheduler"
 	"github.com/whk-newbie/blog/internal/service"
@@ -17,8 +24,8 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// Setup 设置路由
-func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
+// Setup 设置路由，同时返回调度器和访问记录写入缓冲（由调用方负责启动和停止）
+func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager, *service.VisitBuffer) {
 	r := gin.New()
 
 	// 使用中间件
@@ -57,38 +64,109 @@ func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
 	categoryRepo := repository.NewCategoryRepository(gormDB)
 	tagRepo := repository.NewTagRepository(gormDB)
 	articleRepo := repository.NewArticleRepository(gormDB)
+	articleRevisionRepo := repository.NewArticleRevisionRepository(gormDB)
 	fingerprintRepo := repository.NewFingerprintRepository(gormDB)
 	visitRepo := repository.NewVisitRepository(gormDB)
+	visitStatsRepo := repository.NewVisitStatsRepository(gormDB)
+	clientInfoRepo := repository.NewClientInfoRepository(gormDB)
+	geoStatsRepo := repository.NewGeoStatsRepository(gormDB)
 	crawlTaskRepo := repository.NewCrawlTaskRepository(gormDB)
+	crawlResultRepo := repository.NewCrawlResultRepository(gormDB)
+	crawlJobRepo := repository.NewCrawlJobRepository(gormDB)
 	configRepo := repository.NewConfigRepository(gormDB)
 	logRepo := repository.NewLogRepository(gormDB)
+	commentRepo := repository.NewCommentRepository(gormDB)
+	mediaRepo := repository.NewMediaRepository(gormDB)
 
 	// 初始化Service
-	authService := service.NewAuthService(adminRepo, jwtManager, cfg.JWT.ExpireTime)
+	// 两步验证密钥使用主密钥加密存储
+	cryptoUtil, err := crypto.NewCrypto(cfg.Crypto.MasterKey)
+	if err != nil {
+		panic("Failed to initialize crypto: " + err.Error())
+	}
+	// 登录会话（刷新Token轮换与访问Token吊销名单）
+	sessionService := service.NewSessionService(adminRepo, jwtManager, cfg.JWT.RefreshExpireTime)
+	jwtManager.SetDenylist(sessionService)
+	// 初始化日志服务
+	logService := service.NewLogService(logRepo)
+	// 登录防暴力破解：按用户名和IP统计连续失败次数，超过阈值后按指数退避锁定
+	loginGuard := service.NewLoginGuardService(logService, service.LoginGuardConfig{
+		UsernameThreshold: 5,
+		IPThreshold:       20,
+		FailureWindow:     time.Hour,
+		BaseLockDuration:  time.Minute,
+		MaxLockDuration:   time.Hour,
+	})
+	authService := service.NewAuthService(adminRepo, jwtManager, sessionService, loginGuard, cryptoUtil, cfg.JWT.Issuer)
+	adminUserService := service.NewAdminUserService(adminRepo, sessionService)
 	categoryService := service.NewCategoryService(categoryRepo)
 	tagService := service.NewTagService(tagRepo)
+	// 初始化配置服务
+	configService, err := service.NewConfigService(configRepo, cfg.Crypto.MasterKey)
+	if err != nil {
+		panic("Failed to initialize config service: " + err.Error())
+	}
+
+	// 初始化Sitemap服务（文章发布状态变化时异步重建）
+	sitemapService := service.NewSitemapService(articleRepo, categoryRepo, tagRepo, configService, cfg.Site)
+
+	// 初始化全文检索分词器
+	tokenizer, err := search.New(cfg.Search.Tokenizer)
+	if err != nil {
+		panic("Failed to initialize search tokenizer: " + err.Error())
+	}
+
 	articleCacheSvc := service.NewArticleCacheService()
-	articleService := service.NewArticleService(articleRepo, categoryRepo, tagRepo, articleCacheSvc)
+	articleService := service.NewArticleService(articleRepo, categoryRepo, tagRepo, articleCacheSvc, sitemapService, tokenizer)
+	articleRevisionService := service.NewArticleRevisionService(articleRevisionRepo, articleRepo, articleService)
 
 	// 访问统计相关服务
 	visitCacheService := service.NewVisitCacheService()
-	visitService := service.NewVisitService(visitRepo, visitCacheService)
+	visitBuffer := service.NewVisitBuffer(visitRepo, service.VisitBufferConfig{
+		QueueSize:     cfg.Visit.QueueSize,
+		BatchSize:     cfg.Visit.BatchSize,
+		FlushInterval: cfg.Visit.FlushInterval,
+		Workers:       cfg.Visit.Workers,
+	})
+	// 离线IP归属地数据库，未配置或打开失败时不解析访问地区
+	var geoReader *geoip.Reader
+	if cfg.GeoIP.DBPath != "" {
+		geoReader, err = geoip.Open(cfg.GeoIP.DBPath, cfg.GeoIP.Language)
+		if err != nil {
+			logger.Warn("Failed to open GeoIP database %s, visit geo location is disabled: %v", cfg.GeoIP.DBPath, err)
+		} else {
+			logger.Info("GeoIP database loaded: %s (%s)", cfg.GeoIP.DBPath, geoReader.DatabaseType())
+		}
+	}
+	geoService := service.NewGeoService(geoStatsRepo, geoReader, cfg.Visit.TruncateIP)
+	visitService := service.NewVisitService(visitRepo, visitStatsRepo, visitCacheService, visitBuffer, geoService, cfg.Visit.SessionGap)
+	visitRollupService := service.NewVisitRollupService(visitStatsRepo)
+	clientStatsService := service.NewClientStatsService(clientInfoRepo)
 	fingerprintService := service.NewFingerprintService(fingerprintRepo)
+	commentService := service.NewCommentService(commentRepo, articleRepo, fingerprintRepo, articleCacheSvc)
 	statsService := service.NewStatsService(articleRepo, categoryRepo, tagRepo, visitService)
 
+	// 初始化上传文件存储（本地磁盘或S3兼容的对象存储）
+	uploadStorage, err := storage.New(cfg.Upload)
+	if err != nil {
+		panic("Failed to initialize upload storage: " + err.Error())
+	}
+	// 图片缩放（按宽度白名单实时缩放并缓存在本地磁盘）
+	imageService := service.NewImageService(uploadStorage, cfg.Upload.ImageCacheDir, cfg.Upload.VariantWidths)
+	mediaService := service.NewMediaService(mediaRepo, articleRepo, articleRevisionRepo, uploadStorage, imageService)
+
 	// 初始化WebSocket Hub
 	wsHub := websocket.NewHub()
 	go wsHub.Run()
 
 	// 初始化爬虫任务服务（需要Hub）
-	crawlService := service.NewCrawlService(crawlTaskRepo, wsHub)
+	crawlImportService := service.NewCrawlImportService(crawlTaskRepo, crawlResultRepo, articleRepo, categoryRepo, adminRepo, articleService, tagService, wsHub, cfg.Crawler.ImportAuthor)
+	crawlService := service.NewCrawlService(crawlTaskRepo, crawlJobRepo, crawlImportService, wsHub)
+	crawlJobService := service.NewCrawlJobService(crawlJobRepo, crawlTaskRepo, crawlImportService, wsHub)
+	crawlResultService := service.NewCrawlResultService(crawlTaskRepo, crawlResultRepo, wsHub)
 
-	// 初始化配置和日志服务
-	configService, err := service.NewConfigService(configRepo, cfg.Crypto.MasterKey)
-	if err != nil {
-		panic("Failed to initialize config service: " + err.Error())
-	}
-	logService := service.NewLogService(logRepo)
+	// 初始化订阅源服务
+	feedService := service.NewFeedService(articleRepo, categoryRepo, tagRepo, configService, cfg.Site)
 
 	// 初始化备份服务
 	backupService := service.NewBackupService(cfg)
@@ -99,20 +177,41 @@ func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
 
 	// 初始化Handler
 	authHandler := handler.NewAuthHandler(authService)
+	adminUserHandler := handler.NewAdminUserHandler(adminUserService)
+	loginLockHandler := handler.NewLoginLockHandler(loginGuard)
 	categoryHandler := handler.NewCategoryHandler(categoryService)
 	tagHandler := handler.NewTagHandler(tagService)
 	articleHandler := handler.NewArticleHandler(articleService)
-	uploadHandler := handler.NewUploadHandler("uploads", 10) // 10MB max size
+	articleRevisionHandler := handler.NewArticleRevisionHandler(articleRevisionService, articleService)
+	commentHandler := handler.NewCommentHandler(commentService)
+	feedHandler := handler.NewFeedHandler(feedService)
+	sitemapHandler := handler.NewSitemapHandler(sitemapService)
+	uploadHandler := handler.NewUploadHandler(
+		uploadStorage,
+		mediaService,
+		filetype.NewPolicy(cfg.Upload.MaxSize, cfg.Upload.AllowedTypes),
+		filetype.NewPolicy(cfg.Upload.Attachment.MaxSize, cfg.Upload.Attachment.AllowedTypes),
+		cfg.Upload.VariantWidths,
+		cfg.Upload.KeepCopyright,
+	)
+	mediaHandler := handler.NewMediaHandler(mediaService)
+	imageHandler := handler.NewImageHandler(imageService)
 	statsHandler := handler.NewStatsHandler(statsService)
+	visitRollupHandler := handler.NewVisitRollupHandler(visitRollupService)
+	clientStatsHandler := handler.NewClientStatsHandler(clientStatsService)
+	geoStatsHandler := handler.NewGeoStatsHandler(geoService)
 	fingerprintHandler := handler.NewFingerprintHandler(fingerprintService)
 	visitHandler := handler.NewVisitHandler(visitService)
 	crawlerHandler := handler.NewCrawlerHandler(crawlService)
+	crawlResultHandler := handler.NewCrawlResultHandler(crawlService, crawlResultService)
+	crawlImportHandler := handler.NewCrawlImportHandler(crawlService, crawlImportService)
+	crawlJobHandler := handler.NewCrawlJobHandler(crawlJobService)
 	configHandler := handler.NewConfigHandler(configService)
 	logHandler := handler.NewLogHandler(logService)
 	backupHandler := handler.NewBackupHandler(backupService)
 
 	// 初始化WebSocket Handler
-	wsHandler := handler.NewWebSocketHandler(wsHub, jwtManager)
+	wsHandler := handler.NewWebSocketHandler(wsHub, jwtManager, crawlService)
 
 	// API路由组
 	api := r.Group("/api/v1")
@@ -130,6 +229,7 @@ func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/2fa", authHandler.LoginTwoFactor)
 			auth.POST("/refresh", authHandler.RefreshToken)
 		}
 
@@ -139,6 +239,12 @@ func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
 		{
 			authProtected.GET("/verify", authHandler.VerifyToken)
 			authProtected.PUT("/password", authHandler.ChangePassword)
+			authProtected.POST("/logout", authHandler.Logout)
+			authProtected.POST("/logout-all", authHandler.LogoutAll)
+			authProtected.POST("/2fa/setup", authHandler.SetupTwoFactor)
+			authProtected.POST("/2fa/enable", authHandler.EnableTwoFactor)
+			authProtected.POST("/2fa/disable", authHandler.DisableTwoFactor)
+			authProtected.POST("/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
 		}
 
 		// 公开接口 - 分类
@@ -157,9 +263,15 @@ func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
 		api.GET("/articles/slug/:slug", articleHandler.GetBySlug)
 		api.GET("/articles/search", articleHandler.Search)
 
+		// 公开接口 - 评论
+		api.GET("/articles/:id/comments", commentHandler.ListByArticle)
+		api.POST("/articles/:id/comments", commentHandler.Create)
+
 		// 公开接口 - 指纹和访问统计
 		api.POST("/fingerprint", fingerprintHandler.CollectFingerprint)
 		api.POST("/visit", visitHandler.RecordVisit)
+		api.PUT("/visit/:id/beacon", visitHandler.Beacon)
+		api.POST("/visit/:id/beacon", visitHandler.Beacon) // navigator.sendBeacon 只能发送POST
 
 		// 公开接口 - 站点配置
 		api.GET("/site/config", configHandler.GetPublicSiteConfig)
@@ -169,15 +281,30 @@ func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
 		crawler.Use(middleware.CrawlerAuth())
 		{
 			crawler.POST("/tasks", crawlerHandler.RegisterTask)
+			crawler.POST("/tasks/claim", crawlerHandler.ClaimTask)
+			crawler.PUT("/tasks/:id/heartbeat", crawlerHandler.Heartbeat)
 			crawler.PUT("/tasks/:id", crawlerHandler.UpdateTaskStatus)
 			crawler.PUT("/tasks/:id/complete", crawlerHandler.CompleteTask)
 			crawler.PUT("/tasks/:id/fail", crawlerHandler.FailTask)
+			crawler.PUT("/tasks/:id/ack", crawlerHandler.AcknowledgeCommand)
+			crawler.POST("/tasks/:id/results", crawlResultHandler.SubmitResults)
 		}
 
-		// 管理接口（需要认证）
+		// 管理接口（需要认证，并按角色校验权限）
 		admin := api.Group("/admin")
-		admin.Use(middleware.Auth(jwtManager))
+		admin.Use(middleware.Auth(jwtManager), middleware.Permission())
 		{
+			// 账号管理
+			admin.GET("/users", adminUserHandler.List)
+			admin.POST("/users", adminUserHandler.Create)
+			admin.PUT("/users/:id", adminUserHandler.Update)
+			admin.PUT("/users/:id/password", adminUserHandler.ResetPassword)
+			admin.DELETE("/users/:id", adminUserHandler.Delete)
+
+			// 登录锁定管理
+			admin.GET("/login-locks", loginLockHandler.List)
+			admin.POST("/login-locks/unlock", loginLockHandler.Unlock)
+
 			// 分类管理
 			admin.POST("/categories", categoryHandler.Create)
 			admin.PUT("/categories/:id", categoryHandler.Update)
@@ -195,16 +322,46 @@ func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
 			admin.DELETE("/articles/:id", articleHandler.Delete)
 			admin.POST("/articles/:id/publish", articleHandler.Publish)
 			admin.POST("/articles/:id/unpublish", articleHandler.Unpublish)
+			admin.POST("/articles/search-index/rebuild", articleHandler.RebuildSearchIndex)
+
+			// 文章修订
+			admin.GET("/articles/:id/revisions", articleRevisionHandler.List)
+			admin.GET("/articles/:id/revisions/diff", articleRevisionHandler.Diff)
+			admin.GET("/articles/:id/revisions/:revision_id", articleRevisionHandler.GetByID)
+			admin.POST("/articles/:id/revisions/:revision_id/restore", articleRevisionHandler.Restore)
+
+			// 评论审核
+			admin.GET("/comments", commentHandler.List)
+			admin.PUT("/comments/batch-status", commentHandler.BatchUpdateStatus)
+			admin.PUT("/comments/:id/status", commentHandler.UpdateStatus)
+			admin.DELETE("/comments/:id", commentHandler.Delete)
 
 			// 文件上传
 			admin.POST("/upload/image", uploadHandler.UploadImage)
 			admin.POST("/upload/article-image", uploadHandler.UploadArticleImage)
+			admin.POST("/upload/attachment", uploadHandler.UploadAttachment)
+
+			// 媒体库
+			admin.GET("/media", mediaHandler.List)
+			admin.GET("/media/orphans", mediaHandler.Orphans)
+			admin.POST("/media/orphans/cleanup", mediaHandler.CleanupOrphans)
+			admin.POST("/media/scan", mediaHandler.Scan)
+			admin.GET("/media/:id", mediaHandler.Get)
+			admin.DELETE("/media/:id", mediaHandler.Delete)
 
 			// 统计数据
 			admin.GET("/stats/dashboard", statsHandler.GetDashboardStats)
 			admin.GET("/stats/visits", statsHandler.GetVisitStats)
 			admin.GET("/stats/popular-articles", statsHandler.GetPopularArticles)
 			admin.GET("/stats/referrers", statsHandler.GetReferrerStats)
+			admin.GET("/stats/visit-ingest", statsHandler.GetVisitIngestStats)
+			admin.GET("/stats/sessions", statsHandler.GetSessionStats)
+			admin.GET("/stats/clients", clientStatsHandler.GetClientStats)
+			admin.GET("/stats/clients/:dimension", clientStatsHandler.GetBreakdown)
+			admin.GET("/stats/geo", geoStatsHandler.GetGeoStats)
+			admin.GET("/stats/rollups", visitRollupHandler.GetStatus)
+			admin.POST("/stats/rollups/backfill", visitRollupHandler.Backfill)
+			admin.POST("/stats/rollups/reaggregate", visitRollupHandler.Reaggregate)
 
 			// 指纹管理
 			admin.GET("/fingerprints", fingerprintHandler.ListFingerprints)
@@ -215,6 +372,18 @@ func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
 			// 爬虫任务管理
 			admin.GET("/crawler/tasks", crawlerHandler.ListTasks)
 			admin.GET("/crawler/tasks/:task_id", crawlerHandler.GetTaskByID)
+			admin.POST("/crawler/tasks/:task_id/command", crawlerHandler.SendCommand)
+			admin.PUT("/crawler/tasks/:task_id/import-mapping", crawlImportHandler.UpdateMapping)
+			admin.DELETE("/crawler/tasks/:task_id/import-mapping", crawlImportHandler.DeleteMapping)
+			admin.POST("/crawler/tasks/:task_id/import", crawlImportHandler.StartImport)
+			admin.GET("/crawler/jobs", crawlJobHandler.List)
+			admin.POST("/crawler/jobs", crawlJobHandler.Create)
+			admin.GET("/crawler/jobs/:id", crawlJobHandler.GetByID)
+			admin.PUT("/crawler/jobs/:id", crawlJobHandler.Update)
+			admin.DELETE("/crawler/jobs/:id", crawlJobHandler.Delete)
+			admin.POST("/crawler/jobs/:id/run", crawlJobHandler.Run)
+			admin.GET("/crawler/results", crawlResultHandler.ListResults)
+			admin.GET("/crawler/results/export", crawlResultHandler.ExportResults)
 
 			// 配置管理
 			admin.GET("/configs", configHandler.GetConfigs)
@@ -240,14 +409,45 @@ func Setup(cfg *config.Config) (*gin.Engine, *scheduler.Manager) {
 
 	// WebSocket路由
 	r.GET("/ws/crawler/tasks", wsHandler.HandleCrawlerTasks)
+	r.GET("/ws/crawler/control", middleware.CrawlerAuth(), wsHandler.HandleCrawlerControl)
+
+	// 订阅源（RSS 2.0 / Atom 1.0）
+	r.GET("/feed.xml", feedHandler.RSS)
+	r.GET("/atom.xml", feedHandler.Atom)
+	r.GET("/categories/:slug/feed.xml", feedHandler.CategoryRSS)
+	r.GET("/categories/:slug/atom.xml", feedHandler.CategoryAtom)
+	r.GET("/tags/:slug/feed.xml", feedHandler.TagRSS)
+	r.GET("/tags/:slug/atom.xml", feedHandler.TagAtom)
+
+	// Sitemap 与 robots.txt
+	r.GET("/sitemap.xml", sitemapHandler.Sitemap)
+	r.GET("/sitemaps/:file", sitemapHandler.Part)
+	r.GET("/robots.txt", sitemapHandler.Robots)
+
+	// 上传的文件：本地存储直接提供静态文件服务，对象存储由服务端代理读取
+	if local, ok := uploadStorage.(*storage.Local); ok {
+		uploads := r.Group(storage.URLPrefix, middleware.NoSniff())
+		uploads.Static("/", local.Root())
+	} else {
+		r.GET(storage.URLPrefix+"/*filepath", uploadHandler.ServeFile)
+		r.HEAD(storage.URLPrefix+"/*filepath", uploadHandler.ServeFile)
+	}
 
-	// 静态文件服务 - 上传的文件
-	r.Static("/uploads", "./uploads")
-
-	// 创建调度器管理器（日志保留90天，备份每天凌晨3点，保留10个备份）
-	backupSchedule := "0 0 3 * * *" // 每天凌晨3点
-	backupRetentionCount := 10      // 保留10个备份
-	schedulerManager := scheduler.NewManager(articleService, logService, backupService, 90, backupSchedule, backupRetentionCount)
-
-	return r, schedulerManager
+	// 图片缩放：/img/{宽度}/{文件路径}
+	r.GET(service.ImageURLPrefix+"/:w/*path", imageHandler.Resize)
+
+	// 创建调度器管理器（执行时间、保留数量等读取配置文件）
+	schedulerManager := scheduler.NewManager(scheduler.Services{
+		Article:     articleService,
+		Log:         logService,
+		Backup:      backupService,
+		Sitemap:     sitemapService,
+		Media:       mediaService,
+		CrawlJob:    crawlJobService,
+		Crawl:       crawlService,
+		VisitRollup: visitRollupService,
+		ClientStats: clientStatsService,
+	}, cfg)
+
+	return r, schedulerManager, visitBuffer
 }
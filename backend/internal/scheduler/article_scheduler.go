@@ -34,6 +34,12 @@ func (s *ArticleScheduler) Start() error {
 		return err
 	}
 
+	// 清洗升级前保存的文章正文，再补全尚未分词的文章（如升级后的历史文章）
+	go func() {
+		s.renderLegacyContent()
+		s.rebuildMissingSearchIndex()
+	}()
+
 	// 启动调度器
 	s.cron.Start()
 	logger.Info("Article scheduler started")
@@ -77,3 +83,27 @@ func (s *ArticleScheduler) RemoveJob(id cron.EntryID) {
 func (s *ArticleScheduler) GetEntries() []cron.Entry {
 	return s.cron.Entries()
 }
+
+// renderLegacyContent 按白名单重新清洗升级前保存的文章正文并生成目录
+func (s *ArticleScheduler) renderLegacyContent() {
+	count, err := s.articleService.RenderLegacyContent()
+	if err != nil {
+		logger.Error("Failed to render legacy article content: %v", err)
+		return
+	}
+	if count > 0 {
+		logger.Warn("Re-sanitized content of %d articles saved before the content format upgrade", count)
+	}
+}
+
+// rebuildMissingSearchIndex 补全尚未分词的文章
+func (s *ArticleScheduler) rebuildMissingSearchIndex() {
+	count, err := s.articleService.RebuildSearchIndex(false)
+	if err != nil {
+		logger.Error("Failed to rebuild search index: %v", err)
+		return
+	}
+	if count > 0 {
+		logger.Info("Search index rebuilt for %d articles", count)
+	}
+}
@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/logger"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// CrawlScheduler 爬虫作业调度器：按作业的cron表达式生成待执行任务，回收失联工作节点的任务，并将心跳超时的任务标记为失败
+type CrawlScheduler struct {
+	cron            *cron.Cron
+	crawlJobService service.CrawlJobService
+	crawlService    service.CrawlService
+	leaseSchedule   string
+	staleSchedule   string
+	staleTimeout    time.Duration // 任务未指定心跳超时时使用
+
+	mu      sync.Mutex
+	entries map[uint]cron.EntryID // 作业ID -> cron条目
+}
+
+// NewCrawlScheduler 创建爬虫作业调度器
+func NewCrawlScheduler(crawlJobService service.CrawlJobService, crawlService service.CrawlService, staleTimeout time.Duration) *CrawlScheduler {
+	// 创建带秒级精度的cron调度器
+	c := cron.New(cron.WithSeconds())
+
+	// 默认10分钟没有心跳视为失联
+	if staleTimeout <= 0 {
+		staleTimeout = 10 * time.Minute
+	}
+
+	return &CrawlScheduler{
+		cron:            c,
+		crawlJobService: crawlJobService,
+		crawlService:    crawlService,
+		leaseSchedule:   "*/30 * * * * *", // 每30秒检查一次过期租约
+		staleSchedule:   "15 * * * * *",   // 每分钟检查一次心跳超时
+		staleTimeout:    staleTimeout,
+		entries:         make(map[uint]cron.EntryID),
+	}
+}
+
+// Start 启动调度器
+func (s *CrawlScheduler) Start() error {
+	_, err := s.cron.AddFunc(s.leaseSchedule, s.requeueExpired)
+	if err != nil {
+		logger.Error("Failed to add crawl lease job: %v", err)
+		return err
+	}
+
+	_, err = s.cron.AddFunc(s.staleSchedule, s.failStale)
+	if err != nil {
+		logger.Error("Failed to add crawl stale task job: %v", err)
+		return err
+	}
+
+	if err := s.Reload(); err != nil {
+		logger.Error("Failed to load crawl jobs: %v", err)
+		return err
+	}
+
+	// 作业定义变更后重新加载
+	s.crawlJobService.SetChangeListener(func() {
+		if err := s.Reload(); err != nil {
+			logger.Error("Failed to reload crawl jobs: %v", err)
+		}
+	})
+
+	// 启动调度器
+	s.cron.Start()
+	logger.Info("Crawl scheduler started (%d jobs, stale timeout: %s)", len(s.entries), s.staleTimeout)
+
+	return nil
+}
+
+// Stop 停止调度器
+func (s *CrawlScheduler) Stop() {
+	if s.cron != nil {
+		s.crawlJobService.SetChangeListener(nil)
+		ctx := s.cron.Stop()
+		<-ctx.Done()
+		logger.Info("Crawl scheduler stopped")
+	}
+}
+
+// Reload 按当前启用的作业重建cron条目
+func (s *CrawlScheduler) Reload() error {
+	jobs, err := s.crawlJobService.ListEnabled()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for jobID, entryID := range s.entries {
+		s.cron.Remove(entryID)
+		delete(s.entries, jobID)
+	}
+
+	for _, job := range jobs {
+		jobID := job.ID
+		entryID, err := s.cron.AddFunc(job.CronSpec, func() { s.runJob(jobID) })
+		if err != nil {
+			// 保存时已校验，这里只会是历史数据的问题，跳过该作业
+			logger.Warn("Skip crawl job %d (%s): invalid cron spec %q: %v", job.ID, job.Name, job.CronSpec, err)
+			continue
+		}
+		s.entries[jobID] = entryID
+	}
+
+	return nil
+}
+
+// runJob 运行作业
+func (s *CrawlScheduler) runJob(jobID uint) {
+	task, err := s.crawlJobService.RunJob(jobID, false)
+	if err != nil {
+		if errors.Is(err, service.ErrCrawlJobOverlap) {
+			logger.Info("Crawl job %d skipped: previous task is still active", jobID)
+			return
+		}
+		logger.Error("Failed to run crawl job %d: %v", jobID, err)
+		return
+	}
+	logger.Info("Crawl job %d queued task %s", jobID, task.TaskID)
+}
+
+// requeueExpired 回收租约过期的任务
+func (s *CrawlScheduler) requeueExpired() {
+	count, err := s.crawlService.RequeueExpiredTasks()
+	if err != nil {
+		logger.Error("Failed to requeue expired crawl tasks: %v", err)
+		return
+	}
+	if count > 0 {
+		// WARN级别会写入系统日志，便于在后台查看
+		logger.Warn("Released %d crawl tasks whose workers stopped sending heartbeats", count)
+	}
+}
+
+// failStale 将心跳超时的任务标记为失败
+func (s *CrawlScheduler) failStale() {
+	tasks, err := s.crawlService.FailStaleTasks(s.staleTimeout)
+	if err != nil {
+		logger.Error("Failed to check stale crawl tasks: %v", err)
+	}
+	for _, task := range tasks {
+		logger.WithFields(map[string]interface{}{
+			"source":  "crawler",
+			"task_id": task.TaskID,
+		}).Warnf("Crawl task %s (%s) marked as %s: no heartbeat since %s",
+			task.TaskID, task.TaskName, task.Status, lastHeartbeat(&task).Format("2006-01-02 15:04:05"))
+	}
+}
+
+// lastHeartbeat 任务最近一次心跳时间（没有心跳时为注册时间）
+func lastHeartbeat(task *models.CrawlTask) time.Time {
+	if task.HeartbeatAt != nil {
+		return *task.HeartbeatAt
+	}
+	return task.StartTime
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/whk-newbie/blog/internal/pkg/logger"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// MediaScheduler 媒体引用扫描与未引用文件清理调度器
+type MediaScheduler struct {
+	cron          *cron.Cron
+	mediaService  service.MediaService
+	schedule      string
+	removeOrphans bool          // true 删除未引用文件，false 只报告
+	orphanMinAge  time.Duration // 上传超过该时长才视为未引用文件
+}
+
+// NewMediaScheduler 创建媒体调度器
+func NewMediaScheduler(mediaService service.MediaService, schedule string, removeOrphans bool, orphanMinAge time.Duration) *MediaScheduler {
+	// 创建带秒级精度的cron调度器
+	c := cron.New(cron.WithSeconds())
+
+	// 默认每天凌晨4点执行
+	if schedule == "" {
+		schedule = "0 0 4 * * *"
+	}
+	// 默认至少上传7天
+	if orphanMinAge <= 0 {
+		orphanMinAge = 7 * 24 * time.Hour
+	}
+
+	return &MediaScheduler{
+		cron:          c,
+		mediaService:  mediaService,
+		schedule:      schedule,
+		removeOrphans: removeOrphans,
+		orphanMinAge:  orphanMinAge,
+	}
+}
+
+// Start 启动调度器
+func (s *MediaScheduler) Start() error {
+	_, err := s.cron.AddFunc(s.schedule, s.checkOrphans)
+	if err != nil {
+		logger.Error("Failed to add media job: %v", err)
+		return err
+	}
+
+	// 启动调度器
+	s.cron.Start()
+	logger.Info("Media scheduler started (schedule: %s, remove orphans: %v)", s.schedule, s.removeOrphans)
+
+	return nil
+}
+
+// Stop 停止调度器
+func (s *MediaScheduler) Stop() {
+	if s.cron != nil {
+		ctx := s.cron.Stop()
+		<-ctx.Done()
+		logger.Info("Media scheduler stopped")
+	}
+}
+
+// checkOrphans 扫描引用后报告或删除未引用文件
+func (s *MediaScheduler) checkOrphans() {
+	scan, err := s.mediaService.ScanReferences()
+	if err != nil {
+		logger.Error("Failed to scan media references: %v", err)
+		return
+	}
+	logger.Info("Media references scanned: %d articles, %d media, %d references, %d unused",
+		scan.Articles, scan.Media, scan.References, scan.Unused)
+
+	if s.removeOrphans {
+		result, err := s.mediaService.RemoveOrphans(s.orphanMinAge)
+		if err != nil {
+			logger.Error("Failed to remove orphan media: %v", err)
+			return
+		}
+		if result.Removed > 0 || result.Failed > 0 {
+			logger.Info("Orphan media removed: %d files, %d bytes freed, %d failed",
+				result.Removed, result.FreedBytes, result.Failed)
+		}
+		return
+	}
+
+	report, err := s.mediaService.FindOrphans(s.orphanMinAge)
+	if err != nil {
+		logger.Error("Failed to find orphan media: %v", err)
+		return
+	}
+	if report.Count > 0 {
+		logger.Warn("Found %d orphan media files (%d bytes) not referenced by any article for over %s",
+			report.Count, report.TotalSize, s.orphanMinAge)
+	}
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"github.com/whk-newbie/blog/internal/config"
 	"github.com/whk-newbie/blog/internal/service"
 )
 
@@ -9,14 +10,36 @@ type Manager struct {
 	articleScheduler *ArticleScheduler
 	logScheduler     *LogScheduler
 	backupScheduler  *BackupScheduler
+	sitemapScheduler *SitemapScheduler
+	mediaScheduler   *MediaScheduler
+	crawlScheduler   *CrawlScheduler
+	visitScheduler   *VisitStatsScheduler
 }
 
-// NewManager 创建调度器管理器
-func NewManager(articleService service.ArticleService, logService service.LogService, backupService service.BackupService, logRetentionDays int, backupSchedule string, backupRetentionCount int) *Manager {
+// Services 调度器依赖的服务
+type Services struct {
+	Article     service.ArticleService
+	Log         service.LogService
+	Backup      service.BackupService
+	Sitemap     service.SitemapService
+	Media       service.MediaService
+	CrawlJob    service.CrawlJobService
+	Crawl       service.CrawlService
+	VisitRollup service.VisitRollupService
+	ClientStats service.ClientStatsService
+}
+
+// NewManager 创建调度器管理器（执行时间等设置读取配置文件的scheduler部分）
+func NewManager(services Services, cfg *config.Config) *Manager {
+	sc := cfg.Scheduler
 	return &Manager{
-		articleScheduler: NewArticleScheduler(articleService),
-		logScheduler:     NewLogScheduler(logService, logRetentionDays),
-		backupScheduler:  NewBackupScheduler(backupService, backupSchedule, backupRetentionCount),
+		articleScheduler: NewArticleScheduler(services.Article),
+		logScheduler:     NewLogScheduler(services.Log, sc.LogRetentionDays),
+		backupScheduler:  NewBackupScheduler(services.Backup, sc.BackupSchedule, sc.BackupRetentionCount),
+		sitemapScheduler: NewSitemapScheduler(services.Sitemap, sc.SitemapSchedule),
+		mediaScheduler:   NewMediaScheduler(services.Media, sc.MediaSchedule, sc.RemoveOrphanMedia, sc.OrphanMediaMinAge),
+		crawlScheduler:   NewCrawlScheduler(services.CrawlJob, services.Crawl, cfg.Crawler.StaleTimeout),
+		visitScheduler:   NewVisitStatsScheduler(services.VisitRollup, services.ClientStats, sc.VisitRollupSchedule),
 	}
 }
 
@@ -39,6 +62,34 @@ func (m *Manager) Start() error {
 		}
 	}
 
+	// 启动Sitemap调度器
+	if m.sitemapScheduler != nil {
+		if err := m.sitemapScheduler.Start(); err != nil {
+			return err
+		}
+	}
+
+	// 启动媒体调度器
+	if m.mediaScheduler != nil {
+		if err := m.mediaScheduler.Start(); err != nil {
+			return err
+		}
+	}
+
+	// 启动爬虫作业调度器
+	if m.crawlScheduler != nil {
+		if err := m.crawlScheduler.Start(); err != nil {
+			return err
+		}
+	}
+
+	// 启动访问统计调度器
+	if m.visitScheduler != nil {
+		if err := m.visitScheduler.Start(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -53,6 +104,18 @@ func (m *Manager) Stop() {
 	if m.backupScheduler != nil {
 		m.backupScheduler.Stop()
 	}
+	if m.sitemapScheduler != nil {
+		m.sitemapScheduler.Stop()
+	}
+	if m.mediaScheduler != nil {
+		m.mediaScheduler.Stop()
+	}
+	if m.crawlScheduler != nil {
+		m.crawlScheduler.Stop()
+	}
+	if m.visitScheduler != nil {
+		m.visitScheduler.Stop()
+	}
 }
 
 // GetArticleScheduler 获取文章调度器
@@ -69,3 +132,23 @@ func (m *Manager) GetLogScheduler() *LogScheduler {
 func (m *Manager) GetBackupScheduler() *BackupScheduler {
 	return m.backupScheduler
 }
+
+// GetSitemapScheduler 获取Sitemap调度器
+func (m *Manager) GetSitemapScheduler() *SitemapScheduler {
+	return m.sitemapScheduler
+}
+
+// GetMediaScheduler 获取媒体调度器
+func (m *Manager) GetMediaScheduler() *MediaScheduler {
+	return m.mediaScheduler
+}
+
+// GetCrawlScheduler 获取爬虫作业调度器
+func (m *Manager) GetCrawlScheduler() *CrawlScheduler {
+	return m.crawlScheduler
+}
+
+// GetVisitStatsScheduler 获取访问统计调度器
+func (m *Manager) GetVisitStatsScheduler() *VisitStatsScheduler {
+	return m.visitScheduler
+}
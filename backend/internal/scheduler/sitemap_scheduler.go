@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"github.com/robfig/cron/v3"
+	"github.com/whk-newbie/blog/internal/pkg/logger"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// SitemapScheduler Sitemap调度器
+type SitemapScheduler struct {
+	cron           *cron.Cron
+	sitemapService service.SitemapService
+	schedule       string
+}
+
+// NewSitemapScheduler 创建Sitemap调度器
+func NewSitemapScheduler(sitemapService service.SitemapService, schedule string) *SitemapScheduler {
+	// 创建带秒级精度的cron调度器
+	c := cron.New(cron.WithSeconds())
+
+	// 默认每小时整点重建一次（兜底分类/标签变化及定时发布）
+	if schedule == "" {
+		schedule = "0 0 * * * *"
+	}
+
+	return &SitemapScheduler{
+		cron:           c,
+		sitemapService: sitemapService,
+		schedule:       schedule,
+	}
+}
+
+// Start 启动调度器
+func (s *SitemapScheduler) Start() error {
+	_, err := s.cron.AddFunc(s.schedule, s.rebuildSitemap)
+	if err != nil {
+		logger.Error("Failed to add sitemap job: %v", err)
+		return err
+	}
+
+	// 启动时先生成一次
+	s.sitemapService.RequestRebuild()
+
+	// 启动调度器
+	s.cron.Start()
+	logger.Info("Sitemap scheduler started (schedule: %s)", s.schedule)
+
+	return nil
+}
+
+// Stop 停止调度器
+func (s *SitemapScheduler) Stop() {
+	if s.cron != nil {
+		ctx := s.cron.Stop()
+		<-ctx.Done()
+		logger.Info("Sitemap scheduler stopped")
+	}
+}
+
+// rebuildSitemap 重建Sitemap
+func (s *SitemapScheduler) rebuildSitemap() {
+	if err := s.sitemapService.Rebuild(); err != nil {
+		logger.Error("Failed to rebuild sitemap: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"errors"
+
+	"github.com/robfig/cron/v3"
+	"github.com/whk-newbie/blog/internal/pkg/logger"
+	"github.com/whk-newbie/blog/internal/service"
+)
+
+// VisitStatsScheduler 访问统计调度器：每晚将已结束日期的访问记录汇总到日汇总表，启动时补齐历史记录的User-Agent解析
+type VisitStatsScheduler struct {
+	cron               *cron.Cron
+	rollupService      service.VisitRollupService
+	clientStatsService service.ClientStatsService
+	schedule           string
+}
+
+// NewVisitStatsScheduler 创建访问统计调度器
+func NewVisitStatsScheduler(rollupService service.VisitRollupService, clientStatsService service.ClientStatsService, schedule string) *VisitStatsScheduler {
+	// 创建带秒级精度的cron调度器
+	c := cron.New(cron.WithSeconds())
+
+	// 默认每天凌晨0点30分执行（留出时间让写入队列中前一天的访问记录落库）
+	if schedule == "" {
+		schedule = "0 30 0 * * *"
+	}
+
+	return &VisitStatsScheduler{
+		cron:               c,
+		rollupService:      rollupService,
+		clientStatsService: clientStatsService,
+		schedule:           schedule,
+	}
+}
+
+// Start 启动调度器
+func (s *VisitStatsScheduler) Start() error {
+	_, err := s.cron.AddFunc(s.schedule, s.rollup)
+	if err != nil {
+		logger.Error("Failed to add visit rollup job: %v", err)
+		return err
+	}
+
+	// 启动调度器
+	s.cron.Start()
+	logger.Info("Visit stats scheduler started (schedule: %s)", s.schedule)
+
+	// 升级前的记录没有解析User-Agent，在后台补齐，不阻塞启动
+	if s.clientStatsService != nil {
+		go s.backfillClientInfo()
+	}
+
+	return nil
+}
+
+// Stop 停止调度器
+func (s *VisitStatsScheduler) Stop() {
+	if s.cron != nil {
+		ctx := s.cron.Stop()
+		<-ctx.Done()
+		logger.Info("Visit stats scheduler stopped")
+	}
+}
+
+// rollup 汇总上次汇总之后到昨天的日期
+func (s *VisitStatsScheduler) rollup() {
+	days, err := s.rollupService.RollupClosedDays()
+	if err != nil {
+		if errors.Is(err, service.ErrRollupInProgress) {
+			logger.Info("Visit rollup skipped: another rollup is running")
+			return
+		}
+		logger.Warn("Failed to roll up daily visit stats after %d days: %v", days, err)
+		return
+	}
+	if days > 0 {
+		logger.Info("Daily visit stats rolled up: %d days", days)
+	}
+}
+
+// backfillClientInfo 补齐历史访问记录和指纹的User-Agent解析
+func (s *VisitStatsScheduler) backfillClientInfo() {
+	count, err := s.clientStatsService.BackfillClientInfo()
+	if err != nil {
+		logger.Error("Failed to backfill user agent info after %d rows: %v", count, err)
+		return
+	}
+	if count > 0 {
+		logger.Info("User agent info backfilled: %d rows", count)
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+var (
+	ErrAdminNotFound     = repository.ErrAdminNotFound
+	ErrAdminExists       = repository.ErrAdminExists
+	ErrInvalidAdminRole  = errors.New("invalid admin role")
+	ErrUsernameRequired  = errors.New("username is required")
+	ErrCannotDeleteSelf  = errors.New("cannot delete current account")
+	ErrLastOwnerRequired = errors.New("at least one owner is required")
+	ErrCannotDemoteSelf  = errors.New("cannot change role of current account")
+)
+
+// AdminUserService 后台账号管理服务接口
+type AdminUserService interface {
+	// 获取账号列表
+	List(page, pageSize int) (*AdminUserListResponse, error)
+	// 创建账号
+	Create(req *CreateAdminUserRequest) (*models.Admin, error)
+	// 更新账号（邮箱、角色）
+	Update(id, operatorID uint, req *UpdateAdminUserRequest) (*models.Admin, error)
+	// 重置账号密码
+	ResetPassword(id uint, password string) error
+	// 删除账号
+	Delete(id, operatorID uint) error
+}
+
+// CreateAdminUserRequest 创建账号请求
+type CreateAdminUserRequest struct {
+	Username string           `json:"username" binding:"required,max=50" example:"editor01"`
+	Password string           `json:"password" binding:"required,min=6" example:"password123"`
+	Email    string           `json:"email" binding:"omitempty,email,max=100" example:"editor@example.com"`
+	Role     models.AdminRole `json:"role" binding:"required" example:"editor"`
+}
+
+// UpdateAdminUserRequest 更新账号请求
+type UpdateAdminUserRequest struct {
+	Email *string           `json:"email" binding:"omitempty,max=100"`
+	Role  *models.AdminRole `json:"role"`
+}
+
+// ResetAdminPasswordRequest 重置密码请求
+type ResetAdminPasswordRequest struct {
+	Password string `json:"password" binding:"required,min=6" example:"password123"`
+}
+
+// AdminUserListResponse 账号列表响应
+type AdminUserListResponse struct {
+	Items      []*models.Admin `json:"items"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	TotalPages int             `json:"total_pages"`
+}
+
+// adminUserService 后台账号管理服务实现
+type adminUserService struct {
+	adminRepo      repository.AdminRepository
+	sessionService SessionService
+}
+
+// NewAdminUserService 创建后台账号管理服务
+func NewAdminUserService(adminRepo repository.AdminRepository, sessionService SessionService) AdminUserService {
+	return &adminUserService{
+		adminRepo:      adminRepo,
+		sessionService: sessionService,
+	}
+}
+
+// List 获取账号列表
+func (s *adminUserService) List(page, pageSize int) (*AdminUserListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	offset := (page - 1) * pageSize
+	admins, total, err := s.adminRepo.List(offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	return &AdminUserListResponse{
+		Items:      admins,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Create 创建账号
+func (s *adminUserService) Create(req *CreateAdminUserRequest) (*models.Admin, error) {
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return nil, ErrUsernameRequired
+	}
+	if !req.Role.IsValid() {
+		return nil, ErrInvalidAdminRole
+	}
+	if len(req.Password) < 6 {
+		return nil, ErrPasswordTooShort
+	}
+
+	hashedPassword, err := HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	admin := &models.Admin{
+		Username: username,
+		Password: hashedPassword,
+		Email:    strings.TrimSpace(req.Email),
+		Role:     req.Role,
+		// 由站长分配的初始密码，提示账号本人登录后修改
+		IsDefaultPassword: true,
+	}
+
+	if err := s.adminRepo.Create(admin); err != nil {
+		return nil, err
+	}
+
+	return admin, nil
+}
+
+// Update 更新账号（不能修改自己的角色，也不能降级最后一个站长）
+func (s *adminUserService) Update(id, operatorID uint, req *UpdateAdminUserRequest) (*models.Admin, error) {
+	admin, err := s.adminRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Email != nil {
+		admin.Email = strings.TrimSpace(*req.Email)
+	}
+
+	roleChanged := false
+	if req.Role != nil && *req.Role != admin.Role {
+		if !req.Role.IsValid() {
+			return nil, ErrInvalidAdminRole
+		}
+		if id == operatorID {
+			return nil, ErrCannotDemoteSelf
+		}
+		if admin.Role == models.AdminRoleOwner {
+			if err := s.ensureAnotherOwner(); err != nil {
+				return nil, err
+			}
+		}
+		admin.Role = *req.Role
+		roleChanged = true
+	}
+
+	if err := s.adminRepo.Update(admin); err != nil {
+		return nil, err
+	}
+
+	// 角色记录在访问Token中，变更后需要重新登录
+	if roleChanged {
+		if _, err := s.sessionService.RevokeAll(id, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return admin, nil
+}
+
+// ResetPassword 重置账号密码
+func (s *adminUserService) ResetPassword(id uint, password string) error {
+	if len(password) < 6 {
+		return ErrPasswordTooShort
+	}
+
+	if _, err := s.adminRepo.FindByID(id); err != nil {
+		return err
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// 与创建账号一致，由站长设置的密码需要在下次登录后修改
+	if err := s.adminRepo.ResetPassword(id, hashedPassword); err != nil {
+		return err
+	}
+
+	_, err = s.sessionService.RevokeAll(id, "")
+	return err
+}
+
+// Delete 删除账号（不能删除自己，也不能删除最后一个站长）
+func (s *adminUserService) Delete(id, operatorID uint) error {
+	if id == operatorID {
+		return ErrCannotDeleteSelf
+	}
+
+	admin, err := s.adminRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if admin.Role == models.AdminRoleOwner {
+		if err := s.ensureAnotherOwner(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.adminRepo.Delete(id); err != nil {
+		return err
+	}
+
+	_, err = s.sessionService.RevokeAll(id, "")
+	return err
+}
+
+// ensureAnotherOwner 确认除当前账号外还有其他站长
+func (s *adminUserService) ensureAnotherOwner() error {
+	count, err := s.adminRepo.CountByRole(models.AdminRoleOwner)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return ErrLastOwnerRequired
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -94,17 +95,18 @@ func (s *articleCacheService) GetCachedArticleList(key string) (*ArticleListResp
 
 // ClearArticleCache 清除所有文章缓存
 func (s *articleCacheService) ClearArticleCache() error {
-	// 使用通配符删除所有文章列表缓存
-	// 注意: Redis的DEL命令不支持通配符，需要先获取所有匹配的key
-	// 这里简化处理，实际可以使用Redis的SCAN命令
-	// 为了简化，我们使用一个标记来使缓存失效
-	// 或者使用Redis的SET来存储版本号，每次更新时递增版本号
-	return nil // 暂时返回nil，实际实现可以使用Redis的KEYS或SCAN命令
+	// Redis的DEL命令不支持通配符，使用SCAN遍历匹配的键后删除
+	return redis.DelByPattern("article_list:*")
 }
 
 // ClearArticleCacheByID 清除特定文章的缓存
 func (s *articleCacheService) ClearArticleCacheByID(articleID uint) error {
 	// 清除所有包含该文章的列表缓存
 	// 由于无法精确匹配，这里清除所有文章列表缓存
-	return s.ClearArticleCache()
+	listErr := s.ClearArticleCache()
+
+	// 订阅源由已发布文章列表生成，同步失效（列表缓存清除失败时也要执行）
+	feedErr := redis.DelByPattern(feedCacheKeyPrefix + "*")
+
+	return errors.Join(listErr, feedErr)
 }
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/diff"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+var (
+	ErrRevisionNotFound = repository.ErrRevisionNotFound
+)
+
+// ArticleRevisionService 文章修订服务接口
+type ArticleRevisionService interface {
+	// 获取文章的修订列表
+	List(articleID uint, page, pageSize int) (*ArticleRevisionListResponse, error)
+	// 获取修订详情
+	GetByID(articleID, revisionID uint) (*models.ArticleRevision, error)
+	// 比较两个修订
+	Diff(articleID, fromID, toID uint) (*ArticleRevisionDiffResponse, error)
+	// 将文章恢复到指定修订
+	Restore(articleID, revisionID uint) (*models.Article, error)
+}
+
+// ArticleRevisionListResponse 修订列表响应
+type ArticleRevisionListResponse struct {
+	Items      []models.ArticleRevision `json:"items"`
+	Total      int64                    `json:"total"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"page_size"`
+	TotalPages int                      `json:"total_pages"`
+}
+
+// RevisionFieldChange 字段变化
+type RevisionFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// ArticleRevisionDiffResponse 修订差异响应
+type ArticleRevisionDiffResponse struct {
+	From         *models.ArticleRevision `json:"from"`
+	To           *models.ArticleRevision `json:"to"`
+	Fields       []RevisionFieldChange   `json:"fields"`        // 除正文外发生变化的字段
+	ContentDiff  []diff.Line             `json:"content_diff"`  // 正文逐行差异
+	ContentStats diff.Stats              `json:"content_stats"` // 正文差异统计
+}
+
+// articleRevisionService 文章修订服务实现
+type articleRevisionService struct {
+	revisionRepo   repository.ArticleRevisionRepository
+	articleRepo    repository.ArticleRepository
+	articleService ArticleService
+}
+
+// NewArticleRevisionService 创建文章修订服务
+func NewArticleRevisionService(
+	revisionRepo repository.ArticleRevisionRepository,
+	articleRepo repository.ArticleRepository,
+	articleService ArticleService,
+) ArticleRevisionService {
+	return &articleRevisionService{
+		revisionRepo:   revisionRepo,
+		articleRepo:    articleRepo,
+		articleService: articleService,
+	}
+}
+
+// List 获取文章的修订列表
+func (s *articleRevisionService) List(articleID uint, page, pageSize int) (*ArticleRevisionListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	// 确认文章存在
+	if _, err := s.articleRepo.FindByID(articleID); err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * pageSize
+	revisions, total, err := s.revisionRepo.ListByArticleID(articleID, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	return &ArticleRevisionListResponse{
+		Items:      revisions,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetByID 获取修订详情（校验修订属于该文章）
+func (s *articleRevisionService) GetByID(articleID, revisionID uint) (*models.ArticleRevision, error) {
+	revision, err := s.revisionRepo.FindByID(revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if revision.ArticleID != articleID {
+		return nil, ErrRevisionNotFound
+	}
+	return revision, nil
+}
+
+// Diff 比较两个修订
+func (s *articleRevisionService) Diff(articleID, fromID, toID uint) (*ArticleRevisionDiffResponse, error) {
+	from, err := s.GetByID(articleID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetByID(articleID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []RevisionFieldChange
+	addChange := func(field string, a, b interface{}) {
+		fields = append(fields, RevisionFieldChange{Field: field, From: a, To: b})
+	}
+
+	if from.Title != to.Title {
+		addChange("title", from.Title, to.Title)
+	}
+	if from.Slug != to.Slug {
+		addChange("slug", from.Slug, to.Slug)
+	}
+	if from.Summary != to.Summary {
+		addChange("summary", from.Summary, to.Summary)
+	}
+	if from.CoverImage != to.CoverImage {
+		addChange("cover_image", from.CoverImage, to.CoverImage)
+	}
+	if !equalUintPtr(from.CategoryID, to.CategoryID) {
+		addChange("category_id", from.CategoryID, to.CategoryID)
+	}
+	fromTags, _ := decodeTagIDs(from.TagIDs)
+	toTags, _ := decodeTagIDs(to.TagIDs)
+	if !equalUintSet(fromTags, toTags) {
+		addChange("tag_ids", fromTags, toTags)
+	}
+	if from.Status != to.Status {
+		addChange("status", from.Status, to.Status)
+	}
+
+	if from.ContentFormat != to.ContentFormat {
+		addChange("content_format", from.ContentFormat, to.ContentFormat)
+	}
+
+	lines, stats := diff.Lines(revisionBody(from), revisionBody(to))
+
+	return &ArticleRevisionDiffResponse{
+		From:         from,
+		To:           to,
+		Fields:       fields,
+		ContentDiff:  lines,
+		ContentStats: stats,
+	}, nil
+}
+
+// Restore 将文章恢复到指定修订
+// 恢复内容字段（标题、Slug、摘要、正文、封面、分类、标签），保留当前的发布状态与置顶/推荐设置，
+// 恢复本身通过Update完成，因此会生成一条新的修订
+func (s *articleRevisionService) Restore(articleID, revisionID uint) (*models.Article, error) {
+	revision, err := s.GetByID(articleID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	article, err := s.articleRepo.FindByID(articleID)
+	if err != nil {
+		return nil, err
+	}
+
+	tagIDs, err := decodeTagIDs(revision.TagIDs)
+	if err != nil {
+		return nil, errors.New("invalid revision tag ids")
+	}
+
+	req := &UpdateArticleRequest{
+		Title:         revision.Title,
+		Slug:          revision.Slug,
+		Summary:       revision.Summary,
+		Content:       revision.Content,
+		ContentFormat: revision.ContentFormat,
+		ContentSource: revision.ContentSource,
+		CoverImage:    revision.CoverImage,
+		CategoryID:    revision.CategoryID,
+		TagIDs:        tagIDs,
+		Status:        article.Status,
+		PublishAt:     article.PublishAt,
+		IsTop:         article.IsTop,
+		IsFeatured:    article.IsFeatured,
+		RevisionNote:  fmt.Sprintf("恢复自版本 #%d", revision.Version),
+	}
+
+	return s.articleService.Update(articleID, req)
+}
+
+// revisionBody 用于比较的正文（Markdown比较原文，HTML比较渲染结果）
+func revisionBody(revision *models.ArticleRevision) string {
+	if revision.ContentFormat == models.ContentFormatMarkdown {
+		return revision.ContentSource
+	}
+	return revision.Content
+}
+
+// decodeTagIDs 解析修订中保存的标签ID列表
+func decodeTagIDs(data []byte) ([]uint, error) {
+	tagIDs := []uint{}
+	if len(data) == 0 {
+		return tagIDs, nil
+	}
+	if err := json.Unmarshal(data, &tagIDs); err != nil {
+		return nil, err
+	}
+	return tagIDs, nil
+}
+
+// equalUintPtr 比较两个可空ID
+func equalUintPtr(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// equalUintSet 比较两个ID集合（忽略顺序）
+func equalUintSet(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[uint]int, len(a))
+	for _, id := range a {
+		set[id]++
+	}
+	for _, id := range b {
+		if set[id] == 0 {
+			return false
+		}
+		set[id]--
+	}
+	return true
+}
@@ -1,18 +1,24 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"strings"
 	"time"
 
 	"github.com/gosimple/slug"
 	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/markup"
+	"github.com/whk-newbie/blog/internal/pkg/search"
 	"github.com/whk-newbie/blog/internal/repository"
 )
 
 var (
-	ErrArticleTitleRequired = errors.New("article title is required")
-	ErrArticleNotFound      = repository.ErrArticleNotFound
+	ErrArticleTitleRequired   = errors.New("article title is required")
+	ErrArticleContentRequired = errors.New("article content is required")
+	ErrInvalidContentFormat   = errors.New("invalid content format")
+	ErrArticleSourceExists    = errors.New("article source url already imported")
+	ErrArticleNotFound        = repository.ErrArticleNotFound
 )
 
 // ArticleService 文章服务接口
@@ -41,36 +47,54 @@ type ArticleService interface {
 	Search(keyword string, page, pageSize int) (*ArticleListResponse, error)
 	// 处理定时发布
 	ProcessScheduledPublish() error
+	// 重建全文检索分词（all为false时只处理尚未分词的文章），返回处理的文章数
+	RebuildSearchIndex(all bool) (int, error)
+	// 按白名单重新清洗正文格式上线之前保存的文章并生成目录，返回处理的文章数
+	RenderLegacyContent() (int, error)
 }
 
 // CreateArticleRequest 创建文章请求
 type CreateArticleRequest struct {
-	Title      string               `json:"title" binding:"required"`
-	Slug       string               `json:"slug"`
-	Summary    string               `json:"summary"`
-	Content    string               `json:"content" binding:"required"`
-	CoverImage string               `json:"cover_image"`
-	CategoryID *uint                `json:"category_id"`
-	TagIDs     []uint               `json:"tag_ids"`
-	Status     models.ArticleStatus `json:"status"`
-	PublishAt  *time.Time           `json:"publish_at"`
-	IsTop      bool                 `json:"is_top"`
-	IsFeatured bool                 `json:"is_featured"`
+	Title   string `json:"title" binding:"required"`
+	Slug    string `json:"slug"`
+	Summary string `json:"summary"`
+	Content string `json:"content"` // HTML正文（html格式时必填）
+	// 正文格式（html/markdown，默认html）
+	ContentFormat models.ContentFormat `json:"content_format"`
+	// Markdown原文（markdown格式时必填，服务端渲染为HTML）
+	ContentSource string               `json:"content_source"`
+	CoverImage    string               `json:"cover_image"`
+	CategoryID    *uint                `json:"category_id"`
+	TagIDs        []uint               `json:"tag_ids"`
+	Status        models.ArticleStatus `json:"status"`
+	PublishAt     *time.Time           `json:"publish_at"`
+	IsTop         bool                 `json:"is_top"`
+	IsFeatured    bool                 `json:"is_featured"`
+	// 来源URL（同一来源只能创建一篇文章）
+	SourceURL string `json:"source_url"`
+	// 导入来源的爬虫任务（仅由采集结果导入设置）
+	CrawlTaskID *uint `json:"-"`
 }
 
 // UpdateArticleRequest 更新文章请求
 type UpdateArticleRequest struct {
-	Title      string               `json:"title" binding:"required"`
-	Slug       string               `json:"slug"`
-	Summary    string               `json:"summary"`
-	Content    string               `json:"content" binding:"required"`
-	CoverImage string               `json:"cover_image"`
-	CategoryID *uint                `json:"category_id"`
-	TagIDs     []uint               `json:"tag_ids"`
-	Status     models.ArticleStatus `json:"status"`
-	PublishAt  *time.Time           `json:"publish_at"`
-	IsTop      bool                 `json:"is_top"`
-	IsFeatured bool                 `json:"is_featured"`
+	Title   string `json:"title" binding:"required"`
+	Slug    string `json:"slug"`
+	Summary string `json:"summary"`
+	Content string `json:"content"` // HTML正文（html格式时必填）
+	// 正文格式（html/markdown，默认html）
+	ContentFormat models.ContentFormat `json:"content_format"`
+	// Markdown原文（markdown格式时必填，服务端渲染为HTML）
+	ContentSource string               `json:"content_source"`
+	CoverImage    string               `json:"cover_image"`
+	CategoryID    *uint                `json:"category_id"`
+	TagIDs        []uint               `json:"tag_ids"`
+	Status        models.ArticleStatus `json:"status"`
+	PublishAt     *time.Time           `json:"publish_at"`
+	IsTop         bool                 `json:"is_top"`
+	IsFeatured    bool                 `json:"is_featured"`
+	// 修订备注（可选，记录到本次修订快照）
+	RevisionNote string `json:"revision_note"`
 }
 
 // ArticleListRequest 文章列表请求
@@ -100,6 +124,8 @@ type articleService struct {
 	categoryRepo    repository.CategoryRepository
 	tagRepo         repository.TagRepository
 	articleCacheSvc ArticleCacheService
+	sitemapSvc      SitemapService
+	tokenizer       search.Tokenizer
 }
 
 // NewArticleService 创建文章服务
@@ -108,12 +134,16 @@ func NewArticleService(
 	categoryRepo repository.CategoryRepository,
 	tagRepo repository.TagRepository,
 	articleCacheSvc ArticleCacheService,
+	sitemapSvc SitemapService,
+	tokenizer search.Tokenizer,
 ) ArticleService {
 	return &articleService{
 		articleRepo:     articleRepo,
 		categoryRepo:    categoryRepo,
 		tagRepo:         tagRepo,
 		articleCacheSvc: articleCacheSvc,
+		sitemapSvc:      sitemapSvc,
+		tokenizer:       tokenizer,
 	}
 }
 
@@ -132,6 +162,18 @@ func (s *articleService) Create(req *CreateArticleRequest, authorID uint) (*mode
 		articleSlug = slug.Make(articleSlug)
 	}
 
+	// 检查来源是否已导入过
+	sourceURL := strings.TrimSpace(req.SourceURL)
+	if sourceURL != "" {
+		exists, err := s.articleRepo.ExistsBySourceURL(sourceURL)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrArticleSourceExists
+		}
+	}
+
 	// 验证分类是否存在
 	if req.CategoryID != nil {
 		if _, err := s.categoryRepo.FindByID(*req.CategoryID); err != nil {
@@ -152,19 +194,27 @@ func (s *articleService) Create(req *CreateArticleRequest, authorID uint) (*mode
 
 	// 创建文章
 	article := &models.Article{
-		Title:      req.Title,
-		Slug:       articleSlug,
-		Summary:    req.Summary,
-		Content:    req.Content,
-		CoverImage: req.CoverImage,
-		CategoryID: req.CategoryID,
-		Status:     req.Status,
-		PublishAt:  req.PublishAt,
-		IsTop:      req.IsTop,
-		IsFeatured: req.IsFeatured,
-		AuthorID:   &authorID,
+		Title:       req.Title,
+		Slug:        articleSlug,
+		Summary:     req.Summary,
+		CoverImage:  req.CoverImage,
+		CategoryID:  req.CategoryID,
+		Status:      req.Status,
+		PublishAt:   req.PublishAt,
+		IsTop:       req.IsTop,
+		IsFeatured:  req.IsFeatured,
+		AuthorID:    &authorID,
+		SourceURL:   sourceURL,
+		CrawlTaskID: req.CrawlTaskID,
+	}
+
+	// 渲染正文
+	if err := s.renderContent(article, req.ContentFormat, req.Content, req.ContentSource); err != nil {
+		return nil, err
 	}
 
+	s.applySearchText(article)
+
 	// 如果状态为空，默认为草稿
 	if article.Status == "" {
 		article.Status = models.ArticleStatusDraft
@@ -176,15 +226,13 @@ func (s *articleService) Create(req *CreateArticleRequest, authorID uint) (*mode
 		article.PublishAt = &now
 	}
 
-	if err := s.articleRepo.Create(article); err != nil {
+	// 文章、标签关联和初始修订在同一事务中写入
+	revision, err := newRevision(article, req.TagIDs, models.RevisionActionCreate, "")
+	if err != nil {
 		return nil, err
 	}
-
-	// 更新标签关联
-	if len(req.TagIDs) > 0 {
-		if err := s.articleRepo.UpdateTags(article.ID, req.TagIDs); err != nil {
-			return nil, err
-		}
+	if err := s.articleRepo.SaveWithRevision(article, req.TagIDs, len(req.TagIDs) > 0, revision); err != nil {
+		return nil, err
 	}
 
 	// 如果已发布，更新分类和标签的文章数
@@ -202,6 +250,11 @@ func (s *articleService) Create(req *CreateArticleRequest, authorID uint) (*mode
 		_ = s.articleCacheSvc.ClearArticleCacheByID(article.ID)
 	}
 
+	// 已发布文章需要出现在Sitemap中
+	if article.Status == models.ArticleStatusPublished {
+		s.refreshSitemap()
+	}
+
 	// 重新加载文章（包含关联）
 	return s.articleRepo.FindByIDWithAssociations(article.ID)
 }
@@ -229,6 +282,11 @@ func (s *articleService) Update(id uint, req *UpdateArticleRequest) (*models.Art
 		return nil, err
 	}
 
+	// 渲染正文
+	if err := s.renderContent(article, req.ContentFormat, req.Content, req.ContentSource); err != nil {
+		return nil, err
+	}
+
 	oldStatus := article.Status
 	oldCategoryID := article.CategoryID
 
@@ -262,13 +320,13 @@ func (s *articleService) Update(id uint, req *UpdateArticleRequest) (*models.Art
 	article.Title = req.Title
 	article.Slug = articleSlug
 	article.Summary = req.Summary
-	article.Content = req.Content
 	article.CoverImage = req.CoverImage
 	article.CategoryID = req.CategoryID
 	article.Status = req.Status
 	article.PublishAt = req.PublishAt
 	article.IsTop = req.IsTop
 	article.IsFeatured = req.IsFeatured
+	s.applySearchText(article)
 
 	// 如果状态改为发布且没有发布时间，设置为当前时间
 	if article.Status == models.ArticleStatusPublished && article.PublishAt == nil {
@@ -276,10 +334,6 @@ func (s *articleService) Update(id uint, req *UpdateArticleRequest) (*models.Art
 		article.PublishAt = &now
 	}
 
-	if err := s.articleRepo.Update(article); err != nil {
-		return nil, err
-	}
-
 	// 获取旧的标签
 	oldArticle, _ := s.articleRepo.FindByIDWithAssociations(id)
 	var oldTagIDs []uint
@@ -289,8 +343,12 @@ func (s *articleService) Update(id uint, req *UpdateArticleRequest) (*models.Art
 		}
 	}
 
-	// 更新标签关联
-	if err := s.articleRepo.UpdateTags(article.ID, req.TagIDs); err != nil {
+	// 文章、标签关联和修订快照在同一事务中写入
+	revision, err := newRevision(article, req.TagIDs, models.RevisionActionUpdate, req.RevisionNote)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.articleRepo.SaveWithRevision(article, req.TagIDs, true, revision); err != nil {
 		return nil, err
 	}
 
@@ -351,6 +409,11 @@ func (s *articleService) Update(id uint, req *UpdateArticleRequest) (*models.Art
 		}
 	}
 
+	// 发布状态或Slug可能变化，刷新Sitemap
+	if oldStatus == models.ArticleStatusPublished || article.Status == models.ArticleStatusPublished {
+		s.refreshSitemap()
+	}
+
 	// 重新加载文章（包含关联）
 	return s.articleRepo.FindByIDWithAssociations(article.ID)
 }
@@ -383,6 +446,10 @@ func (s *articleService) Delete(id uint) error {
 		_ = s.articleCacheSvc.ClearArticleCacheByID(id)
 	}
 
+	if article.Status == models.ArticleStatusPublished {
+		s.refreshSitemap()
+	}
+
 	return nil
 }
 
@@ -412,8 +479,8 @@ func (s *articleService) List(req *ArticleListRequest) (*ArticleListResponse, er
 		Status:     req.Status,
 		IsTop:      req.IsTop,
 		IsFeatured: req.IsFeatured,
-		Keyword:    req.Keyword,
 	}
+	s.applyKeyword(filter, req.Keyword)
 
 	offset := (req.Page - 1) * req.PageSize
 	articles, total, err := s.articleRepo.List(filter, offset, req.PageSize)
@@ -467,8 +534,8 @@ func (s *articleService) ListPublished(req *ArticleListRequest) (*ArticleListRes
 		CategoryID: req.CategoryID,
 		TagID:      req.TagID,
 		IsFeatured: req.IsFeatured,
-		Keyword:    req.Keyword,
 	}
+	s.applyKeyword(filter, req.Keyword)
 
 	offset := (req.Page - 1) * req.PageSize
 	articles, total, err := s.articleRepo.ListPublished(filter, offset, req.PageSize)
@@ -516,7 +583,7 @@ func (s *articleService) Publish(id uint) error {
 		article.PublishAt = &now
 	}
 
-	if err := s.articleRepo.Update(article); err != nil {
+	if err := s.saveWithRevision(article, models.RevisionActionPublish); err != nil {
 		return err
 	}
 
@@ -530,6 +597,12 @@ func (s *articleService) Publish(id uint) error {
 		}
 	}
 
+	// 清除文章缓存
+	if s.articleCacheSvc != nil {
+		_ = s.articleCacheSvc.ClearArticleCacheByID(article.ID)
+	}
+
+	s.refreshSitemap()
 	return nil
 }
 
@@ -546,7 +619,7 @@ func (s *articleService) Unpublish(id uint) error {
 
 	article.Status = models.ArticleStatusDraft
 
-	if err := s.articleRepo.Update(article); err != nil {
+	if err := s.saveWithRevision(article, models.RevisionActionUnpublish); err != nil {
 		return err
 	}
 
@@ -558,6 +631,12 @@ func (s *articleService) Unpublish(id uint) error {
 		s.tagRepo.DecrementArticleCount(tag.ID)
 	}
 
+	// 清除文章缓存
+	if s.articleCacheSvc != nil {
+		_ = s.articleCacheSvc.ClearArticleCacheByID(article.ID)
+	}
+
+	s.refreshSitemap()
 	return nil
 }
 
@@ -578,11 +657,20 @@ func (s *articleService) Search(keyword string, page, pageSize int) (*ArticleLis
 		pageSize = 100
 	}
 
+	// 关键词中没有可检索的词元时直接返回空结果
+	tsQuery := s.tokenizer.Query(keyword)
+	if tsQuery == "" {
+		return &ArticleListResponse{Items: []models.Article{}, Page: page, PageSize: pageSize}, nil
+	}
+
 	offset := (page - 1) * pageSize
-	articles, total, err := s.articleRepo.Search(keyword, offset, pageSize)
+	articles, total, err := s.articleRepo.Search(tsQuery, offset, pageSize)
 	if err != nil {
 		return nil, err
 	}
+	for i := range articles {
+		articles[i].Highlight = s.tokenizer.Highlight(articles[i].Highlight)
+	}
 
 	totalPages := int(total) / pageSize
 	if int(total)%pageSize > 0 {
@@ -614,3 +702,175 @@ func (s *articleService) ProcessScheduledPublish() error {
 
 	return nil
 }
+
+// RebuildSearchIndex 重建全文检索分词（更换分词器后需要全量重建）
+func (s *articleService) RebuildSearchIndex(all bool) (int, error) {
+	const batchSize = 100
+
+	count := 0
+	var afterID uint
+	for {
+		articles, err := s.articleRepo.ListForSearchIndex(afterID, batchSize, !all)
+		if err != nil {
+			return count, err
+		}
+		if len(articles) == 0 {
+			return count, nil
+		}
+
+		for i := range articles {
+			article := &articles[i]
+			s.applySearchText(article)
+			if err := s.articleRepo.UpdateSearchText(article.ID, article.SearchTitle, article.SearchSummary, article.SearchContent); err != nil {
+				return count, err
+			}
+			afterID = article.ID
+			count++
+		}
+	}
+}
+
+// RenderLegacyContent 重新渲染尚未生成目录的文章
+// 正文清洗上线之前保存的HTML没有经过白名单过滤，可能包含脚本，需要统一清洗一次
+func (s *articleService) RenderLegacyContent() (int, error) {
+	const batchSize = 100
+
+	count := 0
+	var afterID uint
+	for {
+		articles, err := s.articleRepo.ListUnrendered(afterID, batchSize)
+		if err != nil {
+			return count, err
+		}
+		if len(articles) == 0 {
+			return count, nil
+		}
+
+		for i := range articles {
+			article := &articles[i]
+			afterID = article.ID
+
+			source := article.Content
+			if article.ContentFormat == models.ContentFormatMarkdown && article.ContentSource != "" {
+				source = article.ContentSource
+			}
+			result, err := markup.Render(string(article.ContentFormat), source)
+			if err != nil {
+				return count, err
+			}
+			toc, err := json.Marshal(result.TOC)
+			if err != nil {
+				return count, err
+			}
+
+			searchContent := s.tokenizer.Tokenize(markup.PlainText(result.HTML))
+			if err := s.articleRepo.UpdateRenderedContent(article.ID, result.HTML, toc, searchContent); err != nil {
+				return count, err
+			}
+			if s.articleCacheSvc != nil {
+				_ = s.articleCacheSvc.ClearArticleCacheByID(article.ID)
+			}
+			count++
+		}
+	}
+}
+
+// applySearchText 对标题、摘要和正文纯文本分词，写入检索列（由触发器生成search_vector）
+func (s *articleService) applySearchText(article *models.Article) {
+	article.SearchTitle = s.tokenizer.Tokenize(article.Title)
+	article.SearchSummary = s.tokenizer.Tokenize(article.Summary)
+	article.SearchContent = s.tokenizer.Tokenize(markup.PlainText(article.Content))
+}
+
+// applyKeyword 将关键词转换为全文检索条件，无法分词时退化为标题匹配
+func (s *articleService) applyKeyword(filter *repository.ArticleFilter, keyword string) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return
+	}
+	if tsQuery := s.tokenizer.Query(keyword); tsQuery != "" {
+		filter.SearchQuery = tsQuery
+		return
+	}
+	filter.Keyword = keyword
+}
+
+// renderContent 渲染正文：Markdown转换为HTML，HTML与Markdown统一按白名单清洗，并生成标题锚点和目录
+func (s *articleService) renderContent(article *models.Article, format models.ContentFormat, content, source string) error {
+	if format == "" {
+		format = models.ContentFormatHTML
+	}
+
+	switch format {
+	case models.ContentFormatHTML:
+		source = ""
+	case models.ContentFormatMarkdown:
+		content = source
+	default:
+		return ErrInvalidContentFormat
+	}
+	if strings.TrimSpace(content) == "" {
+		return ErrArticleContentRequired
+	}
+
+	result, err := markup.Render(string(format), content)
+	if err != nil {
+		return err
+	}
+	toc, err := json.Marshal(result.TOC)
+	if err != nil {
+		return err
+	}
+
+	article.ContentFormat = format
+	article.ContentSource = source
+	article.Content = result.HTML
+	article.TOC = toc
+	return nil
+}
+
+// refreshSitemap 异步重建Sitemap
+func (s *articleService) refreshSitemap() {
+	if s.sitemapSvc != nil {
+		s.sitemapSvc.RequestRebuild()
+	}
+}
+
+// saveWithRevision 保存文章（标签关联不变）并在同一事务中记录修订快照
+func (s *articleService) saveWithRevision(article *models.Article, action models.RevisionAction) error {
+	tagIDs := make([]uint, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	revision, err := newRevision(article, tagIDs, action, "")
+	if err != nil {
+		return err
+	}
+	return s.articleRepo.SaveWithRevision(article, nil, false, revision)
+}
+
+// newRevision 生成文章当前状态的修订快照（文章ID在保存时填入）
+func newRevision(article *models.Article, tagIDs []uint, action models.RevisionAction, note string) (*models.ArticleRevision, error) {
+	if tagIDs == nil {
+		tagIDs = []uint{}
+	}
+	tagIDsJSON, err := json.Marshal(tagIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ArticleRevision{
+		Action:        action,
+		Title:         article.Title,
+		Slug:          article.Slug,
+		Summary:       article.Summary,
+		Content:       article.Content,
+		ContentFormat: article.ContentFormat,
+		ContentSource: article.ContentSource,
+		CoverImage:    article.CoverImage,
+		CategoryID:    article.CategoryID,
+		TagIDs:        tagIDsJSON,
+		Status:        article.Status,
+		Note:          note,
+	}, nil
+}
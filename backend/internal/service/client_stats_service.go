@@ -0,0 +1,142 @@
+package service
+
+import (
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/uaparser"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+var ErrInvalidClientDimension = repository.ErrInvalidClientDimension
+
+// 补齐User-Agent解析时每批处理的记录数
+const clientInfoBackfillBatchSize = 1000
+
+// ClientStatsService 客户端（浏览器、操作系统、设备类型）统计服务接口
+type ClientStatsService interface {
+	// 获取客户端分布概览（浏览器、操作系统、设备类型），结束日期包含当天
+	GetClientStats(startDate, endDate time.Time, limit int) (*ClientStatsResponse, error)
+	// 获取某个维度的分布（browser、browser_version、os、os_version、device_type）
+	GetBreakdown(dimension string, startDate, endDate time.Time, limit int) ([]repository.ClientStat, error)
+	// 补齐历史访问记录和指纹的User-Agent解析，返回处理的记录数
+	BackfillClientInfo() (int, error)
+}
+
+// ClientStatsResponse 客户端分布概览
+type ClientStatsResponse struct {
+	Browsers         []repository.ClientStat `json:"browsers"`          // 浏览器
+	OperatingSystems []repository.ClientStat `json:"operating_systems"` // 操作系统
+	Devices          []repository.ClientStat `json:"devices"`           // 设备类型
+}
+
+// clientStatsService 客户端统计服务实现
+type clientStatsService struct {
+	clientInfoRepo repository.ClientInfoRepository
+}
+
+// NewClientStatsService 创建客户端统计服务
+func NewClientStatsService(clientInfoRepo repository.ClientInfoRepository) ClientStatsService {
+	return &clientStatsService{
+		clientInfoRepo: clientInfoRepo,
+	}
+}
+
+// GetClientStats 获取客户端分布概览
+func (s *clientStatsService) GetClientStats(startDate, endDate time.Time, limit int) (*ClientStatsResponse, error) {
+	browsers, err := s.GetBreakdown("browser", startDate, endDate, limit)
+	if err != nil {
+		return nil, err
+	}
+	operatingSystems, err := s.GetBreakdown("os", startDate, endDate, limit)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.GetBreakdown("device_type", startDate, endDate, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClientStatsResponse{
+		Browsers:         browsers,
+		OperatingSystems: operatingSystems,
+		Devices:          devices,
+	}, nil
+}
+
+// GetBreakdown 获取某个维度的分布
+func (s *clientStatsService) GetBreakdown(dimension string, startDate, endDate time.Time, limit int) ([]repository.ClientStat, error) {
+	if startDate.IsZero() {
+		startDate = time.Now().AddDate(0, 0, -30)
+	}
+	if endDate.IsZero() {
+		endDate = time.Now()
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return s.clientInfoRepo.GetBreakdown(dimension, localDay(startDate), localDay(endDate).AddDate(0, 0, 1), limit)
+}
+
+// BackfillClientInfo 补齐历史记录的User-Agent解析
+func (s *clientStatsService) BackfillClientInfo() (int, error) {
+	total := 0
+	for _, table := range []string{repository.ClientInfoTableVisits, repository.ClientInfoTableFingerprints} {
+		count, err := s.backfillTable(table)
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// backfillTable 按ID分批解析一张表中尚未解析的记录
+func (s *clientStatsService) backfillTable(table string) (int, error) {
+	// 同一个User-Agent通常出现很多次，只解析一次
+	cache := make(map[string]models.ClientInfo)
+
+	count := 0
+	var afterID uint
+	for {
+		rows, err := s.clientInfoRepo.ListUnparsed(table, afterID, clientInfoBackfillBatchSize)
+		if err != nil {
+			return count, err
+		}
+		if len(rows) == 0 {
+			return count, nil
+		}
+
+		parsed := make([]repository.ParsedUserAgent, 0, len(rows))
+		for _, row := range rows {
+			info, ok := cache[row.UserAgent]
+			if !ok {
+				info = parseClientInfo(row.UserAgent)
+				cache[row.UserAgent] = info
+			}
+			parsed = append(parsed, repository.ParsedUserAgent{ID: row.ID, ClientInfo: info})
+		}
+
+		if err := s.clientInfoRepo.UpdateClientInfo(table, parsed); err != nil {
+			return count, err
+		}
+		count += len(rows)
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+// parseClientInfo 解析User-Agent并截断到列宽
+func parseClientInfo(userAgent string) models.ClientInfo {
+	client := uaparser.Parse(userAgent)
+	return models.ClientInfo{
+		Browser:        truncateRunes(client.Browser, 50),
+		BrowserVersion: truncateRunes(client.BrowserVersion, 20),
+		OS:             truncateRunes(client.OS, 50),
+		OSVersion:      truncateRunes(client.OSVersion, 20),
+		DeviceType:     client.DeviceType,
+	}
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/redis"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+const (
+	// 每个指纹和每个IP在时间窗口内允许发表的评论数（同一出口IP下可能有多个访客）
+	commentRateLimit   = 5
+	commentIPRateLimit = 20
+	commentRateWindow  = 10 * time.Minute
+)
+
+var (
+	ErrCommentNotFound           = repository.ErrCommentNotFound
+	ErrCommentTooFrequent        = errors.New("comment too frequent")
+	ErrCommentContentRequired    = errors.New("comment content is required")
+	ErrCommentArticleClosed      = errors.New("article is not open for comments")
+	ErrCommentParentInvalid      = errors.New("invalid parent comment")
+	ErrCommentFingerprintInvalid = errors.New("fingerprint not found")
+	ErrInvalidCommentStatus      = errors.New("invalid comment status")
+)
+
+// CommentService 评论服务接口
+type CommentService interface {
+	// 发表评论（公开）
+	Create(articleID uint, req *CreateCommentRequest) (*models.Message, error)
+	// 获取文章的评论树（公开，仅已通过）
+	ListByArticle(articleID uint, page, pageSize int) (*CommentTreeResponse, error)
+	// 获取评论列表（管理员审核队列）
+	List(req *CommentListRequest) (*CommentListResponse, error)
+	// 更新评论状态
+	UpdateStatus(id uint, status models.MessageStatus) error
+	// 批量更新评论状态
+	BatchUpdateStatus(ids []uint, status models.MessageStatus) (int64, error)
+	// 删除评论
+	Delete(id uint) error
+}
+
+// CreateCommentRequest 发表评论请求
+type CreateCommentRequest struct {
+	FingerprintID   uint   `json:"fingerprint_id" binding:"required"`
+	FingerprintHash string `json:"fingerprint_hash" binding:"required,len=64"` // 采集指纹时返回的哈希，证明请求来自该指纹的访客
+	ParentID        *uint  `json:"parent_id"`
+	Name            string `json:"name" binding:"required,max=100"`
+	Email           string `json:"email" binding:"omitempty,email,max=100"`
+	Content         string `json:"content" binding:"required,max=2000"`
+	IPAddress       string `json:"-"`
+	UserAgent       string `json:"-"`
+}
+
+// CommentListRequest 评论列表请求（管理员）
+type CommentListRequest struct {
+	Page          int                   `json:"page"`
+	PageSize      int                   `json:"page_size"`
+	ArticleID     *uint                 `json:"article_id"`
+	Status        *models.MessageStatus `json:"status"`
+	FingerprintID *uint                 `json:"fingerprint_id"`
+	Keyword       string                `json:"keyword"`
+}
+
+// CommentListResponse 评论列表响应（管理员）
+type CommentListResponse struct {
+	Items      []models.Message `json:"items"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
+
+// CommentResponse 公开评论（不包含邮箱、IP等隐私字段）
+type CommentResponse struct {
+	ID        uint               `json:"id"`
+	ParentID  *uint              `json:"parent_id"`
+	Name      string             `json:"name"`
+	Content   string             `json:"content"`
+	CreatedAt time.Time          `json:"created_at"`
+	Replies   []*CommentResponse `json:"replies"`
+}
+
+// CommentTreeResponse 文章评论树响应（按顶级评论分页）
+type CommentTreeResponse struct {
+	Items         []*CommentResponse `json:"items"`
+	Total         int64              `json:"total"`          // 顶级评论数
+	TotalComments int64              `json:"total_comments"` // 全部可见评论数（含回复）
+	Page          int                `json:"page"`
+	PageSize      int                `json:"page_size"`
+	TotalPages    int                `json:"total_pages"`
+}
+
+// commentService 评论服务实现
+type commentService struct {
+	commentRepo     repository.CommentRepository
+	articleRepo     repository.ArticleRepository
+	fingerprintRepo repository.FingerprintRepository
+	articleCacheSvc ArticleCacheService
+}
+
+// NewCommentService 创建评论服务
+func NewCommentService(
+	commentRepo repository.CommentRepository,
+	articleRepo repository.ArticleRepository,
+	fingerprintRepo repository.FingerprintRepository,
+	articleCacheSvc ArticleCacheService,
+) CommentService {
+	return &commentService{
+		commentRepo:     commentRepo,
+		articleRepo:     articleRepo,
+		fingerprintRepo: fingerprintRepo,
+		articleCacheSvc: articleCacheSvc,
+	}
+}
+
+// Create 发表评论（公开）
+func (s *commentService) Create(articleID uint, req *CreateCommentRequest) (*models.Message, error) {
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, ErrCommentContentRequired
+	}
+
+	// 只允许评论已发布的文章
+	article, err := s.articleRepo.FindByID(articleID)
+	if err != nil {
+		return nil, err
+	}
+	if article.Status != models.ArticleStatusPublished ||
+		(article.PublishAt != nil && article.PublishAt.After(time.Now())) {
+		return nil, ErrCommentArticleClosed
+	}
+
+	// 验证指纹：指纹ID是自增的，需要同时提供哈希，避免冒用其他访客的指纹
+	fingerprint, err := s.fingerprintRepo.FindByID(req.FingerprintID)
+	if err != nil {
+		return nil, ErrCommentFingerprintInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(fingerprint.FingerprintHash), []byte(req.FingerprintHash)) != 1 {
+		return nil, ErrCommentFingerprintInvalid
+	}
+
+	// 回复必须指向同一文章下已通过的评论
+	if req.ParentID != nil {
+		parent, err := s.commentRepo.FindByID(*req.ParentID)
+		if err != nil {
+			return nil, ErrCommentParentInvalid
+		}
+		if parent.ArticleID == nil || *parent.ArticleID != articleID || parent.Status != models.MessageStatusApproved {
+			return nil, ErrCommentParentInvalid
+		}
+	}
+
+	// 按指纹和IP限流，更换指纹不能绕过限制
+	if err := s.checkRateLimit(fmt.Sprintf("comment_rate:%d", req.FingerprintID), commentRateLimit); err != nil {
+		return nil, err
+	}
+	if req.IPAddress != "" {
+		if err := s.checkRateLimit("comment_rate:ip:"+req.IPAddress, commentIPRateLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	fingerprintID := req.FingerprintID
+	comment := &models.Message{
+		ArticleID:     &articleID,
+		ParentID:      req.ParentID,
+		Name:          strings.TrimSpace(req.Name),
+		Email:         strings.TrimSpace(req.Email),
+		Content:       content,
+		Status:        models.MessageStatusPending,
+		FingerprintID: &fingerprintID,
+		IPAddress:     req.IPAddress,
+		UserAgent:     req.UserAgent,
+	}
+
+	if err := s.commentRepo.Create(comment); err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// checkRateLimit 检查时间窗口内的评论频率（Redis不可用时放行）
+func (s *commentService) checkRateLimit(key string, limit int64) error {
+	count, err := redis.Get().Incr(context.Background(), key).Result()
+	if err != nil {
+		return nil
+	}
+	if count == 1 {
+		_ = redis.Expire(key, commentRateWindow)
+	}
+	if count > limit {
+		return ErrCommentTooFrequent
+	}
+	return nil
+}
+
+// ListByArticle 获取文章的评论树（公开，仅已通过）
+func (s *commentService) ListByArticle(articleID uint, page, pageSize int) (*CommentTreeResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	offset := (page - 1) * pageSize
+	roots, total, err := s.commentRepo.ListRoots(articleID, models.MessageStatusApproved, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := s.commentRepo.CountVisible(articleID, models.MessageStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*CommentResponse, 0, len(roots))
+	level := make([]*CommentResponse, 0, len(roots))
+	for i := range roots {
+		node := newCommentResponse(&roots[i])
+		items = append(items, node)
+		level = append(level, node)
+	}
+
+	// 逐层加载当前页顶级评论的回复：父评论不可见时，其回复一并隐藏
+	for len(level) > 0 {
+		parents := make(map[uint]*CommentResponse, len(level))
+		parentIDs := make([]uint, 0, len(level))
+		for _, node := range level {
+			parents[node.ID] = node
+			parentIDs = append(parentIDs, node.ID)
+		}
+
+		replies, err := s.commentRepo.ListReplies(parentIDs, models.MessageStatusApproved)
+		if err != nil {
+			return nil, err
+		}
+
+		next := make([]*CommentResponse, 0, len(replies))
+		for i := range replies {
+			node := newCommentResponse(&replies[i])
+			parent := parents[*replies[i].ParentID]
+			parent.Replies = append(parent.Replies, node)
+			next = append(next, node)
+		}
+		level = next
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	return &CommentTreeResponse{
+		Items:         items,
+		Total:         total,
+		TotalComments: visible,
+		Page:          page,
+		PageSize:      pageSize,
+		TotalPages:    totalPages,
+	}, nil
+}
+
+// newCommentResponse 转换为公开评论
+func newCommentResponse(comment *models.Message) *CommentResponse {
+	return &CommentResponse{
+		ID:        comment.ID,
+		ParentID:  comment.ParentID,
+		Name:      comment.Name,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+		Replies:   []*CommentResponse{},
+	}
+}
+
+// List 获取评论列表（管理员审核队列）
+func (s *commentService) List(req *CommentListRequest) (*CommentListResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+
+	filter := &repository.CommentFilter{
+		ArticleID:     req.ArticleID,
+		Status:        req.Status,
+		FingerprintID: req.FingerprintID,
+		Keyword:       req.Keyword,
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	comments, total, err := s.commentRepo.List(filter, offset, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPages++
+	}
+
+	return &CommentListResponse{
+		Items:      comments,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// UpdateStatus 更新评论状态
+func (s *commentService) UpdateStatus(id uint, status models.MessageStatus) error {
+	if !isValidCommentStatus(status) {
+		return ErrInvalidCommentStatus
+	}
+
+	comment, err := s.commentRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.commentRepo.UpdateStatus([]uint{id}, status); err != nil {
+		return err
+	}
+
+	s.clearArticleCache(comment.ArticleID)
+	return nil
+}
+
+// BatchUpdateStatus 批量更新评论状态
+func (s *commentService) BatchUpdateStatus(ids []uint, status models.MessageStatus) (int64, error) {
+	if !isValidCommentStatus(status) {
+		return 0, ErrInvalidCommentStatus
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	affected, err := s.commentRepo.UpdateStatus(ids, status)
+	if err != nil {
+		return 0, err
+	}
+
+	// 评论数随审核状态变化，清除文章缓存
+	if s.articleCacheSvc != nil {
+		_ = s.articleCacheSvc.ClearArticleCache()
+	}
+	return affected, nil
+}
+
+// Delete 删除评论
+func (s *commentService) Delete(id uint) error {
+	comment, err := s.commentRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.commentRepo.Delete(id); err != nil {
+		return err
+	}
+
+	s.clearArticleCache(comment.ArticleID)
+	return nil
+}
+
+// clearArticleCache 清除评论所属文章的缓存
+func (s *commentService) clearArticleCache(articleID *uint) {
+	if s.articleCacheSvc != nil && articleID != nil {
+		_ = s.articleCacheSvc.ClearArticleCacheByID(*articleID)
+	}
+}
+
+// isValidCommentStatus 检查评论状态是否合法
+func isValidCommentStatus(status models.MessageStatus) bool {
+	switch status {
+	case models.MessageStatusPending, models.MessageStatusApproved, models.MessageStatusSpam:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,505 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gosimple/slug"
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/repository"
+	"github.com/whk-newbie/blog/internal/websocket"
+	"gorm.io/datatypes"
+)
+
+// 导入限制
+const (
+	crawlImportBatchSize     = 200   // 每次读取的结果数
+	maxReportedImportErrors  = 20    // 汇总中最多保留的错误数
+	defaultImportSourceField = "url" // 默认的来源URL字段
+)
+
+var (
+	ErrInvalidImportMapping = errors.New("invalid import mapping")
+	ErrImportMappingMissing = errors.New("import mapping not configured")
+	ErrImportAuthorRequired = errors.New("import author is required")
+	ErrImportInProgress     = errors.New("import already in progress")
+	ErrTaskNotCompleted     = errors.New("task not completed")
+)
+
+// CrawlImportService 采集结果导入服务接口
+type CrawlImportService interface {
+	// 校验映射规则（字段配置、默认分类和作者是否存在）
+	ValidateMapping(mapping *models.CrawlImportMapping) error
+	// 校验爬虫注册任务时提交的映射规则（不能指定作者，且需要配置了导入作者）
+	ValidateCrawlerMapping(mapping *models.CrawlImportMapping) error
+	// 设置任务的导入映射规则（mapping为nil时清除），未指定作者时使用adminID
+	UpdateMapping(taskID uint, mapping *models.CrawlImportMapping, adminID uint) (*models.CrawlTask, error)
+	// 在后台按映射规则将已完成任务的采集结果导入为草稿文章（映射规则没有作者时使用配置的导入作者）
+	StartImport(taskID uint) error
+}
+
+// crawlImportService 采集结果导入服务实现
+type crawlImportService struct {
+	taskRepo       repository.CrawlTaskRepository
+	resultRepo     repository.CrawlResultRepository
+	articleRepo    repository.ArticleRepository
+	categoryRepo   repository.CategoryRepository
+	adminRepo      repository.AdminRepository
+	articleService ArticleService
+	tagService     TagService
+	hub            *websocket.Hub
+	importAuthor   string // 映射规则没有作者时使用的管理员用户名
+
+	running sync.Map // 正在导入的任务ID
+}
+
+// NewCrawlImportService 创建采集结果导入服务
+func NewCrawlImportService(
+	taskRepo repository.CrawlTaskRepository,
+	resultRepo repository.CrawlResultRepository,
+	articleRepo repository.ArticleRepository,
+	categoryRepo repository.CategoryRepository,
+	adminRepo repository.AdminRepository,
+	articleService ArticleService,
+	tagService TagService,
+	hub *websocket.Hub,
+	importAuthor string,
+) CrawlImportService {
+	return &crawlImportService{
+		taskRepo:       taskRepo,
+		resultRepo:     resultRepo,
+		articleRepo:    articleRepo,
+		categoryRepo:   categoryRepo,
+		adminRepo:      adminRepo,
+		articleService: articleService,
+		tagService:     tagService,
+		hub:            hub,
+		importAuthor:   strings.TrimSpace(importAuthor),
+	}
+}
+
+// ValidateMapping 校验映射规则
+func (s *crawlImportService) ValidateMapping(mapping *models.CrawlImportMapping) error {
+	if strings.TrimSpace(mapping.TitleField) == "" {
+		return fmt.Errorf("%w: 必须指定标题字段 title_field", ErrInvalidImportMapping)
+	}
+	if strings.TrimSpace(mapping.ContentField) == "" {
+		return fmt.Errorf("%w: 必须指定正文字段 content_field", ErrInvalidImportMapping)
+	}
+	switch mapping.ContentFormat {
+	case "", models.ContentFormatHTML, models.ContentFormatMarkdown:
+	default:
+		return fmt.Errorf("%w: 正文格式只支持 html 或 markdown", ErrInvalidImportMapping)
+	}
+	if mapping.DefaultCategoryID != nil {
+		if _, err := s.categoryRepo.FindByID(*mapping.DefaultCategoryID); err != nil {
+			return fmt.Errorf("%w: 默认分类不存在", ErrInvalidImportMapping)
+		}
+	}
+	if mapping.AuthorID > 0 {
+		if _, err := s.adminRepo.FindByID(mapping.AuthorID); err != nil {
+			return fmt.Errorf("%w: 作者不存在", ErrInvalidImportMapping)
+		}
+	}
+	return nil
+}
+
+// ValidateCrawlerMapping 校验爬虫提交的映射规则
+// 爬虫Token不能任意指定管理员作为作者，作者由管理员修改映射规则时设置，或使用配置的导入作者
+func (s *crawlImportService) ValidateCrawlerMapping(mapping *models.CrawlImportMapping) error {
+	if mapping.AuthorID != 0 {
+		return fmt.Errorf("%w: 爬虫不能指定作者 author_id，作者由管理员设置", ErrInvalidImportMapping)
+	}
+	if s.importAuthor == "" {
+		return fmt.Errorf("%w: 服务端未配置导入作者（crawler.import_author），请由管理员设置映射规则", ErrInvalidImportMapping)
+	}
+	return s.ValidateMapping(mapping)
+}
+
+// UpdateMapping 设置任务的导入映射规则
+func (s *crawlImportService) UpdateMapping(taskID uint, mapping *models.CrawlImportMapping, adminID uint) (*models.CrawlTask, error) {
+	task, err := s.taskRepo.FindByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappingJSON datatypes.JSON
+	if mapping != nil {
+		if mapping.AuthorID == 0 {
+			mapping.AuthorID = adminID
+		}
+		if err := s.ValidateMapping(mapping); err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(mapping)
+		if err != nil {
+			return nil, err
+		}
+		mappingJSON = datatypes.JSON(data)
+	}
+
+	if err := s.taskRepo.UpdateImportMapping(task.ID, mappingJSON); err != nil {
+		return nil, err
+	}
+	task.ImportMapping = mappingJSON
+
+	return task, nil
+}
+
+// StartImport 在后台执行导入
+func (s *crawlImportService) StartImport(taskID uint) error {
+	task, err := s.taskRepo.FindByID(taskID)
+	if err != nil {
+		return err
+	}
+	if task.Status != models.CrawlTaskStatusCompleted {
+		return ErrTaskNotCompleted
+	}
+	if len(task.ImportMapping) == 0 {
+		return ErrImportMappingMissing
+	}
+
+	var mapping models.CrawlImportMapping
+	if err := json.Unmarshal(task.ImportMapping, &mapping); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImportMapping, err)
+	}
+	if mapping.AuthorID == 0 {
+		author, err := s.defaultAuthor()
+		if err != nil {
+			// 完成任务时自动导入的错误只会写入日志，同时记录到导入汇总中便于在后台查看
+			now := time.Now()
+			summary := &models.CrawlImportSummary{Status: models.CrawlImportStatusFailed, StartedAt: now, FinishedAt: &now}
+			addImportError(summary, "未指定导入作者，请设置映射规则的作者后重新导入")
+			s.saveSummary(task.ID, summary)
+			return err
+		}
+		mapping.AuthorID = author.ID
+	}
+
+	if _, loaded := s.running.LoadOrStore(task.ID, struct{}{}); loaded {
+		return ErrImportInProgress
+	}
+
+	go func() {
+		defer s.running.Delete(task.ID)
+		s.runImport(task.ID, &mapping)
+	}()
+
+	return nil
+}
+
+// defaultAuthor 配置的导入作者
+func (s *crawlImportService) defaultAuthor() (*models.Admin, error) {
+	if s.importAuthor == "" {
+		return nil, ErrImportAuthorRequired
+	}
+	author, err := s.adminRepo.FindByUsername(s.importAuthor)
+	if err != nil {
+		log.Printf("Crawl import author %s not found: %v", s.importAuthor, err)
+		return nil, ErrImportAuthorRequired
+	}
+	return author, nil
+}
+
+// runImport 执行导入并记录汇总
+func (s *crawlImportService) runImport(taskID uint, mapping *models.CrawlImportMapping) {
+	summary := &models.CrawlImportSummary{
+		Status:    models.CrawlImportStatusRunning,
+		StartedAt: time.Now(),
+	}
+	s.saveSummary(taskID, summary)
+
+	err := s.importResults(taskID, mapping, summary)
+
+	now := time.Now()
+	summary.FinishedAt = &now
+	summary.Status = models.CrawlImportStatusCompleted
+	if err != nil {
+		summary.Status = models.CrawlImportStatusFailed
+		addImportError(summary, err.Error())
+		log.Printf("Crawl import for task %d failed: %v", taskID, err)
+	} else {
+		log.Printf("Crawl import for task %d finished: %d created, %d duplicated, %d skipped, %d failed",
+			taskID, summary.Created, summary.Duplicated, summary.Skipped, summary.Failed)
+	}
+	s.saveSummary(taskID, summary)
+}
+
+// importResults 按ID顺序分批读取结果并逐条导入
+func (s *crawlImportService) importResults(taskID uint, mapping *models.CrawlImportMapping, summary *models.CrawlImportSummary) error {
+	importer := &crawlImporter{
+		service:    s,
+		taskID:     taskID,
+		mapping:    mapping,
+		summary:    summary,
+		seen:       make(map[string]bool),
+		tags:       make(map[string]uint),
+		categories: make(map[string]*uint),
+	}
+
+	filter := &repository.CrawlResultFilter{TaskID: taskID}
+	var afterID uint
+	for {
+		results, err := s.resultRepo.ListAfter(filter, afterID, crawlImportBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+		for i := range results {
+			afterID = results[i].ID
+			summary.Total++
+			importer.importResult(&results[i])
+		}
+		// 每批结束后更新一次进度
+		s.saveSummary(taskID, summary)
+	}
+}
+
+// saveSummary 保存导入汇总并广播任务更新
+func (s *crawlImportService) saveSummary(taskID uint, summary *models.CrawlImportSummary) {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		log.Printf("Failed to marshal crawl import summary: %v", err)
+		return
+	}
+	if err := s.taskRepo.UpdateImportSummary(taskID, datatypes.JSON(data)); err != nil {
+		log.Printf("Failed to save crawl import summary for task %d: %v", taskID, err)
+		return
+	}
+
+	if s.hub != nil {
+		if task, err := s.taskRepo.FindByID(taskID); err == nil {
+			s.hub.BroadcastTaskUpdate(task)
+		}
+	}
+}
+
+// addImportError 记录导入错误（只保留前若干条）
+func addImportError(summary *models.CrawlImportSummary, msg string) {
+	if len(summary.Errors) < maxReportedImportErrors {
+		summary.Errors = append(summary.Errors, msg)
+	}
+}
+
+// crawlImporter 单次导入的状态（批内去重和标签、分类查找缓存）
+type crawlImporter struct {
+	service    *crawlImportService
+	taskID     uint
+	mapping    *models.CrawlImportMapping
+	summary    *models.CrawlImportSummary
+	seen       map[string]bool  // 本次已处理的来源URL
+	tags       map[string]uint  // 标签Slug -> 标签ID
+	categories map[string]*uint // 分类字段值 -> 分类ID
+}
+
+// importResult 将单条结果导入为草稿文章
+func (im *crawlImporter) importResult(result *models.CrawlResult) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(result.Data, &data); err != nil {
+		im.summary.Failed++
+		addImportError(im.summary, fmt.Sprintf("结果 #%d: 不是JSON对象", result.ID))
+		return
+	}
+
+	mapping := im.mapping
+	sourceField := mapping.SourceURLField
+	if sourceField == "" {
+		sourceField = defaultImportSourceField
+	}
+
+	title := truncateRunes(strings.TrimSpace(lookupImportString(data, mapping.TitleField)), 255)
+	sourceURL := strings.TrimSpace(lookupImportString(data, sourceField))
+	if title == "" || sourceURL == "" || len(sourceURL) > 1000 {
+		im.summary.Skipped++
+		return
+	}
+
+	// 批内去重，再检查是否已导入过
+	if im.seen[sourceURL] {
+		im.summary.Duplicated++
+		return
+	}
+	im.seen[sourceURL] = true
+
+	exists, err := im.service.articleRepo.ExistsBySourceURL(sourceURL)
+	if err != nil {
+		im.summary.Failed++
+		addImportError(im.summary, fmt.Sprintf("结果 #%d: %v", result.ID, err))
+		return
+	}
+	if exists {
+		im.summary.Duplicated++
+		return
+	}
+
+	taskID := im.taskID
+	req := &CreateArticleRequest{
+		Title:         title,
+		Slug:          im.uniqueSlug(title, result.ID),
+		Summary:       strings.TrimSpace(lookupImportString(data, mapping.SummaryField)),
+		ContentFormat: mapping.ContentFormat,
+		CategoryID:    im.resolveCategory(lookupImportString(data, mapping.CategoryField)),
+		TagIDs:        im.resolveTags(lookupImportStrings(data, mapping.TagsField)),
+		Status:        models.ArticleStatusDraft,
+		SourceURL:     sourceURL,
+		CrawlTaskID:   &taskID,
+	}
+	content := lookupImportString(data, mapping.ContentField)
+	if mapping.ContentFormat == models.ContentFormatMarkdown {
+		req.ContentSource = content
+	} else {
+		req.Content = content
+	}
+	if cover := strings.TrimSpace(lookupImportString(data, mapping.CoverImageField)); len(cover) <= 500 {
+		req.CoverImage = cover
+	}
+
+	if _, err := im.service.articleService.Create(req, mapping.AuthorID); err != nil {
+		if errors.Is(err, ErrArticleSourceExists) {
+			im.summary.Duplicated++
+			return
+		}
+		im.summary.Failed++
+		addImportError(im.summary, fmt.Sprintf("结果 #%d: %v", result.ID, err))
+		return
+	}
+	im.summary.Created++
+}
+
+// uniqueSlug 由标题生成Slug，已被占用时追加结果ID
+func (im *crawlImporter) uniqueSlug(title string, resultID uint) string {
+	base := truncateSlug(slug.Make(title), 200)
+	if base == "" {
+		return fmt.Sprintf("crawl-%d-%d", im.taskID, resultID)
+	}
+	if exists, err := im.service.articleRepo.ExistsBySlug(base, 0); err == nil && !exists {
+		return base
+	}
+	return fmt.Sprintf("%s-%d", base, resultID)
+}
+
+// resolveCategory 按名称或Slug匹配分类，未匹配时使用默认分类
+func (im *crawlImporter) resolveCategory(value string) *uint {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return im.mapping.DefaultCategoryID
+	}
+	if id, ok := im.categories[value]; ok {
+		return id
+	}
+
+	id := im.mapping.DefaultCategoryID
+	if category, err := im.service.categoryRepo.FindBySlug(slug.Make(value)); err == nil {
+		id = &category.ID
+	}
+	im.categories[value] = id
+	return id
+}
+
+// resolveTags 按Slug匹配标签，不存在的标签自动创建
+func (im *crawlImporter) resolveTags(names []string) []uint {
+	ids := make([]uint, 0, len(names))
+	added := make(map[uint]bool)
+	for _, name := range names {
+		name = truncateRunes(strings.TrimSpace(name), 50)
+		tagSlug := truncateSlug(slug.Make(name), 50)
+		if tagSlug == "" {
+			continue
+		}
+
+		id, ok := im.tags[tagSlug]
+		if !ok {
+			tag, err := im.service.tagService.GetBySlug(tagSlug)
+			if errors.Is(err, ErrTagNotFound) {
+				tag, err = im.service.tagService.Create(&CreateTagRequest{Name: name, Slug: tagSlug}, im.mapping.AuthorID)
+			}
+			if err != nil {
+				log.Printf("Failed to resolve tag %q for crawl import: %v", name, err)
+				continue
+			}
+			id = tag.ID
+			im.tags[tagSlug] = id
+		}
+
+		if !added[id] {
+			added[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// lookupImportValue 按字段路径（"."分隔）取值
+func lookupImportValue(data map[string]interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	var value interface{} = data
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// lookupImportString 按字段路径取字符串值（数字和布尔值转换为字符串）
+func lookupImportString(data map[string]interface{}, path string) string {
+	switch v := lookupImportValue(data, path).(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// lookupImportStrings 按字段路径取字符串列表（数组或逗号分隔的字符串）
+func lookupImportStrings(data map[string]interface{}, path string) []string {
+	switch v := lookupImportValue(data, path).(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == '，'
+		})
+	default:
+		return nil
+	}
+}
+
+// truncateRunes 按字符数截断字符串
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+// truncateSlug 截断Slug并去掉末尾的连字符
+func truncateSlug(s string, max int) string {
+	if len(s) > max {
+		s = s[:max]
+	}
+	return strings.TrimRight(s, "-")
+}
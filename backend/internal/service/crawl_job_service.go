@@ -0,0 +1,353 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/repository"
+	"github.com/whk-newbie/blog/internal/websocket"
+	"gorm.io/datatypes"
+)
+
+// 租约时长限制
+const (
+	minCrawlLeaseSeconds = 30
+	maxCrawlLeaseSeconds = 24 * 60 * 60
+)
+
+var (
+	ErrCrawlJobNotFound = repository.ErrCrawlJobNotFound
+	ErrCrawlJobExists   = repository.ErrCrawlJobExists
+	ErrInvalidCronSpec  = errors.New("invalid cron spec")
+	ErrCrawlJobOverlap  = errors.New("previous task of the job is still active")
+)
+
+// cronParser 与调度器一致的cron表达式解析器（秒 分 时 日 月 周，支持@every等描述符）
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// CrawlJobService 爬虫作业服务接口
+type CrawlJobService interface {
+	// 创建作业
+	Create(req *CrawlJobRequest, adminID uint) (*models.CrawlJob, error)
+	// 更新作业
+	Update(id uint, req *CrawlJobRequest, adminID uint) (*models.CrawlJob, error)
+	// 删除作业
+	Delete(id uint) error
+	// 获取作业详情
+	GetByID(id uint) (*models.CrawlJob, error)
+	// 获取作业列表
+	List(page, pageSize int) (*CrawlJobListResponse, error)
+	// 获取全部启用的作业（供调度器加载）
+	ListEnabled() ([]models.CrawlJob, error)
+	// 运行作业：生成一个待执行任务（force为true时忽略上次任务是否结束）
+	RunJob(id uint, force bool) (*models.CrawlTask, error)
+	// 设置作业定义变更时的回调（调度器据此重新加载）
+	SetChangeListener(fn func())
+}
+
+// CrawlJobRequest 创建/更新作业请求
+type CrawlJobRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	// cron表达式（秒 分 时 日 月 周），如 "0 0 */6 * * *"，也支持 "@every 1h"
+	CronSpec string `json:"cron_spec" binding:"required"`
+	// 是否启用（默认启用）
+	Enabled *bool `json:"enabled"`
+	// 下发给爬虫的参数，作为生成任务的metadata
+	Params map[string]interface{} `json:"params"`
+	// 生成任务的结果JSON Schema
+	ResultSchema map[string]interface{} `json:"result_schema"`
+	// 生成任务的导入映射规则（未指定作者时使用当前管理员）
+	ImportMapping *models.CrawlImportMapping `json:"import_mapping"`
+	// 任务租约时长（秒，默认300），工作节点超过该时长未心跳则任务重新排队
+	LeaseSeconds int `json:"lease_seconds"`
+	// 任务最多领取次数（默认3），超过后标记为失败
+	MaxAttempts int `json:"max_attempts"`
+	// 上次生成的任务未结束时是否仍生成新任务
+	AllowOverlap bool `json:"allow_overlap"`
+}
+
+// CrawlJobListResponse 作业列表响应
+type CrawlJobListResponse struct {
+	Items      []models.CrawlJob `json:"items"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
+
+// crawlJobService 爬虫作业服务实现
+type crawlJobService struct {
+	jobRepo       repository.CrawlJobRepository
+	taskRepo      repository.CrawlTaskRepository
+	importService CrawlImportService
+	hub           *websocket.Hub
+
+	mu       sync.RWMutex
+	onChange func()
+}
+
+// NewCrawlJobService 创建爬虫作业服务
+func NewCrawlJobService(jobRepo repository.CrawlJobRepository, taskRepo repository.CrawlTaskRepository, importService CrawlImportService, hub *websocket.Hub) CrawlJobService {
+	return &crawlJobService{
+		jobRepo:       jobRepo,
+		taskRepo:      taskRepo,
+		importService: importService,
+		hub:           hub,
+	}
+}
+
+// Create 创建作业
+func (s *crawlJobService) Create(req *CrawlJobRequest, adminID uint) (*models.CrawlJob, error) {
+	job := &models.CrawlJob{CreatedBy: &adminID}
+	if err := s.apply(job, req, adminID); err != nil {
+		return nil, err
+	}
+
+	if err := s.jobRepo.Create(job); err != nil {
+		return nil, err
+	}
+
+	s.notifyChange()
+	s.fillNextRun(job)
+	return job, nil
+}
+
+// Update 更新作业
+func (s *crawlJobService) Update(id uint, req *CrawlJobRequest, adminID uint) (*models.CrawlJob, error) {
+	job, err := s.jobRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(job, req, adminID); err != nil {
+		return nil, err
+	}
+
+	if err := s.jobRepo.Update(job); err != nil {
+		return nil, err
+	}
+
+	s.notifyChange()
+	s.fillNextRun(job)
+	return job, nil
+}
+
+// Delete 删除作业
+func (s *crawlJobService) Delete(id uint) error {
+	if err := s.jobRepo.Delete(id); err != nil {
+		return err
+	}
+	s.notifyChange()
+	return nil
+}
+
+// GetByID 获取作业详情
+func (s *crawlJobService) GetByID(id uint) (*models.CrawlJob, error) {
+	job, err := s.jobRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.fillNextRun(job)
+	return job, nil
+}
+
+// List 获取作业列表
+func (s *crawlJobService) List(page, pageSize int) (*CrawlJobListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	jobs, total, err := s.jobRepo.List((page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		s.fillNextRun(&jobs[i])
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	return &CrawlJobListResponse{
+		Items:      jobs,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ListEnabled 获取全部启用的作业
+func (s *crawlJobService) ListEnabled() ([]models.CrawlJob, error) {
+	return s.jobRepo.ListEnabled()
+}
+
+// RunJob 运行作业：生成一个待执行任务
+func (s *crawlJobService) RunJob(id uint, force bool) (*models.CrawlTask, error) {
+	job, err := s.jobRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 上次的任务未结束时跳过，避免任务堆积
+	if !job.AllowOverlap && !force {
+		active, err := s.taskRepo.HasActiveTaskForJob(job.ID)
+		if err != nil {
+			return nil, err
+		}
+		if active {
+			return nil, ErrCrawlJobOverlap
+		}
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	leaseSeconds := job.LeaseSeconds
+	task := &models.CrawlTask{
+		TaskID:        fmt.Sprintf("job-%d-%s-%s", job.ID, now.Format("20060102150405"), hex.EncodeToString(suffix)),
+		TaskName:      job.Name,
+		Status:        models.CrawlTaskStatusPending,
+		Progress:      0,
+		Message:       "等待工作节点领取",
+		StartTime:     now,
+		Metadata:      job.Params,
+		ResultSchema:  job.ResultSchema,
+		ImportMapping: job.ImportMapping,
+		JobID:         &job.ID,
+		LeaseSeconds:  &leaseSeconds,
+	}
+
+	if err := s.taskRepo.Create(task); err != nil {
+		return nil, err
+	}
+	if err := s.jobRepo.UpdateLastRun(job.ID, now); err != nil {
+		return nil, err
+	}
+
+	// 广播任务创建
+	if s.hub != nil {
+		s.hub.BroadcastTaskUpdate(task)
+	}
+
+	return task, nil
+}
+
+// SetChangeListener 设置作业定义变更时的回调
+func (s *crawlJobService) SetChangeListener(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = fn
+}
+
+// notifyChange 通知作业定义已变更
+func (s *crawlJobService) notifyChange() {
+	s.mu.RLock()
+	fn := s.onChange
+	s.mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+// apply 校验请求并写入作业
+func (s *crawlJobService) apply(job *models.CrawlJob, req *CrawlJobRequest, adminID uint) error {
+	cronSpec := strings.TrimSpace(req.CronSpec)
+	if _, err := cronParser.Parse(cronSpec); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCronSpec, err)
+	}
+
+	job.Name = strings.TrimSpace(req.Name)
+	job.Description = req.Description
+	job.CronSpec = cronSpec
+	job.Enabled = req.Enabled == nil || *req.Enabled
+	job.AllowOverlap = req.AllowOverlap
+
+	// 租约时长和最多领取次数
+	job.LeaseSeconds = req.LeaseSeconds
+	if job.LeaseSeconds <= 0 {
+		job.LeaseSeconds = models.DefaultCrawlLeaseSeconds
+	}
+	if job.LeaseSeconds < minCrawlLeaseSeconds {
+		job.LeaseSeconds = minCrawlLeaseSeconds
+	}
+	if job.LeaseSeconds > maxCrawlLeaseSeconds {
+		job.LeaseSeconds = maxCrawlLeaseSeconds
+	}
+	job.MaxAttempts = req.MaxAttempts
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = models.DefaultCrawlMaxAttempts
+	}
+
+	// 参数
+	job.Params = nil
+	if req.Params != nil {
+		paramsJSON, err := json.Marshal(req.Params)
+		if err != nil {
+			return err
+		}
+		job.Params = datatypes.JSON(paramsJSON)
+	}
+
+	// 结果Schema（保存时编译，确保Schema本身合法）
+	job.ResultSchema = nil
+	if req.ResultSchema != nil {
+		schemaJSON, err := json.Marshal(req.ResultSchema)
+		if err != nil {
+			return err
+		}
+		if _, err := CompileResultSchema(schemaJSON); err != nil {
+			return err
+		}
+		job.ResultSchema = datatypes.JSON(schemaJSON)
+	}
+
+	// 导入映射规则
+	job.ImportMapping = nil
+	if req.ImportMapping != nil {
+		if req.ImportMapping.AuthorID == 0 {
+			req.ImportMapping.AuthorID = adminID
+		}
+		if err := s.importService.ValidateMapping(req.ImportMapping); err != nil {
+			return err
+		}
+		mappingJSON, err := json.Marshal(req.ImportMapping)
+		if err != nil {
+			return err
+		}
+		job.ImportMapping = datatypes.JSON(mappingJSON)
+	}
+
+	return nil
+}
+
+// fillNextRun 计算启用作业的下次运行时间
+func (s *crawlJobService) fillNextRun(job *models.CrawlJob) {
+	job.NextRunAt = nil
+	if !job.Enabled {
+		return
+	}
+	schedule, err := cronParser.Parse(job.CronSpec)
+	if err != nil {
+		return
+	}
+	next := schedule.Next(time.Now())
+	job.NextRunAt = &next
+}
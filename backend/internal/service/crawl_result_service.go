@@ -0,0 +1,443 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/repository"
+	"github.com/whk-newbie/blog/internal/websocket"
+	"gorm.io/datatypes"
+)
+
+// 结果提交限制
+const (
+	MaxCrawlResultsPerBatch = 1000    // 每批最多结果数
+	MaxCrawlResultLineSize  = 1 << 20 // 单条结果最大字节数
+	maxReportedResultErrors = 20      // 校验失败时最多返回的错误数
+	crawlResultExportBatch  = 500     // 导出时每次读取的结果数
+)
+
+// 导出格式
+const (
+	CrawlResultExportCSV  = "csv"
+	CrawlResultExportJSON = "json"
+)
+
+var (
+	ErrCrawlTaskAccessDenied = errors.New("no permission to access this task")
+	ErrInvalidResultSchema   = errors.New("invalid result schema")
+	ErrEmptyResultBatch      = errors.New("result batch is empty")
+	ErrTooManyResults        = fmt.Errorf("result batch exceeds %d lines", MaxCrawlResultsPerBatch)
+	ErrInvalidExportFormat   = errors.New("invalid export format")
+)
+
+// CrawlResultLineError 单条结果的校验错误
+type CrawlResultLineError struct {
+	Line  int    `json:"line"` // 行号（从1开始）
+	Error string `json:"error"`
+}
+
+// CrawlResultValidationError 结果校验失败（整批不写入）
+type CrawlResultValidationError struct {
+	Invalid int                    `json:"invalid"` // 校验失败的行数
+	Errors  []CrawlResultLineError `json:"errors"`  // 前若干条错误
+}
+
+func (e *CrawlResultValidationError) Error() string {
+	return fmt.Sprintf("%d results failed validation", e.Invalid)
+}
+
+// CrawlResultService 爬虫采集结果服务接口
+type CrawlResultService interface {
+	// 提交一批结果（NDJSON，每行一个JSON对象），按任务声明的Schema校验，任一行失败则整批不写入
+	IngestResults(taskID string, body io.Reader, token string) (*IngestResultsResponse, error)
+	// 获取结果列表（管理员）
+	ListResults(req *CrawlResultListRequest) (*CrawlResultListResponse, error)
+	// 导出结果（CSV或JSON），按ID顺序分批写入w
+	ExportResults(req *CrawlResultListRequest, format string, w io.Writer) error
+}
+
+// IngestResultsResponse 提交结果响应
+type IngestResultsResponse struct {
+	Accepted    int `json:"accepted"`     // 本批写入的结果数
+	ResultCount int `json:"result_count"` // 任务累计结果数
+}
+
+// CrawlResultListRequest 结果列表请求
+type CrawlResultListRequest struct {
+	Page      int        `json:"page"`
+	PageSize  int        `json:"page_size"`
+	TaskID    uint       `json:"task_id"`
+	Keyword   string     `json:"keyword"`
+	Field     string     `json:"field"`
+	Value     string     `json:"value"`
+	StartTime *time.Time `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+}
+
+// CrawlResultListResponse 结果列表响应
+type CrawlResultListResponse struct {
+	Items      []models.CrawlResult `json:"items"`
+	Total      int64                `json:"total"`
+	Page       int                  `json:"page"`
+	PageSize   int                  `json:"page_size"`
+	TotalPages int                  `json:"total_pages"`
+}
+
+// crawlResultService 爬虫采集结果服务实现
+type crawlResultService struct {
+	taskRepo   repository.CrawlTaskRepository
+	resultRepo repository.CrawlResultRepository
+	hub        *websocket.Hub
+	schemas    sync.Map // 任务ID -> *jsonschema.Schema（Schema注册后不再变化）
+}
+
+// NewCrawlResultService 创建爬虫采集结果服务
+func NewCrawlResultService(taskRepo repository.CrawlTaskRepository, resultRepo repository.CrawlResultRepository, hub *websocket.Hub) CrawlResultService {
+	return &crawlResultService{
+		taskRepo:   taskRepo,
+		resultRepo: resultRepo,
+		hub:        hub,
+	}
+}
+
+// IngestResults 提交一批结果
+func (s *crawlResultService) IngestResults(taskID string, body io.Reader, token string) (*IngestResultsResponse, error) {
+	task, err := s.taskRepo.FindByTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.CreatedByToken != token {
+		return nil, ErrCrawlTaskAccessDenied
+	}
+	if task.Status == models.CrawlTaskStatusCompleted {
+		return nil, ErrTaskAlreadyCompleted
+	}
+	if task.Status == models.CrawlTaskStatusFailed {
+		return nil, ErrTaskAlreadyFailed
+	}
+	if task.Status == models.CrawlTaskStatusCancelled {
+		return nil, ErrTaskAlreadyCancelled
+	}
+
+	schema, err := s.taskSchema(task)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.CrawlResult, 0)
+	validation := &CrawlResultValidationError{Errors: make([]CrawlResultLineError, 0)}
+	reject := func(line int, msg string) {
+		validation.Invalid++
+		if len(validation.Errors) < maxReportedResultErrors {
+			validation.Errors = append(validation.Errors, CrawlResultLineError{Line: line, Error: msg})
+		}
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxCrawlResultLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		if len(results)+validation.Invalid >= MaxCrawlResultsPerBatch {
+			return nil, ErrTooManyResults
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil || decoder.More() {
+			reject(line, "不是合法的JSON")
+			continue
+		}
+		if _, ok := value.(map[string]interface{}); !ok {
+			reject(line, "结果必须是JSON对象")
+			continue
+		}
+		if schema != nil {
+			if err := schema.Validate(value); err != nil {
+				reject(line, describeSchemaError(err))
+				continue
+			}
+		}
+
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, raw); err != nil {
+			reject(line, "不是合法的JSON")
+			continue
+		}
+		results = append(results, models.CrawlResult{
+			TaskID: task.ID,
+			Data:   datatypes.JSON(compact.Bytes()),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			reject(line+1, fmt.Sprintf("单条结果不能超过 %d 字节", MaxCrawlResultLineSize))
+			return nil, validation
+		}
+		return nil, err
+	}
+
+	if validation.Invalid > 0 {
+		return nil, validation
+	}
+	if len(results) == 0 {
+		return nil, ErrEmptyResultBatch
+	}
+
+	// 只提交结果的工作节点也需要续约，否则任务会在租约到期后被重新排队
+	var leaseExpiresAt *time.Time
+	if task.LeaseExpiresAt != nil && task.LeaseSeconds != nil {
+		expiresAt := time.Now().Add(time.Duration(*task.LeaseSeconds) * time.Second)
+		leaseExpiresAt = &expiresAt
+	}
+	if err := s.resultRepo.CreateBatch(task.ID, results, leaseExpiresAt); err != nil {
+		return nil, err
+	}
+
+	// 广播任务更新（结果数变化）
+	task.ResultCount += len(results)
+	if s.hub != nil {
+		s.hub.BroadcastTaskUpdate(task)
+	}
+
+	return &IngestResultsResponse{
+		Accepted:    len(results),
+		ResultCount: task.ResultCount,
+	}, nil
+}
+
+// ListResults 获取结果列表
+func (s *crawlResultService) ListResults(req *CrawlResultListRequest) (*CrawlResultListResponse, error) {
+	// 设置默认值
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+
+	offset := (req.Page - 1) * req.PageSize
+	items, total, err := s.resultRepo.List(resultFilter(req), offset, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+
+	return &CrawlResultListResponse{
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ExportResults 导出结果
+// CSV的列为 id、task_id、created_at 加上结果中出现过的全部顶层字段，嵌套值以JSON写入
+// JSON导出为数组，每个元素包含 id、task_id、created_at 和 data
+func (s *crawlResultService) ExportResults(req *CrawlResultListRequest, format string, w io.Writer) error {
+	filter := resultFilter(req)
+
+	switch format {
+	case CrawlResultExportCSV:
+		fields, err := s.resultRepo.ListFields(filter)
+		if err != nil {
+			return err
+		}
+
+		writer := csv.NewWriter(w)
+		// 字段名来自抓取结果，同样需要防止公式注入
+		header := []string{"id", "task_id", "created_at"}
+		for _, field := range fields {
+			header = append(header, csvEscape(field))
+		}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		err = s.eachResult(filter, func(result *models.CrawlResult) error {
+			record := make([]string, 0, len(header))
+			record = append(record,
+				strconv.FormatUint(uint64(result.ID), 10),
+				strconv.FormatUint(uint64(result.TaskID), 10),
+				result.CreatedAt.Format(time.RFC3339),
+			)
+
+			values := make(map[string]json.RawMessage)
+			_ = json.Unmarshal(result.Data, &values)
+			for _, field := range fields {
+				record = append(record, csvValue(values[field]))
+			}
+			return writer.Write(record)
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+
+	case CrawlResultExportJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		first := true
+		err := s.eachResult(filter, func(result *models.CrawlResult) error {
+			item, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			if !first {
+				if _, err := io.WriteString(w, ",\n"); err != nil {
+					return err
+				}
+			}
+			first = false
+			_, err = w.Write(item)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "]\n")
+		return err
+
+	default:
+		return ErrInvalidExportFormat
+	}
+}
+
+// eachResult 按ID顺序分批遍历符合条件的结果
+func (s *crawlResultService) eachResult(filter *repository.CrawlResultFilter, fn func(*models.CrawlResult) error) error {
+	var afterID uint
+	for {
+		items, err := s.resultRepo.ListAfter(filter, afterID, crawlResultExportBatch)
+		if err != nil {
+			return err
+		}
+		for i := range items {
+			if err := fn(&items[i]); err != nil {
+				return err
+			}
+		}
+		if len(items) < crawlResultExportBatch {
+			return nil
+		}
+		afterID = items[len(items)-1].ID
+	}
+}
+
+// taskSchema 获取任务的结果Schema（已编译的缓存），未声明时返回nil
+func (s *crawlResultService) taskSchema(task *models.CrawlTask) (*jsonschema.Schema, error) {
+	if len(task.ResultSchema) == 0 || string(task.ResultSchema) == "null" {
+		return nil, nil
+	}
+	if cached, ok := s.schemas.Load(task.ID); ok {
+		return cached.(*jsonschema.Schema), nil
+	}
+
+	schema, err := CompileResultSchema(task.ResultSchema)
+	if err != nil {
+		return nil, err
+	}
+	s.schemas.Store(task.ID, schema)
+	return schema, nil
+}
+
+// CompileResultSchema 编译结果的JSON Schema
+// 禁止通过 $ref 加载外部文档（文件或网络），Schema必须是自包含的
+func CompileResultSchema(raw []byte) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("loading external schema %q is not allowed", s)
+	}
+
+	const url = "mem:///result_schema.json"
+	if err := compiler.AddResource(url, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResultSchema, err)
+	}
+	schema, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResultSchema, err)
+	}
+	return schema, nil
+}
+
+// describeSchemaError 将Schema校验错误转换为可读的描述（列出全部叶子错误）
+func describeSchemaError(err error) string {
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return err.Error()
+	}
+
+	messages := make([]string, 0)
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			location := e.InstanceLocation
+			if location == "" {
+				location = "/"
+			}
+			messages = append(messages, location+": "+e.Message)
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(ve)
+	sort.Strings(messages)
+	return strings.Join(messages, "; ")
+}
+
+// resultFilter 构建结果筛选条件
+func resultFilter(req *CrawlResultListRequest) *repository.CrawlResultFilter {
+	return &repository.CrawlResultFilter{
+		TaskID:    req.TaskID,
+		Keyword:   req.Keyword,
+		Field:     req.Field,
+		Value:     req.Value,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+	}
+}
+
+// csvValue 结果字段在CSV中的值：字符串原样输出，其他类型输出JSON
+func csvValue(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return string(raw)
+	}
+	return csvEscape(str)
+}
+
+// csvEscape 以公式字符开头的字符串前加单引号，避免在表格软件中被当作公式执行
+func csvEscape(str string) string {
+	if str != "" && strings.ContainsRune("=+-@\t\r", rune(str[0])) {
+		return "'" + str
+	}
+	return str
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/whk-newbie/blog/internal/models"
@@ -18,6 +20,10 @@ var (
 	ErrInvalidProgress      = errors.New("progress must be between 0 and 100")
 	ErrTaskAlreadyCompleted = errors.New("task already completed")
 	ErrTaskAlreadyFailed    = errors.New("task already failed")
+	ErrTaskAlreadyCancelled = errors.New("task already cancelled")
+	ErrCrawlTaskLeaseLost   = errors.New("task lease lost")
+	ErrInvalidTaskCommand   = errors.New("invalid task command")
+	ErrTaskCommandConflict  = errors.New("command not allowed in current task status")
 )
 
 // CrawlService 爬虫任务服务接口
@@ -36,6 +42,20 @@ type CrawlService interface {
 	GetTaskByID(id uint) (*models.CrawlTask, error)
 	// 获取任务详情（通过TaskID）
 	GetTaskByTaskID(taskID string) (*models.CrawlTask, error)
+	// 领取待执行任务（没有可领取的任务时返回nil）
+	ClaimTask(req *ClaimTaskRequest, token string) (*models.CrawlTask, error)
+	// 任务心跳（续约）
+	Heartbeat(taskID string, req *HeartbeatRequest, token string) (*models.CrawlTask, error)
+	// 将租约过期的任务重新排队（超过最多领取次数则标记失败），返回处理的任务数
+	RequeueExpiredTasks() (int, error)
+	// 将心跳超时的执行中任务标记为失败（任务未指定超时时使用defaultTimeout），返回被标记的任务
+	FailStaleTasks(defaultTimeout time.Duration) ([]models.CrawlTask, error)
+	// 下发控制命令（管理员）：命令保存为任务状态，并推送给持有任务的爬虫连接
+	SendCommand(id uint, command models.CrawlTaskCommand) (*models.CrawlTask, error)
+	// 爬虫确认控制命令，任务进入命令的目标状态
+	AcknowledgeCommand(taskID string, command models.CrawlTaskCommand, token string) (*models.CrawlTask, error)
+	// 获取该Token持有的、等待确认控制命令的任务（爬虫连接控制通道时补发）
+	ListPendingCommands(token string) ([]models.CrawlTask, error)
 }
 
 // RegisterTaskRequest 注册任务请求
@@ -43,6 +63,12 @@ type RegisterTaskRequest struct {
 	TaskID   string                 `json:"task_id" binding:"required"`
 	TaskName string                 `json:"task_name" binding:"required"`
 	Metadata map[string]interface{} `json:"metadata"`
+	// 采集结果的JSON Schema，提交结果时逐条校验；为空时不校验
+	ResultSchema map[string]interface{} `json:"result_schema"`
+	// 心跳超时（秒），超过该时长没有状态上报或提交结果则任务标记为失败；为0时使用全局配置
+	HeartbeatTimeout int `json:"heartbeat_timeout" binding:"min=0"`
+	// 结果导入文章的映射规则，任务完成后自动导入为草稿；为空时不导入
+	ImportMapping *models.CrawlImportMapping `json:"import_mapping"`
 }
 
 // UpdateTaskStatusRequest 更新任务状态请求
@@ -65,6 +91,24 @@ type FailTaskRequest struct {
 	Metadata map[string]interface{} `json:"metadata"`
 }
 
+// ClaimTaskRequest 领取任务请求
+type ClaimTaskRequest struct {
+	WorkerID string `json:"worker_id" binding:"required,max=100"` // 工作节点标识
+	JobIDs   []uint `json:"job_ids"`                              // 只领取这些作业的任务（为空时不限）
+}
+
+// TaskCommandRequest 控制命令请求
+type TaskCommandRequest struct {
+	Command models.CrawlTaskCommand `json:"command" binding:"required,oneof=pause resume cancel"`
+}
+
+// HeartbeatRequest 任务心跳请求
+type HeartbeatRequest struct {
+	WorkerID string `json:"worker_id"` // 工作节点标识（多个节点共用Token时用于确认仍持有租约）
+	Progress *int   `json:"progress"`
+	Message  string `json:"message"`
+}
+
 // CrawlTaskListRequest 任务列表请求
 type CrawlTaskListRequest struct {
 	Page     int                     `json:"page"`
@@ -84,15 +128,19 @@ type CrawlTaskListResponse struct {
 
 // crawlService 爬虫任务服务实现
 type crawlService struct {
-	taskRepo repository.CrawlTaskRepository
-	hub      *websocket.Hub
+	taskRepo      repository.CrawlTaskRepository
+	jobRepo       repository.CrawlJobRepository
+	importService CrawlImportService
+	hub           *websocket.Hub
 }
 
 // NewCrawlService 创建爬虫任务服务
-func NewCrawlService(taskRepo repository.CrawlTaskRepository, hub *websocket.Hub) CrawlService {
+func NewCrawlService(taskRepo repository.CrawlTaskRepository, jobRepo repository.CrawlJobRepository, importService CrawlImportService, hub *websocket.Hub) CrawlService {
 	return &crawlService{
-		taskRepo: taskRepo,
-		hub:      hub,
+		taskRepo:      taskRepo,
+		jobRepo:       jobRepo,
+		importService: importService,
+		hub:           hub,
 	}
 }
 
@@ -115,8 +163,12 @@ func (s *crawlService) RegisterTask(req *RegisterTaskRequest, token string) (*mo
 		Progress:       0,
 		Message:        "任务已注册",
 		StartTime:      now,
+		HeartbeatAt:    &now,
 		CreatedByToken: token,
 	}
+	if req.HeartbeatTimeout > 0 {
+		task.HeartbeatTimeout = &req.HeartbeatTimeout
+	}
 
 	// 处理Metadata
 	if req.Metadata != nil {
@@ -128,6 +180,30 @@ func (s *crawlService) RegisterTask(req *RegisterTaskRequest, token string) (*mo
 		task.Metadata = datatypes.JSON(metadataJSON)
 	}
 
+	// 处理结果Schema（注册时编译，确保Schema本身合法）
+	if req.ResultSchema != nil {
+		schemaJSON, err := json.Marshal(req.ResultSchema)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := CompileResultSchema(schemaJSON); err != nil {
+			return nil, err
+		}
+		task.ResultSchema = datatypes.JSON(schemaJSON)
+	}
+
+	// 处理导入映射规则
+	if req.ImportMapping != nil {
+		if err := s.importService.ValidateCrawlerMapping(req.ImportMapping); err != nil {
+			return nil, err
+		}
+		mappingJSON, err := json.Marshal(req.ImportMapping)
+		if err != nil {
+			return nil, err
+		}
+		task.ImportMapping = datatypes.JSON(mappingJSON)
+	}
+
 	if err := s.taskRepo.Create(task); err != nil {
 		return nil, err
 	}
@@ -160,6 +236,9 @@ func (s *crawlService) UpdateTaskStatus(taskID string, req *UpdateTaskStatusRequ
 	if task.Status == models.CrawlTaskStatusFailed {
 		return nil, ErrTaskAlreadyFailed
 	}
+	if task.Status == models.CrawlTaskStatusCancelled {
+		return nil, ErrTaskAlreadyCancelled
+	}
 
 	// 验证状态
 	if req.Status != models.CrawlTaskStatusRunning &&
@@ -173,15 +252,61 @@ func (s *crawlService) UpdateTaskStatus(taskID string, req *UpdateTaskStatusRequ
 		return nil, ErrInvalidProgress
 	}
 
-	// 更新任务
-	task.Status = req.Status
-	task.Progress = req.Progress
-	if req.Message != "" {
-		task.Message = req.Message
+	// 上报完成与调用完成接口一致（记录结束时间并触发导入）
+	if req.Status == models.CrawlTaskStatusCompleted {
+		return s.CompleteTask(taskID, &CompleteTaskRequest{Message: req.Message}, token)
 	}
 
-	if err := s.taskRepo.Update(task); err != nil {
-		return nil, err
+	// 状态上报同时视为心跳
+	now := time.Now()
+
+	if req.Status == models.CrawlTaskStatusRunning {
+		// 只更新进度，不覆盖状态：任务处于暂停或等待确认命令时，状态保持不变并通过响应返回给爬虫
+		updates := map[string]interface{}{
+			"progress":     req.Progress,
+			"heartbeat_at": now,
+		}
+		if req.Message != "" {
+			updates["message"] = req.Message
+		}
+		if task.LeaseExpiresAt != nil && task.LeaseSeconds != nil {
+			updates["lease_expires_at"] = now.Add(time.Duration(*task.LeaseSeconds) * time.Second)
+		}
+		ok, err := s.taskRepo.UpdateIfStatus(task.ID, models.ActiveCrawlTaskStatuses, updates)
+		if err != nil {
+			return nil, err
+		}
+		if task, err = s.taskRepo.FindByID(task.ID); err != nil {
+			return nil, err
+		}
+		if !ok {
+			// 检查后任务恰好已结束
+			switch task.Status {
+			case models.CrawlTaskStatusCompleted:
+				return nil, ErrTaskAlreadyCompleted
+			case models.CrawlTaskStatusFailed:
+				return nil, ErrTaskAlreadyFailed
+			case models.CrawlTaskStatusCancelled:
+				return nil, ErrTaskAlreadyCancelled
+			}
+			return nil, ErrInvalidTaskStatus
+		}
+	} else {
+		// 更新任务
+		task.Status = req.Status
+		task.Progress = req.Progress
+		if req.Message != "" {
+			task.Message = req.Message
+		}
+		task.HeartbeatAt = &now
+		if task.LeaseExpiresAt != nil && task.LeaseSeconds != nil {
+			leaseExpiresAt := now.Add(time.Duration(*task.LeaseSeconds) * time.Second)
+			task.LeaseExpiresAt = &leaseExpiresAt
+		}
+
+		if err := s.taskRepo.Update(task); err != nil {
+			return nil, err
+		}
 	}
 
 	// 广播任务更新
@@ -212,6 +337,9 @@ func (s *crawlService) CompleteTask(taskID string, req *CompleteTaskRequest, tok
 	if task.Status == models.CrawlTaskStatusFailed {
 		return nil, ErrTaskAlreadyFailed
 	}
+	if task.Status == models.CrawlTaskStatusCancelled {
+		return nil, ErrTaskAlreadyCancelled
+	}
 
 	// 更新任务
 	now := time.Now()
@@ -244,6 +372,13 @@ func (s *crawlService) CompleteTask(taskID string, req *CompleteTaskRequest, tok
 		s.hub.BroadcastTaskUpdate(task)
 	}
 
+	// 配置了映射规则时，在后台将结果导入为草稿文章
+	if len(task.ImportMapping) > 0 {
+		if err := s.importService.StartImport(task.ID); err != nil {
+			log.Printf("Failed to start crawl import for task %s: %v", task.TaskID, err)
+		}
+	}
+
 	return task, nil
 }
 
@@ -267,6 +402,9 @@ func (s *crawlService) FailTask(taskID string, req *FailTaskRequest, token strin
 	if task.Status == models.CrawlTaskStatusFailed {
 		return nil, ErrTaskAlreadyFailed
 	}
+	if task.Status == models.CrawlTaskStatusCancelled {
+		return nil, ErrTaskAlreadyCancelled
+	}
 
 	// 更新任务
 	now := time.Now()
@@ -360,3 +498,401 @@ func (s *crawlService) GetTaskByID(id uint) (*models.CrawlTask, error) {
 func (s *crawlService) GetTaskByTaskID(taskID string) (*models.CrawlTask, error) {
 	return s.taskRepo.FindByTaskID(taskID)
 }
+
+// ClaimTask 领取待执行任务
+func (s *crawlService) ClaimTask(req *ClaimTaskRequest, token string) (*models.CrawlTask, error) {
+	task, err := s.taskRepo.ClaimPending(req.JobIDs, req.WorkerID, token, time.Now())
+	if err != nil {
+		if errors.Is(err, ErrCrawlTaskNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// 广播任务更新
+	if s.hub != nil {
+		s.hub.BroadcastTaskUpdate(task)
+	}
+
+	return task, nil
+}
+
+// Heartbeat 任务心跳（续约）
+func (s *crawlService) Heartbeat(taskID string, req *HeartbeatRequest, token string) (*models.CrawlTask, error) {
+	// 获取任务
+	task, err := s.taskRepo.FindByTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查任务状态
+	if task.Status == models.CrawlTaskStatusCompleted {
+		return nil, ErrTaskAlreadyCompleted
+	}
+	if task.Status == models.CrawlTaskStatusFailed {
+		return nil, ErrTaskAlreadyFailed
+	}
+	if task.Status == models.CrawlTaskStatusCancelled {
+		return nil, ErrTaskAlreadyCancelled
+	}
+
+	// 任务已被重新排队或由其他工作节点领取
+	if !task.Status.IsActive() || task.CreatedByToken != token ||
+		(req.WorkerID != "" && task.WorkerID != req.WorkerID) {
+		if task.JobID != nil {
+			return nil, ErrCrawlTaskLeaseLost
+		}
+		return nil, ErrCrawlTaskAccessDenied
+	}
+
+	if req.Progress != nil && (*req.Progress < 0 || *req.Progress > 100) {
+		return nil, ErrInvalidProgress
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"heartbeat_at": now}
+	if task.LeaseSeconds != nil {
+		updates["lease_expires_at"] = now.Add(time.Duration(*task.LeaseSeconds) * time.Second)
+	}
+	if req.Progress != nil {
+		updates["progress"] = *req.Progress
+	}
+	if req.Message != "" {
+		updates["message"] = req.Message
+	}
+
+	renewed, err := s.taskRepo.RenewLease(task.ID, token, req.WorkerID, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !renewed {
+		return nil, ErrCrawlTaskLeaseLost
+	}
+
+	task, err = s.taskRepo.FindByID(task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 有进度或消息变化时广播
+	if s.hub != nil && (req.Progress != nil || req.Message != "") {
+		s.hub.BroadcastTaskUpdate(task)
+	}
+
+	return task, nil
+}
+
+// RequeueExpiredTasks 将租约过期的任务重新排队
+func (s *crawlService) RequeueExpiredTasks() (int, error) {
+	now := time.Now()
+	tasks, err := s.taskRepo.ListExpiredLeases(now)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for i := range tasks {
+		task := &tasks[i]
+
+		maxAttempts := models.DefaultCrawlMaxAttempts
+		if task.JobID != nil {
+			if job, err := s.jobRepo.FindByID(*task.JobID); err == nil && job.MaxAttempts > 0 {
+				maxAttempts = job.MaxAttempts
+			}
+		}
+
+		var updates map[string]interface{}
+		requeue := false
+		if task.Status == models.CrawlTaskStatusCancelling {
+			// 等待确认取消的任务不再重新排队
+			updates = cancelledUpdates(task, now, fmt.Sprintf("工作节点 %s 失联，任务已取消", task.WorkerID))
+		} else if task.Attempts >= maxAttempts {
+			duration := int(now.Sub(task.StartTime).Seconds())
+			updates = map[string]interface{}{
+				"status":           models.CrawlTaskStatusFailed,
+				"message":          fmt.Sprintf("工作节点 %s 失联，已达到最多领取次数（%d次）", task.WorkerID, maxAttempts),
+				"end_time":         now,
+				"duration":         duration,
+				"lease_expires_at": nil,
+			}
+		} else {
+			// 重新排队时丢弃上一次领取提交的结果，避免下一次执行的结果重复
+			requeue = true
+			updates = map[string]interface{}{
+				"status":           models.CrawlTaskStatusPending,
+				"progress":         0,
+				"result_count":     0,
+				"message":          fmt.Sprintf("工作节点 %s 失联，任务已重新排队", task.WorkerID),
+				"worker_id":        "",
+				"created_by_token": "",
+				"heartbeat_at":     nil,
+				"lease_expires_at": nil,
+			}
+		}
+
+		ok, err := s.taskRepo.ReleaseExpiredLease(task.ID, now, updates, requeue)
+		if err != nil {
+			return released, err
+		}
+		if !ok {
+			// 检查后工作节点恰好续约成功
+			continue
+		}
+		released++
+
+		// 广播任务更新
+		if s.hub != nil {
+			if updated, err := s.taskRepo.FindByID(task.ID); err == nil {
+				s.hub.BroadcastTaskUpdate(updated)
+			}
+		}
+	}
+
+	return released, nil
+}
+
+// FailStaleTasks 将心跳超时的执行中任务标记为失败
+func (s *crawlService) FailStaleTasks(defaultTimeout time.Duration) ([]models.CrawlTask, error) {
+	now := time.Now()
+	tasks, err := s.taskRepo.ListStale(defaultTimeout, now)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := make([]models.CrawlTask, 0, len(tasks))
+	for i := range tasks {
+		task := &tasks[i]
+
+		timeout := defaultTimeout
+		if task.HeartbeatTimeout != nil && *task.HeartbeatTimeout > 0 {
+			timeout = time.Duration(*task.HeartbeatTimeout) * time.Second
+		}
+
+		// 更新Metadata（包含错误信息）
+		metadata := make(map[string]interface{})
+		if task.Metadata != nil {
+			json.Unmarshal(task.Metadata, &metadata)
+		}
+		metadata["error"] = "heartbeat timeout"
+		metadataJSON, err := json.Marshal(metadata)
+		if err != nil {
+			return failed, err
+		}
+
+		duration := int(now.Sub(task.StartTime).Seconds())
+		updates := map[string]interface{}{
+			"status":   models.CrawlTaskStatusFailed,
+			"message":  fmt.Sprintf("超过 %s 没有心跳，任务已标记为失败", timeout),
+			"end_time": now,
+			"duration": duration,
+			"metadata": datatypes.JSON(metadataJSON),
+		}
+		if task.Status == models.CrawlTaskStatusCancelling {
+			// 等待确认取消的任务直接视为已取消
+			updates = cancelledUpdates(task, now, fmt.Sprintf("超过 %s 没有心跳，任务已取消", timeout))
+		}
+		ok, err := s.taskRepo.MarkStale(task.ID, defaultTimeout, now, updates)
+		if err != nil {
+			return failed, err
+		}
+		if !ok {
+			// 检查后任务恰好有了心跳或已结束
+			continue
+		}
+
+		updated, err := s.taskRepo.FindByID(task.ID)
+		if err != nil {
+			return failed, err
+		}
+		failed = append(failed, *updated)
+
+		// 广播任务更新
+		if s.hub != nil {
+			s.hub.BroadcastTaskUpdate(updated)
+		}
+	}
+
+	return failed, nil
+}
+
+// SendCommand 下发控制命令（管理员）
+func (s *crawlService) SendCommand(id uint, command models.CrawlTaskCommand) (*models.CrawlTask, error) {
+	task, err := s.taskRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查任务状态
+	if task.Status == models.CrawlTaskStatusCompleted {
+		return nil, ErrTaskAlreadyCompleted
+	}
+	if task.Status == models.CrawlTaskStatusFailed {
+		return nil, ErrTaskAlreadyFailed
+	}
+	if task.Status == models.CrawlTaskStatusCancelled {
+		return nil, ErrTaskAlreadyCancelled
+	}
+
+	now := time.Now()
+	var from []models.CrawlTaskStatus
+	var updates map[string]interface{}
+	switch command {
+	case models.CrawlTaskCommandPause:
+		from = []models.CrawlTaskStatus{models.CrawlTaskStatusRunning, models.CrawlTaskStatusPausing, models.CrawlTaskStatusResuming}
+		updates = map[string]interface{}{
+			"status":  models.CrawlTaskStatusPausing,
+			"message": "已请求暂停，等待爬虫确认",
+		}
+	case models.CrawlTaskCommandResume:
+		from = []models.CrawlTaskStatus{models.CrawlTaskStatusPaused, models.CrawlTaskStatusPausing, models.CrawlTaskStatusResuming}
+		updates = map[string]interface{}{
+			"status":  models.CrawlTaskStatusResuming,
+			"message": "已请求恢复，等待爬虫确认",
+		}
+	case models.CrawlTaskCommandCancel:
+		if task.Status == models.CrawlTaskStatusPending {
+			// 尚未被领取的任务直接取消
+			from = []models.CrawlTaskStatus{models.CrawlTaskStatusPending}
+			updates = map[string]interface{}{
+				"status":   models.CrawlTaskStatusCancelled,
+				"message":  "任务已取消",
+				"end_time": now,
+			}
+		} else {
+			from = models.ActiveCrawlTaskStatuses
+			updates = map[string]interface{}{
+				"status":  models.CrawlTaskStatusCancelling,
+				"message": "已请求取消，等待爬虫确认",
+			}
+		}
+	default:
+		return nil, ErrInvalidTaskCommand
+	}
+	updates["control_requested_at"] = now
+
+	// 条件更新：任务状态不允许该命令，或检查后爬虫恰好上报了完成、失败
+	ok, err := s.taskRepo.UpdateIfStatus(task.ID, from, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTaskCommandConflict
+	}
+
+	task, err = s.taskRepo.FindByID(task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 推送给爬虫并广播任务更新
+	if s.hub != nil {
+		if task.Status.PendingCommand() != "" {
+			s.hub.SendTaskCommand(task.CreatedByToken, task)
+		}
+		s.hub.BroadcastTaskUpdate(task)
+	}
+
+	return task, nil
+}
+
+// AcknowledgeCommand 爬虫确认控制命令
+func (s *crawlService) AcknowledgeCommand(taskID string, command models.CrawlTaskCommand, token string) (*models.CrawlTask, error) {
+	task, err := s.taskRepo.FindByTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证Token权限
+	if task.CreatedByToken != token {
+		return nil, ErrCrawlTaskAccessDenied
+	}
+
+	// 重复确认（如WebSocket和HTTP都确认了一次）时直接返回
+	if (command == models.CrawlTaskCommandPause && task.Status == models.CrawlTaskStatusPaused) ||
+		(command == models.CrawlTaskCommandCancel && task.Status == models.CrawlTaskStatusCancelled) {
+		return task, nil
+	}
+
+	// 检查任务状态
+	if task.Status == models.CrawlTaskStatusCompleted {
+		return nil, ErrTaskAlreadyCompleted
+	}
+	if task.Status == models.CrawlTaskStatusFailed {
+		return nil, ErrTaskAlreadyFailed
+	}
+	if task.Status == models.CrawlTaskStatusCancelled {
+		return nil, ErrTaskAlreadyCancelled
+	}
+
+	if command == "" || task.Status.PendingCommand() != command {
+		return nil, ErrTaskCommandConflict
+	}
+
+	now := time.Now()
+	var updates map[string]interface{}
+	switch command {
+	case models.CrawlTaskCommandPause:
+		updates = map[string]interface{}{
+			"status":  models.CrawlTaskStatusPaused,
+			"message": "任务已暂停",
+		}
+	case models.CrawlTaskCommandResume:
+		updates = map[string]interface{}{
+			"status":  models.CrawlTaskStatusRunning,
+			"message": "任务已恢复",
+		}
+	case models.CrawlTaskCommandCancel:
+		updates = cancelledUpdates(task, now, "任务已取消")
+	}
+	updates["control_acked_at"] = now
+	updates["heartbeat_at"] = now
+	if command != models.CrawlTaskCommandCancel && task.LeaseExpiresAt != nil && task.LeaseSeconds != nil {
+		updates["lease_expires_at"] = now.Add(time.Duration(*task.LeaseSeconds) * time.Second)
+	}
+
+	// 条件更新：检查后管理员恰好下发了新的命令时确认失败，爬虫会收到新的命令
+	ok, err := s.taskRepo.UpdateIfStatus(task.ID, []models.CrawlTaskStatus{task.Status}, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTaskCommandConflict
+	}
+
+	task, err = s.taskRepo.FindByID(task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 广播任务更新
+	if s.hub != nil {
+		s.hub.BroadcastTaskUpdate(task)
+	}
+
+	return task, nil
+}
+
+// ListPendingCommands 获取等待确认控制命令的任务
+func (s *crawlService) ListPendingCommands(token string) ([]models.CrawlTask, error) {
+	filter := &repository.CrawlTaskFilter{
+		Statuses: []models.CrawlTaskStatus{
+			models.CrawlTaskStatusPausing,
+			models.CrawlTaskStatusResuming,
+			models.CrawlTaskStatusCancelling,
+		},
+		CreatedByToken: token,
+	}
+	tasks, _, err := s.taskRepo.List(filter, 0, 100)
+	return tasks, err
+}
+
+// cancelledUpdates 任务取消时的更新字段
+func cancelledUpdates(task *models.CrawlTask, now time.Time, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"status":           models.CrawlTaskStatusCancelled,
+		"message":          message,
+		"end_time":         now,
+		"duration":         int(now.Sub(task.StartTime).Seconds()),
+		"lease_expires_at": nil,
+	}
+}
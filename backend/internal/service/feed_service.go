@@ -0,0 +1,253 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/config"
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/feed"
+	"github.com/whk-newbie/blog/internal/pkg/redis"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+const (
+	// feedCacheKeyPrefix 订阅源缓存键前缀
+	feedCacheKeyPrefix = "feed:"
+	// feedCacheTTL 订阅源缓存时间
+	feedCacheTTL = 10 * time.Minute
+	// feedItemLimit 订阅源包含的文章数
+	feedItemLimit = 20
+	// articlePathPrefix 前端文章页路径前缀
+	articlePathPrefix = "/article/"
+	// categoryPathPrefix、tagPathPrefix 前端分类、标签文章列表页路径前缀
+	categoryPathPrefix = "/categories/"
+	tagPathPrefix      = "/tags/"
+)
+
+// FeedFormat 订阅源格式
+type FeedFormat string
+
+const (
+	FeedFormatRSS  FeedFormat = "rss"  // RSS 2.0
+	FeedFormatAtom FeedFormat = "atom" // Atom 1.0
+)
+
+// FeedService 订阅源服务接口
+type FeedService interface {
+	// 生成订阅源（带缓存，文章变更时由ArticleCacheService统一失效）
+	GetFeed(req *FeedRequest) ([]byte, error)
+}
+
+// FeedRequest 订阅源请求
+type FeedRequest struct {
+	Format       FeedFormat
+	CategorySlug string // 按分类输出（可选）
+	TagSlug      string // 按标签输出（可选）
+	BaseURL      string // 站点地址（未配置site.url时使用请求的地址）
+	FeedPath     string // 订阅源自身路径，如 /feed.xml
+}
+
+// feedService 订阅源服务实现
+type feedService struct {
+	articleRepo   repository.ArticleRepository
+	categoryRepo  repository.CategoryRepository
+	tagRepo       repository.TagRepository
+	configService ConfigService
+	siteCfg       config.SiteConfig
+}
+
+// NewFeedService 创建订阅源服务
+func NewFeedService(
+	articleRepo repository.ArticleRepository,
+	categoryRepo repository.CategoryRepository,
+	tagRepo repository.TagRepository,
+	configService ConfigService,
+	siteCfg config.SiteConfig,
+) FeedService {
+	return &feedService{
+		articleRepo:   articleRepo,
+		categoryRepo:  categoryRepo,
+		tagRepo:       tagRepo,
+		configService: configService,
+		siteCfg:       siteCfg,
+	}
+}
+
+// GetFeed 生成订阅源（带缓存）
+func (s *feedService) GetFeed(req *FeedRequest) ([]byte, error) {
+	baseURL := strings.TrimRight(req.BaseURL, "/")
+	if s.siteCfg.URL != "" {
+		baseURL = strings.TrimRight(s.siteCfg.URL, "/")
+	}
+
+	f, err := s.loadFeed(req)
+	if err != nil {
+		return nil, err
+	}
+	withBaseURL(f, baseURL)
+	f.FeedURL = baseURL + req.FeedPath
+
+	switch req.Format {
+	case FeedFormatAtom:
+		return feed.Atom(f)
+	default:
+		return feed.RSS(f)
+	}
+}
+
+// loadFeed 获取订阅源内容（带缓存）
+// 缓存中的链接只保存路径，输出时再加上站点地址，缓存键与请求的Host无关
+func (s *feedService) loadFeed(req *FeedRequest) (*feed.Feed, error) {
+	cacheKey := fmt.Sprintf("%scat:%s:tag:%s", feedCacheKeyPrefix, req.CategorySlug, req.TagSlug)
+	if cached, err := redis.GetValue(cacheKey); err == nil {
+		var f feed.Feed
+		if err := json.Unmarshal([]byte(cached), &f); err == nil {
+			return &f, nil
+		}
+	}
+
+	siteTitle, siteDescription := s.siteInfo()
+	f := &feed.Feed{
+		Title:       siteTitle,
+		Link:        "/",
+		Description: siteDescription,
+		Author:      siteTitle,
+		Language:    s.siteCfg.Language,
+	}
+
+	// 按分类或标签筛选
+	filter := &repository.ArticleFilter{}
+	if req.CategorySlug != "" {
+		category, err := s.categoryRepo.FindBySlug(req.CategorySlug)
+		if err != nil {
+			return nil, err
+		}
+		filter.CategoryID = &category.ID
+		f.Title = fmt.Sprintf("%s - %s", siteTitle, category.Name)
+		if category.Description != "" {
+			f.Description = category.Description
+		}
+	}
+	if req.TagSlug != "" {
+		tag, err := s.tagRepo.FindBySlug(req.TagSlug)
+		if err != nil {
+			return nil, err
+		}
+		filter.TagID = &tag.ID
+		f.Title = fmt.Sprintf("%s - #%s", siteTitle, tag.Name)
+	}
+
+	// ListPublished 已排除发布时间在未来的定时文章
+	articles, _, err := s.articleRepo.ListPublished(filter, 0, feedItemLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, article := range articles {
+		f.Items = append(f.Items, s.toFeedItem(&article))
+		if article.UpdatedAt.After(f.Updated) {
+			f.Updated = article.UpdatedAt
+		}
+	}
+	if f.Updated.IsZero() {
+		f.Updated = time.Now()
+	}
+
+	if data, err := json.Marshal(f); err == nil {
+		_ = redis.Set(cacheKey, string(data), feedCacheTTL)
+	}
+	return f, nil
+}
+
+// toFeedItem 将文章转换为订阅条目（链接为站内路径）
+func (s *feedService) toFeedItem(article *models.Article) feed.Item {
+	link := articlePathPrefix + article.Slug
+
+	item := feed.Item{
+		ID:      link,
+		Title:   article.Title,
+		Link:    link,
+		Summary: article.Summary,
+		Content: article.Content,
+		Updated: article.UpdatedAt,
+		Image:   article.CoverImage,
+	}
+
+	if article.PublishAt != nil {
+		item.Published = *article.PublishAt
+	} else {
+		item.Published = article.CreatedAt
+	}
+
+	if article.Category != nil {
+		item.Categories = append(item.Categories, article.Category.Name)
+	}
+	for _, tag := range article.Tags {
+		item.Categories = append(item.Categories, tag.Name)
+	}
+
+	return item
+}
+
+// withBaseURL 将订阅源中的站内路径转换为绝对地址
+func withBaseURL(f *feed.Feed, baseURL string) {
+	f.Link = absoluteURL(f.Link, baseURL)
+	for i := range f.Items {
+		item := &f.Items[i]
+		item.ID = absoluteURL(item.ID, baseURL)
+		item.Link = absoluteURL(item.Link, baseURL)
+		item.Content = absolutizeURLs(item.Content, baseURL)
+		if item.Image != "" {
+			item.Image = absoluteURL(item.Image, baseURL)
+		}
+	}
+}
+
+// siteInfo 从站点配置读取博客标题和描述
+func (s *feedService) siteInfo() (string, string) {
+	title := "我的博客"
+	description := ""
+
+	if s.configService == nil {
+		return title, description
+	}
+
+	value, err := s.configService.GetConfigValue(models.ConfigTypeSiteInfo)
+	if err != nil {
+		return title, description
+	}
+
+	var info map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &info); err != nil {
+		return title, description
+	}
+	if v, ok := info["blogTitle"].(string); ok && v != "" {
+		title = v
+	}
+	if v, ok := info["blogDescription"].(string); ok {
+		description = v
+	}
+
+	return title, description
+}
+
+// absoluteURL 将站内相对路径转换为绝对地址
+func absoluteURL(u, baseURL string) string {
+	if strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") {
+		return baseURL + u
+	}
+	return u
+}
+
+// absolutizeURLs 将HTML中以 / 开头的src/href转换为绝对地址，便于阅读器加载图片
+func absolutizeURLs(html, baseURL string) string {
+	replacer := strings.NewReplacer(
+		`src="/uploads/`, `src="`+baseURL+`/uploads/`,
+		`href="/uploads/`, `href="`+baseURL+`/uploads/`,
+		`href="/article/`, `href="`+baseURL+`/article/`,
+	)
+	return replacer.Replace(html)
+}
@@ -92,6 +92,7 @@ func (s *fingerprintService) CollectFingerprint(fingerprintData interface{}, use
 		FingerprintHash: hash,
 		FingerprintData: datatypes.JSON(jsonBytes),
 		UserAgent:       userAgent,
+		ClientInfo:      parseClientInfo(userAgent),
 		FirstSeenAt:     now,
 		LastSeenAt:      now,
 		VisitCount:      1,
@@ -158,6 +159,7 @@ func (s *fingerprintService) Update(id uint, userAgent string) error {
 	}
 
 	fingerprint.UserAgent = userAgent
+	fingerprint.ClientInfo = parseClientInfo(userAgent)
 	return s.fingerprintRepo.Update(fingerprint)
 }
 
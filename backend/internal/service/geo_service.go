@@ -0,0 +1,114 @@
+package service
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/geoip"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+// GeoService 访问地区（离线GeoIP）服务接口
+type GeoService interface {
+	// 解析客户端IP，返回写入访问记录的IP（开启截断时为截断后的IP）和归属地
+	// 未配置数据库时只返回IP，归属地为空
+	Resolve(ip string) (string, models.GeoInfo)
+	// 获取地区分布（结束日期包含当天），countryCode不为空时省/州和城市只统计该国家/地区
+	GetGeoStats(startDate, endDate time.Time, countryCode string, limit int) (*GeoStatsResponse, error)
+}
+
+// GeoStatsResponse 地区分布
+type GeoStatsResponse struct {
+	Enabled      bool                 `json:"enabled"`                 // 是否配置了GeoIP数据库，未配置时新的访问记录不解析归属地
+	DatabaseType string               `json:"database_type,omitempty"` // 数据库类型（如 GeoLite2-City）
+	Countries    []repository.GeoStat `json:"countries"`               // 国家/地区
+	Regions      []repository.GeoStat `json:"regions"`                 // 省/州
+	Cities       []repository.GeoStat `json:"cities"`                  // 城市
+}
+
+// geoService 访问地区服务实现
+type geoService struct {
+	geoStatsRepo repository.GeoStatsRepository
+	reader       *geoip.Reader
+	truncateIP   bool
+}
+
+// NewGeoService 创建访问地区服务（reader为nil时不解析归属地，truncateIP为true时写入截断后的IP）
+func NewGeoService(geoStatsRepo repository.GeoStatsRepository, reader *geoip.Reader, truncateIP bool) GeoService {
+	return &geoService{
+		geoStatsRepo: geoStatsRepo,
+		reader:       reader,
+		truncateIP:   truncateIP,
+	}
+}
+
+// Resolve 解析客户端IP
+func (s *geoService) Resolve(ip string) (string, models.GeoInfo) {
+	ip = strings.TrimSpace(ip)
+	if ip == "" {
+		return "", models.GeoInfo{}
+	}
+
+	// 用完整IP查询归属地，截断只影响写入的IP
+	var geo models.GeoInfo
+	loc, err := s.reader.Lookup(ip)
+	if err != nil {
+		log.Printf("Failed to look up geo location of %s: %v", ip, err)
+	}
+	if loc != nil {
+		geo = models.GeoInfo{
+			CountryCode: truncateRunes(loc.CountryCode, 2),
+			Country:     truncateRunes(loc.Country, 100),
+			Region:      truncateRunes(loc.Region, 100),
+			City:        truncateRunes(loc.City, 100),
+		}
+	}
+
+	if s.truncateIP {
+		ip = geoip.TruncateIP(ip)
+	}
+	return truncateRunes(ip, 45), geo
+}
+
+// GetGeoStats 获取地区分布
+func (s *geoService) GetGeoStats(startDate, endDate time.Time, countryCode string, limit int) (*GeoStatsResponse, error) {
+	if startDate.IsZero() {
+		startDate = time.Now().AddDate(0, 0, -30)
+	}
+	if endDate.IsZero() {
+		endDate = time.Now()
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	countryCode = strings.ToUpper(strings.TrimSpace(countryCode))
+
+	start := localDay(startDate)
+	end := localDay(endDate).AddDate(0, 0, 1)
+
+	countries, err := s.geoStatsRepo.GetBreakdown(repository.GeoLevelCountry, "", start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	regions, err := s.geoStatsRepo.GetBreakdown(repository.GeoLevelRegion, countryCode, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	cities, err := s.geoStatsRepo.GetBreakdown(repository.GeoLevelCity, countryCode, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeoStatsResponse{
+		Enabled:      s.reader.Enabled(),
+		DatabaseType: s.reader.DatabaseType(),
+		Countries:    countries,
+		Regions:      regions,
+		Cities:       cities,
+	}, nil
+}
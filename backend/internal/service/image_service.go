@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+	imgutil "github.com/whk-newbie/blog/internal/pkg/image"
+	"github.com/whk-newbie/blog/internal/pkg/storage"
+)
+
+// ImageURLPrefix 图片缩放接口路径前缀（/img/{宽度}/{对象key}）
+const ImageURLPrefix = "/img"
+
+var (
+	ErrImageWidthNotAllowed = errors.New("image width is not allowed")
+	ErrImageNotFound        = errors.New("image not found")
+	ErrImageUnsupported     = errors.New("image format is not supported")
+)
+
+// ImageService 图片缩放服务接口
+// 按白名单中的宽度实时缩放上传的图片，结果缓存在本地磁盘（每个实例各自缓存）
+type ImageService interface {
+	// 获取指定宽度的图片，返回缓存文件路径；format 为空时使用原图格式，可指定 webp
+	Resize(key string, width int, format string) (string, error)
+	// 删除图片的全部缓存（文件删除后调用）
+	Purge(key string)
+	// 允许的宽度
+	AllowedWidths() []int
+}
+
+// imageService 图片缩放服务实现
+type imageService struct {
+	backend  storage.Backend
+	cacheDir string
+	widths   []int
+	allowed  map[int]bool
+}
+
+// NewImageService 创建图片缩放服务
+func NewImageService(backend storage.Backend, cacheDir string, widths []int) ImageService {
+	if cacheDir == "" {
+		cacheDir = "./cache/images"
+	}
+	widths = imgutil.NormalizeWidths(widths)
+	if len(widths) == 0 {
+		widths = imgutil.DefaultVariantWidths
+	}
+
+	allowed := make(map[int]bool, len(widths))
+	for _, w := range widths {
+		allowed[w] = true
+	}
+
+	return &imageService{
+		backend:  backend,
+		cacheDir: cacheDir,
+		widths:   widths,
+		allowed:  allowed,
+	}
+}
+
+// Resize 获取指定宽度的图片（优先读取磁盘缓存）
+func (s *imageService) Resize(key string, width int, format string) (string, error) {
+	if !s.allowed[width] {
+		return "", ErrImageWidthNotAllowed
+	}
+
+	key, err := storage.CleanKey(key)
+	if err != nil {
+		return "", ErrImageNotFound
+	}
+	srcFormat, ok := imgutil.FormatFromKey(key)
+	if !ok || !imgutil.Resizable(srcFormat) {
+		return "", ErrImageUnsupported
+	}
+	switch format {
+	case "", srcFormat:
+		format = srcFormat
+	case imgutil.FormatWebP:
+	default:
+		return "", ErrImageUnsupported
+	}
+
+	cachePath := s.cachePath(key, width, format)
+	if _, err := os.Stat(cachePath); err == nil {
+		return cachePath, nil
+	}
+
+	reader, err := s.backend.Get(context.Background(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", ErrImageNotFound
+		}
+		return "", err
+	}
+	defer reader.Close()
+
+	// 早期上传的图片未做方向规范化，按EXIF方向解码
+	src, err := imaging.Decode(reader, imaging.AutoOrientation(true))
+	if err != nil {
+		return "", ErrImageUnsupported
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// 先写临时文件再重命名，并发请求同一尺寸时不会读到写了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), ".resize-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if err := imgutil.Encode(tmp, imgutil.Resize(src, width), format); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, cachePath); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	return cachePath, nil
+}
+
+// Purge 删除图片的全部缓存
+func (s *imageService) Purge(key string) {
+	key, err := storage.CleanKey(key)
+	if err != nil {
+		return
+	}
+	for _, width := range s.widths {
+		for _, format := range []string{imgutil.FormatJPEG, imgutil.FormatPNG, imgutil.FormatWebP} {
+			_ = os.Remove(s.cachePath(key, width, format))
+		}
+	}
+}
+
+// AllowedWidths 允许的宽度
+func (s *imageService) AllowedWidths() []int {
+	return s.widths
+}
+
+// cachePath 缓存文件路径：{cacheDir}/{width}/{key}，输出格式与原图不同时追加扩展名
+func (s *imageService) cachePath(key string, width int, format string) string {
+	p := filepath.Join(s.cacheDir, strconv.Itoa(width), filepath.FromSlash(key))
+	if srcFormat, _ := imgutil.FormatFromKey(key); srcFormat != format {
+		p += imgutil.Extension(format)
+	}
+	return p
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/redis"
+)
+
+const (
+	// loginFailKeyPrefix 登录失败计数缓存键前缀（auth:login_fail:{type}:{value}）
+	loginFailKeyPrefix = "auth:login_fail:"
+	// loginLockKeyPrefix 登录锁定缓存键前缀（auth:login_lock:{type}:{value}）
+	loginLockKeyPrefix = "auth:login_lock:"
+)
+
+// 锁定对象类型
+const (
+	LoginLockTypeUsername = "username" // 按用户名锁定
+	LoginLockTypeIP       = "ip"       // 按IP锁定
+)
+
+var (
+	ErrInvalidLockType   = errors.New("invalid login lock type")
+	ErrLoginLockNotFound = errors.New("login lock not found")
+)
+
+// LoginLockedError 登录已被临时锁定
+type LoginLockedError struct {
+	Type       string        // 锁定对象类型
+	RetryAfter time.Duration // 剩余锁定时间
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("login locked by %s, retry after %s", e.Type, e.RetryAfter)
+}
+
+// LoginGuardConfig 登录防暴力破解配置
+type LoginGuardConfig struct {
+	// 同一用户名连续失败多少次后锁定
+	UsernameThreshold int
+	// 同一IP连续失败多少次后锁定
+	IPThreshold int
+	// 失败计数的统计窗口（最后一次失败后超过该时间未再失败则清零）
+	FailureWindow time.Duration
+	// 首次锁定时长，之后每多失败一次翻倍
+	BaseLockDuration time.Duration
+	// 最长锁定时长
+	MaxLockDuration time.Duration
+}
+
+// LoginGuardService 登录防暴力破解服务接口
+type LoginGuardService interface {
+	// 检查用户名和IP是否处于锁定状态，锁定时返回 *LoginLockedError
+	Check(username, ip string) error
+	// 记录一次登录失败，达到阈值时锁定
+	RecordFailure(username, ip string)
+	// 登录成功后清除用户名的失败计数
+	RecordSuccess(username string)
+	// 获取当前所有锁定
+	ListLocks() ([]*LoginLock, error)
+	// 解除锁定（同时清除失败计数）
+	Unlock(lockType, value string) error
+}
+
+// LoginLock 登录锁定信息
+type LoginLock struct {
+	Type      string    `json:"type"`      // username 或 ip
+	Value     string    `json:"value"`     // 用户名或IP
+	Failures  int64     `json:"failures"`  // 锁定时的连续失败次数
+	LockedAt  time.Time `json:"locked_at"` // 锁定时间
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UnlockLoginRequest 解除锁定请求
+type UnlockLoginRequest struct {
+	Type  string `json:"type" binding:"required,oneof=username ip" example:"username"`
+	Value string `json:"value" binding:"required" example:"admin"`
+}
+
+// loginGuardService 登录防暴力破解服务实现
+type loginGuardService struct {
+	logService LogService
+	config     LoginGuardConfig
+}
+
+// NewLoginGuardService 创建登录防暴力破解服务
+func NewLoginGuardService(logService LogService, config LoginGuardConfig) LoginGuardService {
+	if config.UsernameThreshold <= 0 {
+		config.UsernameThreshold = 5
+	}
+	if config.IPThreshold <= 0 {
+		config.IPThreshold = 20
+	}
+	if config.FailureWindow <= 0 {
+		config.FailureWindow = time.Hour
+	}
+	if config.BaseLockDuration <= 0 {
+		config.BaseLockDuration = time.Minute
+	}
+	if config.MaxLockDuration <= 0 {
+		config.MaxLockDuration = time.Hour
+	}
+	return &loginGuardService{
+		logService: logService,
+		config:     config,
+	}
+}
+
+// Check 检查用户名和IP是否处于锁定状态（Redis不可用时放行）
+func (s *loginGuardService) Check(username, ip string) error {
+	for _, target := range s.targets(username, ip) {
+		ttl, err := redis.Get().TTL(context.Background(), loginLockKeyPrefix+target.key).Result()
+		if err != nil {
+			log.Printf("Failed to check login lock: %v", err)
+			continue
+		}
+		// 键不存在时返回负值
+		if ttl > 0 {
+			return &LoginLockedError{Type: target.lockType, RetryAfter: ttl}
+		}
+	}
+	return nil
+}
+
+// RecordFailure 记录一次登录失败
+// 达到阈值后锁定，锁定时长为 BaseLockDuration * 2^(失败次数-阈值)，不超过 MaxLockDuration
+func (s *loginGuardService) RecordFailure(username, ip string) {
+	ctx := context.Background()
+
+	for _, target := range s.targets(username, ip) {
+		failKey := loginFailKeyPrefix + target.key
+		failures, err := redis.Get().Incr(ctx, failKey).Result()
+		if err != nil {
+			log.Printf("Failed to record login failure: %v", err)
+			continue
+		}
+		_ = redis.Expire(failKey, s.config.FailureWindow)
+
+		if failures < int64(target.threshold) {
+			continue
+		}
+
+		duration := s.lockDuration(failures - int64(target.threshold))
+		now := time.Now()
+		lock := &LoginLock{
+			Type:      target.lockType,
+			Value:     target.value,
+			Failures:  failures,
+			LockedAt:  now,
+			ExpiresAt: now.Add(duration),
+		}
+		data, err := json.Marshal(lock)
+		if err != nil {
+			continue
+		}
+		if err := redis.Set(loginLockKeyPrefix+target.key, string(data), duration); err != nil {
+			log.Printf("Failed to lock login: %v", err)
+			continue
+		}
+		// 失败计数至少保留到锁定结束之后，保证下一次失败继续延长锁定
+		if duration*2 > s.config.FailureWindow {
+			_ = redis.Expire(failKey, duration*2)
+		}
+
+		s.logLock(lock, ip)
+	}
+}
+
+// RecordSuccess 登录成功后清除用户名的失败计数
+// IP的失败计数不清除，避免攻击者用自己的账号登录来重置对其他账号的尝试次数
+func (s *loginGuardService) RecordSuccess(username string) {
+	if username = strings.TrimSpace(username); username == "" {
+		return
+	}
+	_ = redis.Del(loginFailKeyPrefix + LoginLockTypeUsername + ":" + username)
+}
+
+// ListLocks 获取当前所有锁定（按锁定时间倒序）
+func (s *loginGuardService) ListLocks() ([]*LoginLock, error) {
+	ctx := context.Background()
+	locks := make([]*LoginLock, 0)
+
+	iter := redis.Get().Scan(ctx, 0, loginLockKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := redis.GetValue(iter.Val())
+		if err != nil {
+			// 扫描期间已过期
+			continue
+		}
+		var lock LoginLock
+		if err := json.Unmarshal([]byte(data), &lock); err != nil {
+			continue
+		}
+		locks = append(locks, &lock)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].LockedAt.After(locks[j].LockedAt)
+	})
+	return locks, nil
+}
+
+// Unlock 解除锁定
+func (s *loginGuardService) Unlock(lockType, value string) error {
+	if lockType != LoginLockTypeUsername && lockType != LoginLockTypeIP {
+		return ErrInvalidLockType
+	}
+
+	key := lockType + ":" + strings.TrimSpace(value)
+	count, err := redis.Exists(loginLockKeyPrefix+key, loginFailKeyPrefix+key)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLoginLockNotFound
+	}
+
+	return redis.Del(loginLockKeyPrefix+key, loginFailKeyPrefix+key)
+}
+
+// guardTarget 计数对象
+type guardTarget struct {
+	lockType  string
+	value     string
+	key       string
+	threshold int
+}
+
+// targets 本次登录需要计数的对象
+func (s *loginGuardService) targets(username, ip string) []guardTarget {
+	targets := make([]guardTarget, 0, 2)
+	if username = strings.TrimSpace(username); username != "" {
+		targets = append(targets, guardTarget{
+			lockType:  LoginLockTypeUsername,
+			value:     username,
+			key:       LoginLockTypeUsername + ":" + username,
+			threshold: s.config.UsernameThreshold,
+		})
+	}
+	if ip != "" {
+		targets = append(targets, guardTarget{
+			lockType:  LoginLockTypeIP,
+			value:     ip,
+			key:       LoginLockTypeIP + ":" + ip,
+			threshold: s.config.IPThreshold,
+		})
+	}
+	return targets
+}
+
+// lockDuration 计算锁定时长（指数退避）
+func (s *loginGuardService) lockDuration(extra int64) time.Duration {
+	duration := s.config.BaseLockDuration
+	for i := int64(0); i < extra && duration < s.config.MaxLockDuration; i++ {
+		duration *= 2
+	}
+	if duration > s.config.MaxLockDuration {
+		duration = s.config.MaxLockDuration
+	}
+	return duration
+}
+
+// logLock 将锁定事件写入系统日志
+func (s *loginGuardService) logLock(lock *LoginLock, ip string) {
+	if s.logService == nil {
+		return
+	}
+	message := fmt.Sprintf("Login locked for %s %s after %d failed attempts", lock.Type, lock.Value, lock.Failures)
+	logContext := map[string]interface{}{
+		"type":       lock.Type,
+		"value":      lock.Value,
+		"failures":   lock.Failures,
+		"duration":   lock.ExpiresAt.Sub(lock.LockedAt).String(),
+		"expires_at": lock.ExpiresAt.Format(time.RFC3339),
+	}
+	if err := s.logService.Log(models.LogLevelWarn, message, "auth", logContext, nil, ip); err != nil {
+		log.Printf("Failed to write login lock log: %v", err)
+	}
+}
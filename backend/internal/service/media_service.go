@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/storage"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+var (
+	ErrMediaNotFound = repository.ErrMediaNotFound
+	ErrMediaInUse    = errors.New("media is referenced by articles")
+)
+
+// mediaAttrPattern 正文中可能引用文件的属性（src/href/poster/data-src/srcset）
+var mediaAttrPattern = regexp.MustCompile(`(?i)\b(?:src|href|poster|data-src|srcset)\s*=\s*["']([^"']+)["']`)
+
+// MediaService 媒体库服务接口
+type MediaService interface {
+	// 记录上传的文件
+	Record(media *models.Media) error
+	// 获取媒体列表
+	List(req *MediaListRequest) (*MediaListResponse, error)
+	// 获取媒体详情（含引用该文件的文章）
+	Get(id uint) (*MediaDetail, error)
+	// 删除媒体（仍被引用时需要 force）
+	Delete(id uint, force bool) error
+	// 扫描全部文章的正文和封面，重建引用记录
+	ScanReferences() (*MediaScanResult, error)
+	// 获取上传超过 minAge 且未被任何文章引用的文件
+	FindOrphans(minAge time.Duration) (*MediaOrphanReport, error)
+	// 删除上传超过 minAge 且未被任何文章引用的文件
+	RemoveOrphans(minAge time.Duration) (*MediaCleanupResult, error)
+}
+
+// MediaListRequest 媒体列表请求
+type MediaListRequest struct {
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	Keyword    string `json:"keyword"`     // 搜索原始文件名或对象key
+	MimeType   string `json:"mime_type"`   // 文件类型前缀，如 image/
+	UploaderID *uint  `json:"uploader_id"` // 上传者
+	Unused     *bool  `json:"unused"`      // true 只看未被引用的文件
+}
+
+// MediaListResponse 媒体列表响应
+type MediaListResponse struct {
+	Items      []models.Media `json:"items"`
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"page_size"`
+	TotalPages int            `json:"total_pages"`
+}
+
+// MediaDetail 媒体详情
+type MediaDetail struct {
+	*models.Media
+	References []models.MediaReference `json:"references"`
+}
+
+// MediaScanResult 引用扫描结果
+type MediaScanResult struct {
+	Articles   int       `json:"articles"`   // 扫描的文章数
+	Media      int       `json:"media"`      // 媒体总数
+	References int       `json:"references"` // 引用记录数
+	Unused     int       `json:"unused"`     // 未被引用的媒体数
+	ScannedAt  time.Time `json:"scanned_at"`
+}
+
+// MediaOrphanReport 未引用文件报告
+type MediaOrphanReport struct {
+	Count     int            `json:"count"`
+	TotalSize int64          `json:"total_size"` // 合计大小（字节）
+	Items     []models.Media `json:"items"`
+}
+
+// MediaCleanupResult 未引用文件清理结果
+type MediaCleanupResult struct {
+	Removed    int   `json:"removed"`     // 删除的文件数
+	Failed     int   `json:"failed"`      // 删除失败的文件数
+	FreedBytes int64 `json:"freed_bytes"` // 释放的空间（字节）
+}
+
+// mediaService 媒体库服务实现
+type mediaService struct {
+	mediaRepo    repository.MediaRepository
+	articleRepo  repository.ArticleRepository
+	revisionRepo repository.ArticleRevisionRepository
+	backend      storage.Backend
+	imageService ImageService
+}
+
+// NewMediaService 创建媒体库服务
+func NewMediaService(mediaRepo repository.MediaRepository, articleRepo repository.ArticleRepository, revisionRepo repository.ArticleRevisionRepository, backend storage.Backend, imageService ImageService) MediaService {
+	return &mediaService{
+		mediaRepo:    mediaRepo,
+		articleRepo:  articleRepo,
+		revisionRepo: revisionRepo,
+		backend:      backend,
+		imageService: imageService,
+	}
+}
+
+// Record 记录上传的文件
+func (s *mediaService) Record(media *models.Media) error {
+	return s.mediaRepo.Create(media)
+}
+
+// List 获取媒体列表
+func (s *mediaService) List(req *MediaListRequest) (*MediaListResponse, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	filter := &repository.MediaFilter{
+		Keyword:    strings.TrimSpace(req.Keyword),
+		MimeType:   strings.TrimSpace(req.MimeType),
+		UploaderID: req.UploaderID,
+		Unused:     req.Unused,
+	}
+
+	offset := (page - 1) * pageSize
+	items, total, err := s.mediaRepo.List(filter, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	return &MediaListResponse{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// Get 获取媒体详情
+func (s *mediaService) Get(id uint) (*MediaDetail, error) {
+	media, err := s.mediaRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := s.mediaRepo.ListReferences(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MediaDetail{Media: media, References: refs}, nil
+}
+
+// Delete 删除媒体：先删除存储中的文件（含宽度变体和缩放缓存），再删除记录
+func (s *mediaService) Delete(id uint, force bool) error {
+	media, err := s.mediaRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		refs, err := s.mediaRepo.ListReferences(id)
+		if err != nil {
+			return err
+		}
+		if len(refs) > 0 {
+			return ErrMediaInUse
+		}
+	}
+
+	if err := s.removeFiles(media); err != nil {
+		return err
+	}
+
+	return s.mediaRepo.Delete(id)
+}
+
+// ScanReferences 扫描全部文章的正文和封面，重建引用记录
+func (s *mediaService) ScanReferences() (*MediaScanResult, error) {
+	const batchSize = 100
+
+	scannedAt := time.Now()
+	items, err := s.mediaRepo.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	index := newMediaIndex(items)
+
+	result := &MediaScanResult{Media: len(items), ScannedAt: scannedAt}
+	refs := make([]models.MediaReference, 0)
+	referenced := make(map[uint]bool)
+
+	var afterID uint
+	for {
+		articles, err := s.articleRepo.ListForMediaScan(afterID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(articles) == 0 {
+			break
+		}
+
+		for i := range articles {
+			article := &articles[i]
+			afterID = article.ID
+			result.Articles++
+
+			seen := make(map[uint]bool)
+			for _, mediaID := range index.lookup(extractMediaURLs(article.Content)) {
+				if !seen[mediaID] {
+					seen[mediaID] = true
+					refs = append(refs, models.MediaReference{MediaID: mediaID, ArticleID: article.ID, Field: models.MediaReferenceContent})
+				}
+				referenced[mediaID] = true
+			}
+			if article.CoverImage != "" {
+				for _, mediaID := range index.lookup([]string{article.CoverImage}) {
+					refs = append(refs, models.MediaReference{MediaID: mediaID, ArticleID: article.ID, Field: models.MediaReferenceCover})
+					referenced[mediaID] = true
+				}
+			}
+		}
+	}
+
+	// 修订快照可以恢复为文章内容，其中引用的文件同样视为被引用（每篇文章每个文件只记录一次）
+	revisionRefs := make(map[models.MediaReference]bool)
+	afterID = 0
+	for {
+		revisions, err := s.revisionRepo.ListForMediaScan(afterID, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(revisions) == 0 {
+			break
+		}
+
+		for i := range revisions {
+			revision := &revisions[i]
+			afterID = revision.ID
+
+			urls := extractMediaURLs(revision.Content)
+			if revision.CoverImage != "" {
+				urls = append(urls, revision.CoverImage)
+			}
+			for _, mediaID := range index.lookup(urls) {
+				ref := models.MediaReference{MediaID: mediaID, ArticleID: revision.ArticleID, Field: models.MediaReferenceRevision}
+				if !revisionRefs[ref] {
+					revisionRefs[ref] = true
+					refs = append(refs, ref)
+				}
+				referenced[mediaID] = true
+			}
+		}
+	}
+
+	if err := s.mediaRepo.ReplaceReferences(refs, scannedAt); err != nil {
+		return nil, err
+	}
+
+	result.References = len(refs)
+	result.Unused = len(items) - len(referenced)
+	return result, nil
+}
+
+// FindOrphans 获取未被引用的文件
+func (s *mediaService) FindOrphans(minAge time.Duration) (*MediaOrphanReport, error) {
+	items, err := s.mediaRepo.ListOrphans(time.Now().Add(-minAge))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MediaOrphanReport{Count: len(items), Items: items}
+	for _, item := range items {
+		report.TotalSize += item.Size
+	}
+	return report, nil
+}
+
+// RemoveOrphans 删除未被引用的文件（单个文件删除失败不影响其他文件）
+func (s *mediaService) RemoveOrphans(minAge time.Duration) (*MediaCleanupResult, error) {
+	items, err := s.mediaRepo.ListOrphans(time.Now().Add(-minAge))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MediaCleanupResult{}
+	for i := range items {
+		item := &items[i]
+		if err := s.removeFiles(item); err != nil {
+			log.Printf("Failed to delete orphan media %s: %v", item.StorageKey, err)
+			result.Failed++
+			continue
+		}
+		if err := s.mediaRepo.Delete(item.ID); err != nil && err != ErrMediaNotFound {
+			log.Printf("Failed to delete orphan media record %d: %v", item.ID, err)
+			result.Failed++
+			continue
+		}
+		result.Removed++
+		result.FreedBytes += item.Size
+	}
+	return result, nil
+}
+
+// removeFiles 删除媒体在存储中的文件（宽度变体删除失败只记录日志）及缩放缓存
+func (s *mediaService) removeFiles(media *models.Media) error {
+	ctx := context.Background()
+	for _, variant := range media.VariantList() {
+		if err := s.backend.Delete(ctx, variant.Key); err != nil {
+			log.Printf("Failed to delete media variant %s: %v", variant.Key, err)
+		}
+	}
+	if err := s.backend.Delete(ctx, media.StorageKey); err != nil {
+		return err
+	}
+	if s.imageService != nil {
+		s.imageService.Purge(media.StorageKey)
+	}
+	return nil
+}
+
+// mediaIndex 按访问地址和对象key查找媒体
+type mediaIndex struct {
+	byURL map[string]uint
+	byKey map[string]uint
+}
+
+// newMediaIndex 创建媒体索引
+func newMediaIndex(items []models.Media) *mediaIndex {
+	index := &mediaIndex{
+		byURL: make(map[string]uint, len(items)),
+		byKey: make(map[string]uint, len(items)),
+	}
+	for _, item := range items {
+		index.byURL[item.URL] = item.ID
+		index.byKey[item.StorageKey] = item.ID
+		for _, variant := range item.VariantList() {
+			index.byURL[variant.URL] = item.ID
+			index.byKey[variant.Key] = item.ID
+		}
+	}
+	return index
+}
+
+// lookup 返回地址列表中引用到的媒体ID
+func (idx *mediaIndex) lookup(urls []string) []uint {
+	ids := make([]uint, 0)
+	for _, raw := range urls {
+		if id, ok := idx.match(raw); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// match 匹配单个地址：先按完整地址匹配（对象存储公开地址），再按 /uploads/ 或 /img/{宽度}/ 之后的对象key匹配
+func (idx *mediaIndex) match(raw string) (uint, bool) {
+	raw = strings.TrimSpace(raw)
+	if i := strings.IndexAny(raw, "?#"); i >= 0 {
+		raw = raw[:i]
+	}
+	if raw == "" {
+		return 0, false
+	}
+	if unescaped, err := url.PathUnescape(raw); err == nil {
+		raw = unescaped
+	}
+
+	if id, ok := idx.byURL[raw]; ok {
+		return id, true
+	}
+	if i := strings.Index(raw, storage.URLPrefix+"/"); i >= 0 {
+		if id, ok := idx.byKey[raw[i+len(storage.URLPrefix)+1:]]; ok {
+			return id, true
+		}
+	}
+	if i := strings.Index(raw, ImageURLPrefix+"/"); i >= 0 {
+		// 跳过宽度段
+		rest := raw[i+len(ImageURLPrefix)+1:]
+		if j := strings.Index(rest, "/"); j >= 0 {
+			if id, ok := idx.byKey[rest[j+1:]]; ok {
+				return id, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// extractMediaURLs 提取HTML正文中引用的地址（srcset按逗号拆分并去掉宽度描述）
+func extractMediaURLs(content string) []string {
+	urls := make([]string, 0)
+	for _, match := range mediaAttrPattern.FindAllStringSubmatch(content, -1) {
+		value := match[1]
+		if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(match[0])), "srcset") {
+			urls = append(urls, value)
+			continue
+		}
+		for _, candidate := range strings.Split(value, ",") {
+			fields := strings.Fields(candidate)
+			if len(fields) > 0 {
+				urls = append(urls, fields[0])
+			}
+		}
+	}
+	return urls
+}
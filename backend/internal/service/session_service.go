@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/jwt"
+	"github.com/whk-newbie/blog/internal/pkg/redis"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+const (
+	// sessionKeyPrefix 登录会话缓存键前缀
+	sessionKeyPrefix = "auth:session:"
+	// userSessionsKeyPrefix 账号的会话集合缓存键前缀
+	userSessionsKeyPrefix = "auth:sessions:"
+	// refreshTokenKeyPrefix 刷新Token缓存键前缀（键中为Token的SHA-256哈希）
+	refreshTokenKeyPrefix = "auth:refresh:"
+	// refreshUsedKeyPrefix 已使用的刷新Token标记缓存键前缀
+	refreshUsedKeyPrefix = "auth:refresh_used:"
+	// denylistKeyPrefix 已吊销的访问Token（jti）缓存键前缀
+	denylistKeyPrefix = "auth:denylist:"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+)
+
+// SessionService 登录会话服务接口
+// 每次登录创建一个会话：访问Token为短期JWT，刷新Token为存储在Redis中的随机串，
+// 刷新时轮换刷新Token；已使用过的刷新Token再次出现时视为被盗用，吊销整个会话
+type SessionService interface {
+	// 为账号创建新会话
+	Create(admin *models.Admin) (*SessionTokens, error)
+	// 使用刷新Token轮换会话，返回新的Token和最新的账号信息
+	Refresh(refreshToken string) (*SessionTokens, *models.Admin, error)
+	// 吊销指定会话
+	Revoke(sessionID string) error
+	// 吊销账号的所有会话（exceptSessionID 不为空时保留该会话），返回吊销数量
+	RevokeAll(adminID uint, exceptSessionID string) (int, error)
+	// 访问Token是否已吊销（实现 jwt.Denylist）
+	IsRevoked(jti string) bool
+}
+
+// SessionTokens 会话Token
+type SessionTokens struct {
+	SessionID        string
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// sessionRecord 会话记录
+type sessionRecord struct {
+	AdminID         uint      `json:"admin_id"`
+	RefreshHash     string    `json:"refresh_hash"`      // 当前有效的刷新Token哈希
+	AccessJTI       string    `json:"access_jti"`        // 当前访问Token的jti
+	AccessExpiresAt time.Time `json:"access_expires_at"` // 当前访问Token过期时间
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// refreshRecord 刷新Token记录
+type refreshRecord struct {
+	AdminID   uint   `json:"admin_id"`
+	SessionID string `json:"session_id"`
+}
+
+// sessionService 登录会话服务实现
+type sessionService struct {
+	adminRepo  repository.AdminRepository
+	jwtManager *jwt.Manager
+	refreshTTL time.Duration
+}
+
+// NewSessionService 创建登录会话服务
+func NewSessionService(adminRepo repository.AdminRepository, jwtManager *jwt.Manager, refreshTTL time.Duration) SessionService {
+	return &sessionService{
+		adminRepo:  adminRepo,
+		jwtManager: jwtManager,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Create 为账号创建新会话
+func (s *sessionService) Create(admin *models.Admin) (*SessionTokens, error) {
+	sessionID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.issue(admin, sessionID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := redis.Get().SAdd(context.Background(), s.userSessionsKey(admin.ID), sessionID).Err(); err != nil {
+		return nil, err
+	}
+	_ = redis.Expire(s.userSessionsKey(admin.ID), s.refreshTTL)
+
+	return tokens, nil
+}
+
+// Refresh 使用刷新Token轮换会话
+func (s *sessionService) Refresh(refreshToken string) (*SessionTokens, *models.Admin, error) {
+	hash := hashToken(refreshToken)
+
+	data, err := redis.GetValue(refreshTokenKeyPrefix + hash)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	var record refreshRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// 原子标记为已使用，并发或重复使用同一刷新Token时只有第一次成功
+	first, err := redis.Get().SetNX(context.Background(), refreshUsedKeyPrefix+hash, 1, s.refreshTTL).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !first {
+		log.Printf("Refresh token reuse detected, revoking session %s of admin %d", record.SessionID, record.AdminID)
+		_ = s.Revoke(record.SessionID)
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	session, err := s.loadSession(record.SessionID)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if session.RefreshHash != hash {
+		log.Printf("Stale refresh token presented, revoking session %s of admin %d", record.SessionID, record.AdminID)
+		_ = s.Revoke(record.SessionID)
+		return nil, nil, ErrRefreshTokenReused
+	}
+
+	admin, err := s.adminRepo.FindByID(record.AdminID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAdminNotFound) {
+			_ = s.Revoke(record.SessionID)
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	// 旧的访问Token随轮换一并失效
+	s.deny(session.AccessJTI, session.AccessExpiresAt)
+
+	tokens, err := s.issue(admin, record.SessionID, session.CreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	_ = redis.Expire(s.userSessionsKey(admin.ID), s.refreshTTL)
+
+	return tokens, admin, nil
+}
+
+// Revoke 吊销指定会话：当前访问Token加入吊销名单，会话删除后其刷新Token随之失效
+func (s *sessionService) Revoke(sessionID string) error {
+	session, err := s.loadSession(sessionID)
+	if err != nil {
+		// 会话已过期或已吊销
+		return nil
+	}
+
+	s.deny(session.AccessJTI, session.AccessExpiresAt)
+
+	if err := redis.Del(sessionKeyPrefix + sessionID); err != nil {
+		return err
+	}
+	return redis.Get().SRem(context.Background(), s.userSessionsKey(session.AdminID), sessionID).Err()
+}
+
+// RevokeAll 吊销账号的所有会话
+func (s *sessionService) RevokeAll(adminID uint, exceptSessionID string) (int, error) {
+	sessionIDs, err := redis.Get().SMembers(context.Background(), s.userSessionsKey(adminID)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, sessionID := range sessionIDs {
+		if sessionID == exceptSessionID {
+			continue
+		}
+		if err := s.Revoke(sessionID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// IsRevoked 访问Token是否已吊销（Redis不可用时放行，避免所有账号无法访问后台）
+func (s *sessionService) IsRevoked(jti string) bool {
+	count, err := redis.Exists(denylistKeyPrefix + jti)
+	if err != nil {
+		log.Printf("Failed to check token denylist: %v", err)
+		return false
+	}
+	return count > 0
+}
+
+// issue 签发访问Token和刷新Token，并保存会话
+func (s *sessionService) issue(admin *models.Admin, sessionID string, createdAt time.Time) (*SessionTokens, error) {
+	accessToken, claims, err := s.jwtManager.GenerateToken(admin.ID, admin.Username, string(admin.Role), sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hash := hashToken(refreshToken)
+
+	record, err := json.Marshal(refreshRecord{AdminID: admin.ID, SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+	if err := redis.Set(refreshTokenKeyPrefix+hash, string(record), s.refreshTTL); err != nil {
+		return nil, err
+	}
+
+	session, err := json.Marshal(sessionRecord{
+		AdminID:         admin.ID,
+		RefreshHash:     hash,
+		AccessJTI:       claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		CreatedAt:       createdAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := redis.Set(sessionKeyPrefix+sessionID, string(session), s.refreshTTL); err != nil {
+		return nil, err
+	}
+
+	return &SessionTokens{
+		SessionID:        sessionID,
+		AccessToken:      accessToken,
+		AccessExpiresAt:  claims.ExpiresAt.Time,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: time.Now().Add(s.refreshTTL),
+	}, nil
+}
+
+// deny 将访问Token加入吊销名单，保留到其自然过期
+func (s *sessionService) deny(jti string, expiresAt time.Time) {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return
+	}
+	if err := redis.Set(denylistKeyPrefix+jti, 1, ttl); err != nil {
+		log.Printf("Failed to add token %s to denylist: %v", jti, err)
+	}
+}
+
+// loadSession 读取会话
+func (s *sessionService) loadSession(sessionID string) (*sessionRecord, error) {
+	data, err := redis.GetValue(sessionKeyPrefix + sessionID)
+	if err != nil {
+		return nil, err
+	}
+	var session sessionRecord
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// userSessionsKey 账号会话集合缓存键
+func (s *sessionService) userSessionsKey(adminID uint) string {
+	return fmt.Sprintf("%s%d", userSessionsKeyPrefix, adminID)
+}
+
+// randomToken 生成指定字节数的随机十六进制串
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken 计算Token的SHA-256哈希（Redis中不保存刷新Token明文）
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/config"
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/pkg/redis"
+	"github.com/whk-newbie/blog/internal/pkg/sitemap"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+const (
+	// sitemapURLsPerFile 每个Sitemap分片包含的URL数（低于协议上限，控制单文件体积）
+	sitemapURLsPerFile = 10000
+	// sitemapMetaKey Sitemap元信息缓存键
+	sitemapMetaKey = "sitemap:meta"
+	// sitemapPartKeyPrefix Sitemap分片缓存键前缀
+	sitemapPartKeyPrefix = "sitemap:part:"
+)
+
+var (
+	ErrSitemapPartNotFound = errors.New("sitemap part not found")
+)
+
+// SitemapService Sitemap与robots.txt服务接口
+type SitemapService interface {
+	// 重新生成Sitemap（同步执行）
+	Rebuild() error
+	// 请求异步重新生成Sitemap（合并短时间内的多次请求）
+	RequestRebuild()
+	// 获取 /sitemap.xml（单文件时为urlset，多文件时为sitemapindex）
+	GetSitemap(baseURL string) ([]byte, error)
+	// 获取指定分片（从1开始）
+	GetPart(index int, baseURL string) ([]byte, error)
+	// 获取 robots.txt 内容
+	GetRobotsTxt(baseURL string) string
+}
+
+// sitemapEntry 缓存中的Sitemap条目（只保存路径，输出时拼接站点地址）
+type sitemapEntry struct {
+	Path       string    `json:"path"`
+	LastMod    time.Time `json:"lastmod"`
+	ChangeFreq string    `json:"changefreq,omitempty"`
+	Priority   float64   `json:"priority,omitempty"`
+}
+
+// sitemapMeta Sitemap元信息
+type sitemapMeta struct {
+	Parts        int         `json:"parts"`
+	PartLastMods []time.Time `json:"part_lastmods"`
+	TotalURLs    int         `json:"total_urls"`
+	BuiltAt      time.Time   `json:"built_at"`
+}
+
+// sitemapService Sitemap服务实现
+type sitemapService struct {
+	articleRepo   repository.ArticleRepository
+	categoryRepo  repository.CategoryRepository
+	tagRepo       repository.TagRepository
+	configService ConfigService
+	siteCfg       config.SiteConfig
+
+	buildMu    sync.Mutex // 串行化重建
+	mu         sync.Mutex // 保护以下异步状态
+	rebuilding bool
+	pending    bool
+}
+
+// NewSitemapService 创建Sitemap服务
+func NewSitemapService(
+	articleRepo repository.ArticleRepository,
+	categoryRepo repository.CategoryRepository,
+	tagRepo repository.TagRepository,
+	configService ConfigService,
+	siteCfg config.SiteConfig,
+) SitemapService {
+	return &sitemapService{
+		articleRepo:   articleRepo,
+		categoryRepo:  categoryRepo,
+		tagRepo:       tagRepo,
+		configService: configService,
+		siteCfg:       siteCfg,
+	}
+}
+
+// Rebuild 重新生成Sitemap（同步执行）
+func (s *sitemapService) Rebuild() error {
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	entries, err := s.collectEntries()
+	if err != nil {
+		return err
+	}
+
+	// 读取旧的元信息，用于清理多余的分片
+	oldParts := 0
+	if old, err := s.loadMeta(); err == nil {
+		oldParts = old.Parts
+	}
+
+	meta := &sitemapMeta{
+		TotalURLs: len(entries),
+		BuiltAt:   time.Now(),
+	}
+
+	// 条目至少包含首页，因此总会生成一个分片
+	for start := 0; start < len(entries); start += sitemapURLsPerFile {
+		end := start + sitemapURLsPerFile
+		if end > len(entries) {
+			end = len(entries)
+		}
+		part := entries[start:end]
+
+		var lastMod time.Time
+		for _, e := range part {
+			if e.LastMod.After(lastMod) {
+				lastMod = e.LastMod
+			}
+		}
+
+		data, err := json.Marshal(part)
+		if err != nil {
+			return err
+		}
+		meta.Parts++
+		if err := redis.Set(s.partKey(meta.Parts), string(data), 0); err != nil {
+			return err
+		}
+		meta.PartLastMods = append(meta.PartLastMods, lastMod)
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := redis.Set(sitemapMetaKey, string(data), 0); err != nil {
+		return err
+	}
+
+	// 删除本次不再需要的旧分片
+	for i := meta.Parts + 1; i <= oldParts; i++ {
+		_ = redis.Del(s.partKey(i))
+	}
+
+	return nil
+}
+
+// RequestRebuild 请求异步重新生成Sitemap（合并短时间内的多次请求）
+func (s *sitemapService) RequestRebuild() {
+	s.mu.Lock()
+	if s.rebuilding {
+		s.pending = true
+		s.mu.Unlock()
+		return
+	}
+	s.rebuilding = true
+	s.mu.Unlock()
+
+	go func() {
+		for {
+			if err := s.Rebuild(); err != nil {
+				log.Printf("Failed to rebuild sitemap: %v", err)
+			}
+
+			s.mu.Lock()
+			if !s.pending {
+				s.rebuilding = false
+				s.mu.Unlock()
+				return
+			}
+			s.pending = false
+			s.mu.Unlock()
+		}
+	}()
+}
+
+// GetSitemap 获取 /sitemap.xml
+func (s *sitemapService) GetSitemap(baseURL string) ([]byte, error) {
+	meta, err := s.ensureMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	// 只有一个分片时直接输出urlset
+	if meta.Parts <= 1 {
+		return s.GetPart(1, baseURL)
+	}
+
+	base := s.resolveBaseURL(baseURL)
+	entries := make([]sitemap.IndexEntry, 0, meta.Parts)
+	for i := 1; i <= meta.Parts; i++ {
+		entry := sitemap.IndexEntry{Loc: fmt.Sprintf("%s/sitemaps/sitemap-%d.xml", base, i)}
+		if i-1 < len(meta.PartLastMods) {
+			entry.LastMod = meta.PartLastMods[i-1]
+		}
+		entries = append(entries, entry)
+	}
+
+	return sitemap.Index(entries)
+}
+
+// GetPart 获取指定分片（从1开始）
+func (s *sitemapService) GetPart(index int, baseURL string) ([]byte, error) {
+	meta, err := s.ensureMeta()
+	if err != nil {
+		return nil, err
+	}
+	if index < 1 || index > meta.Parts {
+		return nil, ErrSitemapPartNotFound
+	}
+
+	data, err := redis.GetValue(s.partKey(index))
+	if err != nil {
+		return nil, ErrSitemapPartNotFound
+	}
+
+	var entries []sitemapEntry
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil, err
+	}
+
+	base := s.resolveBaseURL(baseURL)
+	urls := make([]sitemap.URL, 0, len(entries))
+	for _, e := range entries {
+		urls = append(urls, sitemap.URL{
+			Loc:        base + e.Path,
+			LastMod:    e.LastMod,
+			ChangeFreq: e.ChangeFreq,
+			Priority:   e.Priority,
+		})
+	}
+
+	return sitemap.URLSet(urls)
+}
+
+// GetRobotsTxt 获取 robots.txt 内容（未配置时使用默认规则）
+func (s *sitemapService) GetRobotsTxt(baseURL string) string {
+	content := ""
+	if s.configService != nil {
+		// 配置键与类型同名
+		if value, err := s.configService.GetConfigValue(models.ConfigTypeRobotsTxt); err == nil {
+			content = value
+		}
+	}
+
+	if strings.TrimSpace(content) == "" {
+		content = "User-agent: *\nDisallow: /admin\nDisallow: /api/\n"
+	}
+
+	// 自动补充Sitemap地址
+	if !strings.Contains(strings.ToLower(content), "sitemap:") {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += fmt.Sprintf("\nSitemap: %s/sitemap.xml\n", s.resolveBaseURL(baseURL))
+	}
+
+	return content
+}
+
+// collectEntries 收集首页、文章、分类和标签地址
+func (s *sitemapService) collectEntries() ([]sitemapEntry, error) {
+	articles, err := s.articleRepo.ListPublishedSlugs()
+	if err != nil {
+		return nil, err
+	}
+	categories, _, err := s.categoryRepo.List(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	tags, _, err := s.tagRepo.List(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest time.Time
+	for _, article := range articles {
+		if article.UpdatedAt.After(latest) {
+			latest = article.UpdatedAt
+		}
+	}
+
+	entries := make([]sitemapEntry, 0, len(articles)+len(categories)+len(tags)+2)
+	entries = append(entries,
+		sitemapEntry{Path: "/", LastMod: latest, ChangeFreq: "daily", Priority: 1.0},
+		sitemapEntry{Path: "/articles", LastMod: latest, ChangeFreq: "daily", Priority: 0.8},
+	)
+
+	for _, article := range articles {
+		entries = append(entries, sitemapEntry{
+			Path:       articlePathPrefix + article.Slug,
+			LastMod:    article.UpdatedAt,
+			ChangeFreq: "weekly",
+			Priority:   0.7,
+		})
+	}
+
+	// 分类和标签只收录有已发布文章的
+	for _, category := range categories {
+		if category.ArticleCount <= 0 {
+			continue
+		}
+		entries = append(entries, sitemapEntry{
+			Path:       categoryPathPrefix + category.Slug,
+			LastMod:    category.UpdatedAt,
+			ChangeFreq: "weekly",
+			Priority:   0.5,
+		})
+	}
+	for _, tag := range tags {
+		if tag.ArticleCount <= 0 {
+			continue
+		}
+		entries = append(entries, sitemapEntry{
+			Path:       tagPathPrefix + tag.Slug,
+			LastMod:    tag.UpdatedAt,
+			ChangeFreq: "weekly",
+			Priority:   0.4,
+		})
+	}
+
+	return entries, nil
+}
+
+// ensureMeta 读取元信息，缓存缺失时立即重建
+func (s *sitemapService) ensureMeta() (*sitemapMeta, error) {
+	if meta, err := s.loadMeta(); err == nil {
+		return meta, nil
+	}
+	if err := s.Rebuild(); err != nil {
+		return nil, err
+	}
+	return s.loadMeta()
+}
+
+// loadMeta 读取元信息
+func (s *sitemapService) loadMeta() (*sitemapMeta, error) {
+	data, err := redis.GetValue(sitemapMetaKey)
+	if err != nil {
+		return nil, err
+	}
+	var meta sitemapMeta
+	if err := json.Unmarshal([]byte(data), &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// partKey 分片缓存键
+func (s *sitemapService) partKey(index int) string {
+	return fmt.Sprintf("%s%d", sitemapPartKeyPrefix, index)
+}
+
+// resolveBaseURL 优先使用配置的站点地址
+func (s *sitemapService) resolveBaseURL(baseURL string) string {
+	if s.siteCfg.URL != "" {
+		return strings.TrimRight(s.siteCfg.URL, "/")
+	}
+	return strings.TrimRight(baseURL, "/")
+}
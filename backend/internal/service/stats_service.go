@@ -17,6 +17,10 @@ type StatsService interface {
 	GetPopularArticles(limit, days int) ([]repository.PopularArticle, error)
 	// 获取访问来源统计
 	GetReferrerStats(startDate, endDate time.Time) (*ReferrerStatsResponse, error)
+	// 获取访问记录写入统计
+	GetVisitIngestStats() *VisitIngestStats
+	// 获取会话统计
+	GetSessionStats(startDate, endDate time.Time) (*SessionStatsResponse, error)
 }
 
 // DashboardStatsResponse 仪表盘统计响应
@@ -145,3 +149,13 @@ func (s *statsService) GetPopularArticles(limit, days int) ([]repository.Popular
 func (s *statsService) GetReferrerStats(startDate, endDate time.Time) (*ReferrerStatsResponse, error) {
 	return s.visitService.GetReferrerStats(startDate, endDate)
 }
+
+// GetVisitIngestStats 获取访问记录写入统计
+func (s *statsService) GetVisitIngestStats() *VisitIngestStats {
+	return s.visitService.GetIngestStats()
+}
+
+// GetSessionStats 获取会话统计
+func (s *statsService) GetSessionStats(startDate, endDate time.Time) (*SessionStatsResponse, error) {
+	return s.visitService.GetSessionStats(startDate, endDate)
+}
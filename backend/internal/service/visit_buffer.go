@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/models"
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+var (
+	ErrVisitQueueFull    = errors.New("visit queue is full")
+	ErrVisitBufferClosed = errors.New("visit buffer is closed")
+)
+
+// 队列已满时日志的最小间隔，避免流量高峰时刷屏
+const visitDropLogInterval = time.Minute
+
+// VisitBufferConfig 访问记录缓冲配置
+type VisitBufferConfig struct {
+	QueueSize     int           // 队列容量
+	BatchSize     int           // 每批最多写入条数
+	FlushInterval time.Duration // 未攒满一批时的最长等待时间
+	Workers       int           // 写入协程数
+}
+
+// VisitIngestStats 访问记录写入统计（背压指标）
+type VisitIngestStats struct {
+	QueueLength   int        `json:"queue_length"`            // 当前排队数
+	QueueCapacity int        `json:"queue_capacity"`          // 队列容量
+	Enqueued      uint64     `json:"enqueued"`                // 累计入队
+	Dropped       uint64     `json:"dropped"`                 // 累计因队列已满被拒绝
+	Written       uint64     `json:"written"`                 // 累计写入
+	Failed        uint64     `json:"failed"`                  // 累计写入失败（已丢弃）
+	Batches       uint64     `json:"batches"`                 // 累计写入批次
+	LastFlushAt   *time.Time `json:"last_flush_at,omitempty"` // 最近一次写入时间
+	LastError     string     `json:"last_error,omitempty"`    // 最近一次写入失败的原因
+}
+
+// VisitBuffer 访问记录缓冲：请求只把访问记录放入有界队列，
+// 后台协程攒够一批或等待超时后用多行INSERT写入，流量高峰时不会直接变成数据库的写入压力
+type VisitBuffer struct {
+	visitRepo repository.VisitRepository
+	config    VisitBufferConfig
+	queue     chan *models.Visit
+
+	mu     sync.RWMutex // 保护closed，关闭队列时不能再有写入
+	closed bool
+	wg     sync.WaitGroup
+
+	// 已入队但还没写入的访问记录（按访问标识），期间收到的离开信标暂存在这里，写入后再更新
+	pendingMu sync.Mutex
+	pending   map[string]*VisitBeacon
+
+	enqueued atomic.Uint64
+	dropped  atomic.Uint64
+	written  atomic.Uint64
+	failed   atomic.Uint64
+	batches  atomic.Uint64
+
+	statsMu     sync.Mutex
+	lastFlushAt time.Time
+	lastError   string
+	lastDropLog time.Time
+}
+
+// NewVisitBuffer 创建访问记录缓冲
+func NewVisitBuffer(visitRepo repository.VisitRepository, config VisitBufferConfig) *VisitBuffer {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 2 * time.Second
+	}
+	if config.Workers <= 0 {
+		config.Workers = 2
+	}
+
+	return &VisitBuffer{
+		visitRepo: visitRepo,
+		config:    config,
+		queue:     make(chan *models.Visit, config.QueueSize),
+		pending:   make(map[string]*VisitBeacon),
+	}
+}
+
+// Start 启动写入协程
+func (b *VisitBuffer) Start() {
+	for i := 0; i < b.config.Workers; i++ {
+		b.wg.Add(1)
+		go b.worker()
+	}
+}
+
+// Enqueue 放入队列，队列已满时返回ErrVisitQueueFull，已关闭时返回ErrVisitBufferClosed
+func (b *VisitBuffer) Enqueue(visit *models.Visit) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrVisitBufferClosed
+	}
+
+	// 入队前登记，避免写入协程先写完再登记
+	b.trackPending(visit, true)
+
+	select {
+	case b.queue <- visit:
+		b.enqueued.Add(1)
+		return nil
+	default:
+		b.trackPending(visit, false)
+		b.dropped.Add(1)
+		b.logDrop()
+		return ErrVisitQueueFull
+	}
+}
+
+// Beacon 访问记录还在队列中时暂存离开信标并返回true，写入后再更新；已写入或不存在时返回false
+func (b *VisitBuffer) Beacon(visitKey string, beacon *VisitBeacon) bool {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+
+	existing, ok := b.pending[visitKey]
+	if !ok {
+		return false
+	}
+	if existing == nil {
+		existing = &VisitBeacon{}
+		b.pending[visitKey] = existing
+	}
+	existing.merge(beacon)
+	return true
+}
+
+// trackPending 登记或移除排队中的访问记录
+func (b *VisitBuffer) trackPending(visit *models.Visit, add bool) {
+	if visit.VisitKey == nil {
+		return
+	}
+
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+	if add {
+		b.pending[*visit.VisitKey] = nil
+	} else {
+		delete(b.pending, *visit.VisitKey)
+	}
+}
+
+// takeBeacons 移除一批已处理的访问记录，返回排队期间收到的离开信标
+func (b *VisitBuffer) takeBeacons(batch []*models.Visit) map[string]*VisitBeacon {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+
+	var beacons map[string]*VisitBeacon
+	for _, visit := range batch {
+		if visit.VisitKey == nil {
+			continue
+		}
+		if beacon := b.pending[*visit.VisitKey]; beacon != nil {
+			if beacons == nil {
+				beacons = make(map[string]*VisitBeacon)
+			}
+			beacons[*visit.VisitKey] = beacon
+		}
+		delete(b.pending, *visit.VisitKey)
+	}
+	return beacons
+}
+
+// Stop 停止接收并等待队列中的访问记录全部写入，ctx到期时返回ctx.Err()
+func (b *VisitBuffer) Stop(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats 获取写入统计
+func (b *VisitBuffer) Stats() *VisitIngestStats {
+	stats := &VisitIngestStats{
+		QueueLength:   len(b.queue),
+		QueueCapacity: cap(b.queue),
+		Enqueued:      b.enqueued.Load(),
+		Dropped:       b.dropped.Load(),
+		Written:       b.written.Load(),
+		Failed:        b.failed.Load(),
+		Batches:       b.batches.Load(),
+	}
+
+	b.statsMu.Lock()
+	if !b.lastFlushAt.IsZero() {
+		lastFlushAt := b.lastFlushAt
+		stats.LastFlushAt = &lastFlushAt
+	}
+	stats.LastError = b.lastError
+	b.statsMu.Unlock()
+
+	return stats
+}
+
+// worker 攒批写入，队列关闭后写完剩余记录再退出
+func (b *VisitBuffer) worker() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.Visit, 0, b.config.BatchSize)
+	for {
+		select {
+		case visit, ok := <-b.queue:
+			if !ok {
+				b.flush(batch)
+				return
+			}
+			batch = append(batch, visit)
+			if len(batch) >= b.config.BatchSize {
+				b.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				b.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush 写入一批访问记录，整批写入失败时稍后逐条重试，避免一条异常记录导致整批丢失
+func (b *VisitBuffer) flush(batch []*models.Visit) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var failed map[*models.Visit]bool
+	err := b.visitRepo.CreateBatch(batch)
+	if err != nil {
+		// 逐条写入，只丢弃本身有问题的记录（如引用的指纹或文章不存在）
+		failed, err = b.createEach(batch)
+		// 全部失败通常是数据库短暂不可用，稍后重试一次
+		if len(failed) == len(batch) {
+			time.Sleep(time.Second)
+			failed, err = b.createEach(batch)
+		}
+	}
+
+	// 写入后才移除登记，之后到达的离开信标直接更新数据库
+	beacons := b.takeBeacons(batch)
+	for _, visit := range batch {
+		if failed[visit] || visit.VisitKey == nil {
+			continue
+		}
+		beacon := beacons[*visit.VisitKey]
+		if beacon == nil {
+			continue
+		}
+		if _, updateErr := b.visitRepo.UpdateBeacon(*visit.VisitKey, beacon.StayDuration, beacon.ScrollDepth); updateErr != nil {
+			log.Printf("Failed to apply visit beacon %s: %v", *visit.VisitKey, updateErr)
+		}
+	}
+
+	b.batches.Add(1)
+	b.statsMu.Lock()
+	b.lastFlushAt = time.Now()
+	if err != nil {
+		b.lastError = err.Error()
+	}
+	b.statsMu.Unlock()
+
+	if len(failed) > 0 {
+		b.failed.Add(uint64(len(failed)))
+		log.Printf("Failed to write %d of %d visits: %v", len(failed), len(batch), err)
+	}
+	b.written.Add(uint64(len(batch) - len(failed)))
+}
+
+// createEach 逐条写入访问记录，返回写入失败的记录和最后一个错误
+func (b *VisitBuffer) createEach(batch []*models.Visit) (map[*models.Visit]bool, error) {
+	failed := make(map[*models.Visit]bool)
+	var err error
+	for _, visit := range batch {
+		visit.ID = 0
+		if createErr := b.visitRepo.Create(visit); createErr != nil {
+			failed[visit] = true
+			err = createErr
+			log.Printf("Failed to write visit %s: %v", visit.URL, createErr)
+		}
+	}
+	return failed, err
+}
+
+// logDrop 队列已满时记录日志（限频）
+func (b *VisitBuffer) logDrop() {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	if time.Since(b.lastDropLog) < visitDropLogInterval {
+		return
+	}
+	b.lastDropLog = time.Now()
+	log.Printf("Visit queue is full (capacity %d), %d visits rejected so far", cap(b.queue), b.dropped.Load())
+}
@@ -23,6 +23,10 @@ type VisitCacheService interface {
 	CacheReferrerStats(startDate, endDate time.Time, stats *ReferrerStatsResponse, ttl time.Duration) error
 	// 获取缓存的访问来源统计
 	GetCachedReferrerStats(startDate, endDate time.Time) (*ReferrerStatsResponse, error)
+	// 缓存会话统计
+	CacheSessionStats(startDate, endDate time.Time, stats *SessionStatsResponse, ttl time.Duration) error
+	// 获取缓存的会话统计
+	GetCachedSessionStats(startDate, endDate time.Time) (*SessionStatsResponse, error)
 	// 清除访问统计缓存
 	ClearVisitStatsCache() error
 }
@@ -145,6 +149,41 @@ func (s *visitCacheService) GetCachedReferrerStats(startDate, endDate time.Time)
 	return &stats, nil
 }
 
+// CacheSessionStats 缓存会话统计
+func (s *visitCacheService) CacheSessionStats(startDate, endDate time.Time, stats *SessionStatsResponse, ttl time.Duration) error {
+	key := s.getCacheKey("sessions",
+		startDate.Format("2006-01-02"),
+		endDate.Format("2006-01-02"),
+	)
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	return redis.Set(key, string(data), ttl)
+}
+
+// GetCachedSessionStats 获取缓存的会话统计
+func (s *visitCacheService) GetCachedSessionStats(startDate, endDate time.Time) (*SessionStatsResponse, error) {
+	key := s.getCacheKey("sessions",
+		startDate.Format("2006-01-02"),
+		endDate.Format("2006-01-02"),
+	)
+
+	data, err := redis.GetValue(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats SessionStatsResponse
+	if err := json.Unmarshal([]byte(data), &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
 // ClearVisitStatsCache 清除访问统计缓存
 func (s *visitCacheService) ClearVisitStatsCache() error {
 	// 这里可以使用Redis的KEYS命令或SCAN命令来查找并删除所有相关缓存
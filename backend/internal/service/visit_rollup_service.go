@@ -0,0 +1,278 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/whk-newbie/blog/internal/repository"
+)
+
+var (
+	ErrRollupInProgress   = errors.New("visit rollup is already running")
+	ErrInvalidRollupRange = errors.New("invalid rollup date range")
+)
+
+// 一次重新汇总最多的天数，每天都要删除并重新汇总
+const maxReaggregateDays = 366
+
+// 汇总任务类型
+const (
+	VisitRollupNightly     = "nightly"
+	VisitRollupBackfill    = "backfill"
+	VisitRollupReaggregate = "reaggregate"
+)
+
+// VisitRollupService 访问统计日汇总服务接口
+//
+// 只汇总已结束的日期（昨天及以前），当天的统计始终读取访问记录
+type VisitRollupService interface {
+	// 汇总上次汇总之后到昨天的日期（没有汇总数据时从最早的访问记录开始），供夜间任务调用，返回汇总的天数
+	RollupClosedDays() (int, error)
+	// 在后台补齐从最早的访问记录到昨天之间所有未汇总的日期
+	StartBackfill() (*VisitRollupTask, error)
+	// 在后台重新汇总指定日期范围（含首尾，结束日期最晚为昨天，开始日期最早为最早的访问记录，最多366天）
+	StartReaggregate(startDate, endDate time.Time) (*VisitRollupTask, error)
+	// 获取汇总状态
+	GetStatus() (*VisitRollupStatus, error)
+}
+
+// VisitRollupStatus 访问统计日汇总状态
+type VisitRollupStatus struct {
+	FirstDay string           `json:"first_day,omitempty"` // 已汇总的最早日期
+	LastDay  string           `json:"last_day,omitempty"`  // 已汇总的最晚日期，之后的日期读取访问记录
+	Running  bool             `json:"running"`             // 是否有汇总任务正在执行
+	LastTask *VisitRollupTask `json:"last_task,omitempty"` // 最近一次汇总任务
+}
+
+// VisitRollupTask 汇总任务进度
+type VisitRollupTask struct {
+	Command    string     `json:"command"` // nightly, backfill, reaggregate
+	StartDate  string     `json:"start_date,omitempty"`
+	EndDate    string     `json:"end_date,omitempty"`
+	Total      int        `json:"total"`     // 需要汇总的天数
+	Processed  int        `json:"processed"` // 已汇总的天数
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// visitRollupService 访问统计日汇总服务实现
+type visitRollupService struct {
+	statsRepo repository.VisitStatsRepository
+
+	// 同一时间只允许一个汇总任务，避免重复汇总同一天
+	mu       sync.Mutex
+	running  bool
+	lastTask *VisitRollupTask
+}
+
+// NewVisitRollupService 创建访问统计日汇总服务
+func NewVisitRollupService(statsRepo repository.VisitStatsRepository) VisitRollupService {
+	return &visitRollupService{
+		statsRepo: statsRepo,
+	}
+}
+
+// RollupClosedDays 汇总上次汇总之后到昨天的日期
+func (s *visitRollupService) RollupClosedDays() (int, error) {
+	yesterday := localDay(time.Now()).AddDate(0, 0, -1)
+
+	_, last, err := s.statsRepo.GetAggregatedRange()
+	if err != nil {
+		return 0, err
+	}
+
+	var startDay time.Time
+	if last != nil {
+		startDay = last.AddDate(0, 0, 1)
+	} else {
+		earliest, err := s.statsRepo.GetEarliestVisitDay()
+		if err != nil {
+			return 0, err
+		}
+		if earliest == nil {
+			return 0, nil
+		}
+		startDay = *earliest
+	}
+
+	days := dayRange(startDay, yesterday)
+	if len(days) == 0 {
+		return 0, nil
+	}
+
+	task, err := s.begin(VisitRollupNightly, days)
+	if err != nil {
+		return 0, err
+	}
+	err = s.run(task, days)
+	return task.Processed, err
+}
+
+// StartBackfill 在后台补齐未汇总的日期
+func (s *visitRollupService) StartBackfill() (*VisitRollupTask, error) {
+	yesterday := localDay(time.Now()).AddDate(0, 0, -1)
+
+	earliest, err := s.statsRepo.GetEarliestVisitDay()
+	if err != nil {
+		return nil, err
+	}
+
+	var days []time.Time
+	if earliest != nil {
+		aggregated, err := s.statsRepo.ListAggregatedDays(*earliest, yesterday.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+		done := make(map[time.Time]bool, len(aggregated))
+		for _, day := range aggregated {
+			done[day] = true
+		}
+		for _, day := range dayRange(*earliest, yesterday) {
+			if !done[day] {
+				days = append(days, day)
+			}
+		}
+	}
+
+	return s.startAsync(VisitRollupBackfill, days)
+}
+
+// StartReaggregate 在后台重新汇总指定日期范围
+func (s *visitRollupService) StartReaggregate(startDate, endDate time.Time) (*VisitRollupTask, error) {
+	yesterday := localDay(time.Now()).AddDate(0, 0, -1)
+
+	startDay := localDay(startDate)
+	endDay := localDay(endDate)
+	if endDay.After(yesterday) {
+		endDay = yesterday
+	}
+
+	// 最早的访问记录之前没有可汇总的数据
+	earliest, err := s.statsRepo.GetEarliestVisitDay()
+	if err != nil {
+		return nil, err
+	}
+	if earliest != nil && startDay.Before(*earliest) {
+		startDay = *earliest
+	}
+
+	if startDay.After(endDay) || startDay.AddDate(0, 0, maxReaggregateDays).Before(endDay.AddDate(0, 0, 1)) {
+		return nil, ErrInvalidRollupRange
+	}
+
+	return s.startAsync(VisitRollupReaggregate, dayRange(startDay, endDay))
+}
+
+// GetStatus 获取汇总状态
+func (s *visitRollupService) GetStatus() (*VisitRollupStatus, error) {
+	first, last, err := s.statsRepo.GetAggregatedRange()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &VisitRollupStatus{}
+	if first != nil {
+		status.FirstDay = first.Format(dateLayout)
+	}
+	if last != nil {
+		status.LastDay = last.Format(dateLayout)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status.Running = s.running
+	if s.lastTask != nil {
+		task := *s.lastTask
+		status.LastTask = &task
+	}
+
+	return status, nil
+}
+
+// startAsync 登记汇总任务并在后台执行
+func (s *visitRollupService) startAsync(command string, days []time.Time) (*VisitRollupTask, error) {
+	task, err := s.begin(command, days)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := *task
+
+	go func() {
+		if err := s.run(task, days); err != nil {
+			log.Printf("Visit rollup %s failed after %d/%d days: %v", command, task.Processed, task.Total, err)
+			return
+		}
+		log.Printf("Visit rollup %s finished: %d days", command, task.Total)
+	}()
+
+	return &snapshot, nil
+}
+
+// begin 登记汇总任务，已有任务在执行时返回ErrRollupInProgress
+func (s *visitRollupService) begin(command string, days []time.Time) (*VisitRollupTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return nil, ErrRollupInProgress
+	}
+
+	task := &VisitRollupTask{
+		Command:   command,
+		Total:     len(days),
+		StartedAt: time.Now(),
+	}
+	if len(days) > 0 {
+		task.StartDate = days[0].Format(dateLayout)
+		task.EndDate = days[len(days)-1].Format(dateLayout)
+	}
+
+	s.running = true
+	s.lastTask = task
+	return task, nil
+}
+
+// run 逐天汇总，出错时停止（已汇总的日期保留）
+func (s *visitRollupService) run(task *VisitRollupTask, days []time.Time) error {
+	var runErr error
+	for _, day := range days {
+		if err := s.statsRepo.AggregateDay(day); err != nil {
+			runErr = err
+			break
+		}
+		s.mu.Lock()
+		task.Processed++
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	task.FinishedAt = &now
+	if runErr != nil {
+		task.Error = runErr.Error()
+	}
+	s.running = false
+
+	return runErr
+}
+
+// dateLayout 日期格式
+const dateLayout = "2006-01-02"
+
+// localDay 取本地时区当天零点（只保留年月日）
+func localDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// dayRange 返回[startDay, endDay]之间的每一天（含首尾）
+func dayRange(startDay, endDay time.Time) []time.Time {
+	var days []time.Time
+	for day := localDay(startDay); !day.After(endDay); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
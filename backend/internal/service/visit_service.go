@@ -1,33 +1,70 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"time"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"github.com/whk-newbie/blog/internal/models"
 	"github.com/whk-newbie/blog/internal/repository"
 )
 
+var (
+	ErrVisitNotFound      = repository.ErrVisitNotFound
+	ErrInvalidVisitBeacon = errors.New("invalid visit beacon")
+	ErrInvalidVisit       = errors.New("invalid visit")
+)
+
+// 离开信标上报的停留时间上限（秒），超过按上限处理
+const maxBeaconStayDuration = 24 * 60 * 60
+
 // VisitService 访问记录服务接口
 type VisitService interface {
-	// 记录访问
-	RecordVisit(req *RecordVisitRequest) error
+	// 记录访问（放入写入队列，队列已满时返回ErrVisitQueueFull），返回访问标识
+	RecordVisit(req *RecordVisitRequest) (*RecordVisitResponse, error)
+	// 按访问标识更新停留时间和滚动深度（离开信标）
+	UpdateBeacon(visitKey string, beacon *VisitBeacon) error
+	// 获取访问记录写入统计（未启用写入队列时返回nil）
+	GetIngestStats() *VisitIngestStats
 	// 获取访问统计
 	GetVisitStats(req *VisitStatsRequest) (*VisitStatsResponse, error)
 	// 获取热门文章
 	GetPopularArticles(limit, days int) ([]repository.PopularArticle, error)
 	// 获取访问来源统计
 	GetReferrerStats(startDate, endDate time.Time) (*ReferrerStatsResponse, error)
+	// 获取会话统计（结束日期包含当天）
+	GetSessionStats(startDate, endDate time.Time) (*SessionStatsResponse, error)
 }
 
 // RecordVisitRequest 记录访问请求
 type RecordVisitRequest struct {
 	FingerprintID *uint  `json:"fingerprint_id"`
-	URL           string `json:"url" binding:"required"`
-	Referrer      string `json:"referrer"`
-	PageTitle     string `json:"page_title"`
+	URL           string `json:"url" binding:"required"` // 最多500个字符
+	Referrer      string `json:"referrer"`               // 超过500个字符时截断
+	PageTitle     string `json:"page_title"`             // 超过255个字符时截断
 	ArticleID     *uint  `json:"article_id"`
 	StayDuration  *int   `json:"stay_duration"`
 	UserAgent     string `json:"user_agent"`
+	IP            string `json:"-"` // 客户端IP，由处理器从请求中获取
+}
+
+// RecordVisitResponse 记录访问响应
+type RecordVisitResponse struct {
+	VisitID string `json:"visit_id"` // 访问标识，页面离开时用于上报离开信标
+}
+
+// VisitBeacon 离开信标（页面隐藏或离开时上报，可多次上报，只保留最大值）
+type VisitBeacon struct {
+	StayDuration *int `json:"stay_duration"` // 停留时间（秒）
+	ScrollDepth  *int `json:"scroll_depth"`  // 最大滚动深度（百分比，0-100）
+}
+
+// merge 合并另一次上报，取最大值
+func (b *VisitBeacon) merge(other *VisitBeacon) {
+	b.StayDuration = maxIntPtr(b.StayDuration, other.StayDuration)
+	b.ScrollDepth = maxIntPtr(b.ScrollDepth, other.ScrollDepth)
 }
 
 // VisitStatsRequest 访问统计请求
@@ -63,34 +100,145 @@ type ReferrerStatsResponse struct {
 	TopReferrers []repository.TopReferrer `json:"top_referrers"`
 }
 
+// SessionStatsResponse 会话统计响应
+type SessionStatsResponse struct {
+	Sessions           int64                        `json:"sessions"`             // 会话数
+	Visitors           int64                        `json:"visitors"`             // 访客数
+	PageViews          int64                        `json:"page_views"`           // 页面浏览数
+	BounceRate         float64                      `json:"bounce_rate"`          // 跳出率（只浏览了一个页面的会话占比，0-1）
+	PagesPerSession    float64                      `json:"pages_per_session"`    // 每个会话的平均页面数
+	AvgSessionDuration float64                      `json:"avg_session_duration"` // 平均会话时长（秒）
+	SessionGap         int                          `json:"session_gap"`          // 会话间隔（秒）
+	EntryPages         []repository.SessionPageStat `json:"entry_pages"`          // 入口页面
+	ExitPages          []repository.SessionPageStat `json:"exit_pages"`           // 退出页面
+}
+
 // visitService 访问记录服务实现
 type visitService struct {
 	visitRepo    repository.VisitRepository
+	statsRepo    repository.VisitStatsRepository
 	cacheService VisitCacheService
+	buffer       *VisitBuffer
+	geoService   GeoService
+	sessionGap   time.Duration
 }
 
-// NewVisitService 创建访问记录服务
-func NewVisitService(visitRepo repository.VisitRepository, cacheService VisitCacheService) VisitService {
+// NewVisitService 创建访问记录服务（buffer为nil时同步写入，geoService用于保存客户端IP和归属地，sessionGap为同一指纹两次访问视为新会话的间隔）
+func NewVisitService(visitRepo repository.VisitRepository, statsRepo repository.VisitStatsRepository, cacheService VisitCacheService, buffer *VisitBuffer, geoService GeoService, sessionGap time.Duration) VisitService {
+	// 默认30分钟
+	if sessionGap <= 0 {
+		sessionGap = 30 * time.Minute
+	}
+
 	return &visitService{
 		visitRepo:    visitRepo,
+		statsRepo:    statsRepo,
 		cacheService: cacheService,
+		buffer:       buffer,
+		geoService:   geoService,
+		sessionGap:   sessionGap,
 	}
 }
 
 // RecordVisit 记录访问
-func (s *visitService) RecordVisit(req *RecordVisitRequest) error {
+func (s *visitService) RecordVisit(req *RecordVisitRequest) (*RecordVisitResponse, error) {
+	// 访问记录是攒批写入的，入队前只做不访问数据库的校验
+	// 引用不存在的指纹或文章（外键错误）在写入时逐条重试，只丢弃有问题的记录
+	if err := s.validateVisit(req); err != nil {
+		return nil, err
+	}
+
+	// 访问标识在入队前生成，写入是异步的，离开信标按标识而不是自增ID更新
+	visitKey := uuid.New().String()
 	visit := &models.Visit{
 		FingerprintID: req.FingerprintID,
 		URL:           req.URL,
-		Referrer:      req.Referrer,
-		PageTitle:     req.PageTitle,
+		Referrer:      truncateRunes(req.Referrer, 500),
+		PageTitle:     truncateRunes(req.PageTitle, 255),
 		ArticleID:     req.ArticleID,
 		StayDuration:  req.StayDuration,
 		UserAgent:     req.UserAgent,
+		ClientInfo:    parseClientInfo(req.UserAgent),
 		VisitTime:     time.Now(),
+		VisitKey:      &visitKey,
 	}
+	visit.IP, visit.GeoInfo = s.geoService.Resolve(req.IP)
+
+	resp := &RecordVisitResponse{VisitID: visitKey}
 
-	return s.visitRepo.Create(visit)
+	if s.buffer != nil {
+		err := s.buffer.Enqueue(visit)
+		if err == nil {
+			return resp, nil
+		}
+		if !errors.Is(err, ErrVisitBufferClosed) {
+			return nil, err
+		}
+		// 服务关闭过程中仍在处理的请求直接写入
+	}
+
+	if err := s.visitRepo.Create(visit); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// validateVisit 校验访问记录的URL长度和停留时间
+func (s *visitService) validateVisit(req *RecordVisitRequest) error {
+	if utf8.RuneCountInString(req.URL) > 500 {
+		return fmt.Errorf("%w: url is longer than 500 characters", ErrInvalidVisit)
+	}
+	if req.StayDuration != nil {
+		if *req.StayDuration < 0 {
+			return fmt.Errorf("%w: stay_duration must not be negative", ErrInvalidVisit)
+		}
+		if *req.StayDuration > maxBeaconStayDuration {
+			stayDuration := maxBeaconStayDuration
+			req.StayDuration = &stayDuration
+		}
+	}
+	return nil
+}
+
+// UpdateBeacon 按访问标识更新停留时间和滚动深度
+func (s *visitService) UpdateBeacon(visitKey string, beacon *VisitBeacon) error {
+	if beacon.StayDuration == nil && beacon.ScrollDepth == nil {
+		return ErrInvalidVisitBeacon
+	}
+	if beacon.StayDuration != nil {
+		if *beacon.StayDuration < 0 {
+			return ErrInvalidVisitBeacon
+		}
+		if *beacon.StayDuration > maxBeaconStayDuration {
+			stayDuration := maxBeaconStayDuration
+			beacon.StayDuration = &stayDuration
+		}
+	}
+	if beacon.ScrollDepth != nil && (*beacon.ScrollDepth < 0 || *beacon.ScrollDepth > 100) {
+		return ErrInvalidVisitBeacon
+	}
+
+	// 访问记录还在写入队列中时，写入后再更新
+	if s.buffer != nil && s.buffer.Beacon(visitKey, beacon) {
+		return nil
+	}
+
+	updated, err := s.visitRepo.UpdateBeacon(visitKey, beacon.StayDuration, beacon.ScrollDepth)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrVisitNotFound
+	}
+	return nil
+}
+
+// GetIngestStats 获取访问记录写入统计
+func (s *visitService) GetIngestStats() *VisitIngestStats {
+	if s.buffer == nil {
+		return nil
+	}
+	return s.buffer.Stats()
 }
 
 // GetVisitStats 获取访问统计
@@ -115,63 +263,30 @@ func (s *visitService) GetVisitStats(req *VisitStatsRequest) (*VisitStatsRespons
 		}
 	}
 
-	// 获取PV统计
-	pvStats, err := s.visitRepo.GetPVStats(req.StartDate, req.EndDate, req.Type)
+	// 已汇总的日期读取日汇总表，其余日期（至少包括今天）读取访问记录
+	startDay := localDay(req.StartDate)
+	endDay := localDay(req.EndDate).AddDate(0, 0, 1)
+	splitDay, err := s.rollupSplitDay(startDay, endDay)
 	if err != nil {
 		return nil, err
 	}
 
-	// 获取UV统计
-	uvStats, err := s.visitRepo.GetUVStats(req.StartDate, req.EndDate, req.Type)
+	periodStats, err := s.statsRepo.GetPeriodStats(startDay, splitDay, endDay, req.Type)
 	if err != nil {
 		return nil, err
 	}
 
-	// 获取平均停留时间统计
-	stayDurationStats, err := s.visitRepo.GetAvgStayDuration(req.StartDate, req.EndDate, req.Type)
-	if err != nil {
-		return nil, err
-	}
-
-	// 合并统计数据
-	dailyStatsMap := make(map[string]*DailyStat)
-
-	// 初始化PV
-	for _, stat := range pvStats {
-		dailyStatsMap[stat.Date] = &DailyStat{
+	dailyStats := make([]DailyStat, 0, len(periodStats))
+	for _, stat := range periodStats {
+		dailyStat := DailyStat{
 			Date: stat.Date,
 			PV:   stat.PV,
+			UV:   stat.UV,
 		}
-	}
-
-	// 合并UV
-	for _, stat := range uvStats {
-		if existing, ok := dailyStatsMap[stat.Date]; ok {
-			existing.UV = stat.UV
-		} else {
-			dailyStatsMap[stat.Date] = &DailyStat{
-				Date: stat.Date,
-				UV:   stat.UV,
-			}
-		}
-	}
-
-	// 合并平均停留时间
-	for _, stat := range stayDurationStats {
-		if existing, ok := dailyStatsMap[stat.Date]; ok {
-			existing.AvgStayDuration = stat.AvgStayDuration
-		} else {
-			dailyStatsMap[stat.Date] = &DailyStat{
-				Date:            stat.Date,
-				AvgStayDuration: stat.AvgStayDuration,
-			}
+		if stat.StayDurationCount > 0 {
+			dailyStat.AvgStayDuration = float64(stat.StayDurationSum) / float64(stat.StayDurationCount)
 		}
-	}
-
-	// 转换为切片
-	var dailyStats []DailyStat
-	for _, stat := range dailyStatsMap {
-		dailyStats = append(dailyStats, *stat)
+		dailyStats = append(dailyStats, dailyStat)
 	}
 
 	// 计算总计
@@ -211,6 +326,29 @@ func (s *visitService) GetVisitStats(req *VisitStatsRequest) (*VisitStatsRespons
 	return response, nil
 }
 
+// rollupSplitDay 计算区间内日汇总与访问记录的分界日期：分界之前的日期已汇总，之后（至少包括今天）读取访问记录
+func (s *visitService) rollupSplitDay(startDay, endDay time.Time) (time.Time, error) {
+	_, last, err := s.statsRepo.GetAggregatedRange()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if last == nil {
+		return startDay, nil
+	}
+
+	splitDay := last.AddDate(0, 0, 1)
+	if today := localDay(time.Now()); splitDay.After(today) {
+		splitDay = today
+	}
+	if splitDay.Before(startDay) {
+		return startDay, nil
+	}
+	if splitDay.After(endDay) {
+		return endDay, nil
+	}
+	return splitDay, nil
+}
+
 // GetPopularArticles 获取热门文章
 func (s *visitService) GetPopularArticles(limit, days int) ([]repository.PopularArticle, error) {
 	if limit <= 0 {
@@ -227,7 +365,15 @@ func (s *visitService) GetPopularArticles(limit, days int) ([]repository.Popular
 		}
 	}
 
-	articles, err := s.visitRepo.GetPopularArticles(limit, days)
+	// 最近days个自然日（含今天）
+	today := localDay(time.Now())
+	startDay := today.AddDate(0, 0, 1-days)
+	splitDay, err := s.rollupSplitDay(startDay, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	articles, err := s.statsRepo.GetPopularArticles(startDay, splitDay, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -275,3 +421,57 @@ func (s *visitService) GetReferrerStats(startDate, endDate time.Time) (*Referrer
 
 	return response, nil
 }
+
+// GetSessionStats 获取会话统计
+func (s *visitService) GetSessionStats(startDate, endDate time.Time) (*SessionStatsResponse, error) {
+	if startDate.IsZero() {
+		startDate = time.Now().AddDate(0, 0, -30)
+	}
+	if endDate.IsZero() {
+		endDate = time.Now()
+	}
+
+	// 尝试从缓存获取
+	if s.cacheService != nil {
+		if cached, err := s.cacheService.GetCachedSessionStats(startDate, endDate); err == nil {
+			return cached, nil
+		}
+	}
+
+	stats, err := s.visitRepo.GetSessionStats(localDay(startDate), localDay(endDate).AddDate(0, 0, 1), s.sessionGap, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SessionStatsResponse{
+		Sessions:   stats.Sessions,
+		Visitors:   stats.Visitors,
+		PageViews:  stats.PageViews,
+		SessionGap: int(s.sessionGap.Seconds()),
+		EntryPages: stats.EntryPages,
+		ExitPages:  stats.ExitPages,
+	}
+	if stats.Sessions > 0 {
+		response.BounceRate = float64(stats.Bounces) / float64(stats.Sessions)
+		response.PagesPerSession = float64(stats.PageViews) / float64(stats.Sessions)
+		response.AvgSessionDuration = stats.TotalDurationSecs / float64(stats.Sessions)
+	}
+
+	// 缓存结果（缓存10分钟）
+	if s.cacheService != nil {
+		_ = s.cacheService.CacheSessionStats(startDate, endDate, response, 10*time.Minute)
+	}
+
+	return response, nil
+}
+
+// maxIntPtr 取两个可空整数的较大值
+func maxIntPtr(a, b *int) *int {
+	if a == nil {
+		return b
+	}
+	if b == nil || *a >= *b {
+		return a
+	}
+	return b
+}
@@ -24,6 +24,7 @@ const (
 )
 
 // Hub 维护所有活跃的客户端连接和广播消息
+// 管理后台的连接接收任务更新广播；爬虫的连接（带爬虫Token）只接收下发给该Token的控制命令
 type Hub struct {
 	// 注册的客户端
 	clients map[*Client]bool
@@ -76,6 +77,10 @@ func (h *Hub) Run() {
 		case message := <-h.broadcast:
 			h.mu.RLock()
 			for client := range h.clients {
+				// 任务更新只广播给管理后台
+				if client.token != "" {
+					continue
+				}
 				select {
 				case client.send <- message:
 				default:
@@ -109,6 +114,47 @@ func (h *Hub) BroadcastTaskUpdate(task *models.CrawlTask) {
 	h.broadcast <- data
 }
 
+// SendTaskCommand 向持有任务的爬虫连接下发控制命令，返回收到命令的连接数
+// 爬虫未连接时返回0，命令仍保存在任务状态中，爬虫可在重连或上报状态时获取
+func (h *Hub) SendTaskCommand(token string, task *models.CrawlTask) int {
+	if token == "" {
+		return 0
+	}
+
+	message := TaskCommandMessage{
+		Type: "task_command",
+		Data: TaskCommandData{
+			TaskID:  task.TaskID,
+			Command: string(task.Status.PendingCommand()),
+			Status:  string(task.Status),
+		},
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return 0
+	}
+
+	sent := 0
+	h.mu.RLock()
+	for client := range h.clients {
+		if client.token != token {
+			continue
+		}
+		select {
+		case client.send <- data:
+			sent++
+		default:
+		}
+	}
+	h.mu.RUnlock()
+
+	return sent
+}
+
+// MessageHandler 处理客户端发来的消息（ping以外）
+type MessageHandler func(client *Client, message []byte)
+
 // Client 表示一个WebSocket客户端连接
 type Client struct {
 	hub *Hub
@@ -118,6 +164,12 @@ type Client struct {
 
 	// 发送消息的缓冲通道
 	send chan []byte
+
+	// 爬虫Token（管理后台的连接为空）
+	token string
+
+	// 消息处理函数
+	handler MessageHandler
 }
 
 // NewClient 创建新的客户端
@@ -129,6 +181,29 @@ func NewClient(hub *Hub, conn *websocket.Conn) *Client {
 	}
 }
 
+// NewCrawlerClient 创建爬虫控制通道的客户端
+func NewCrawlerClient(hub *Hub, conn *websocket.Conn, token string, handler MessageHandler) *Client {
+	return &Client{
+		hub:     hub,
+		conn:    conn,
+		send:    make(chan []byte, 256),
+		token:   token,
+		handler: handler,
+	}
+}
+
+// SendJSON 向客户端发送消息，发送队列已满时丢弃
+func (c *Client) SendJSON(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+	}
+}
+
 // ReadPump 从WebSocket连接读取消息
 func (c *Client) ReadPump() {
 	defer func() {
@@ -160,6 +235,8 @@ func (c *Client) ReadPump() {
 				pongMsg := map[string]string{"type": "pong"}
 				pongData, _ := json.Marshal(pongMsg)
 				c.send <- pongData
+			} else if c.handler != nil {
+				c.handler(c, message)
 			}
 		}
 	}
@@ -222,3 +299,43 @@ type TaskUpdateData struct {
 	Message   string    `json:"message"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// TaskCommandMessage 任务控制命令消息
+type TaskCommandMessage struct {
+	Type string          `json:"type"`
+	Data TaskCommandData `json:"data"`
+}
+
+// TaskCommandData 任务控制命令数据
+type TaskCommandData struct {
+	TaskID  string `json:"task_id"`
+	Command string `json:"command"` // pause / resume / cancel
+	Status  string `json:"status"`
+}
+
+// CommandAckMessage 爬虫确认控制命令的消息
+type CommandAckMessage struct {
+	Type string         `json:"type"` // command_ack
+	Data CommandAckData `json:"data"`
+}
+
+// CommandAckData 确认控制命令数据
+type CommandAckData struct {
+	TaskID  string `json:"task_id"`
+	Command string `json:"command"`
+}
+
+// CommandAckResultMessage 确认结果消息
+type CommandAckResultMessage struct {
+	Type string               `json:"type"` // command_ack_result
+	Data CommandAckResultData `json:"data"`
+}
+
+// CommandAckResultData 确认结果数据
+type CommandAckResultData struct {
+	TaskID  string `json:"task_id"`
+	Command string `json:"command"`
+	Success bool   `json:"success"`
+	Status  string `json:"status,omitempty"` // 确认后的任务状态
+	Error   string `json:"error,omitempty"`
+}
@@ -0,0 +1,41 @@
+-- 007_add_article_revisions.sql
+-- 添加文章修订历史
+
+-- 文章修订表
+CREATE TABLE IF NOT EXISTS article_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    article_id BIGINT NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) NOT NULL,
+    summary TEXT,
+    content TEXT NOT NULL,
+    cover_image VARCHAR(500),
+    category_id BIGINT,
+    tag_ids JSONB,
+    status VARCHAR(20) NOT NULL,
+    note VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(article_id, version)
+);
+
+COMMENT ON TABLE article_revisions IS '文章修订历史表';
+COMMENT ON COLUMN article_revisions.version IS '修订版本号（同一文章内递增）';
+COMMENT ON COLUMN article_revisions.action IS '触发快照的操作：create/update/publish/unpublish';
+COMMENT ON COLUMN article_revisions.tag_ids IS '快照时的标签ID列表（JSON数组）';
+COMMENT ON COLUMN article_revisions.note IS '修订备注（如恢复来源）';
+
+ALTER TABLE article_revisions ADD CONSTRAINT check_revision_action
+    CHECK (action IN ('create', 'update', 'publish', 'unpublish'));
+
+CREATE INDEX IF NOT EXISTS idx_article_revisions_article_id ON article_revisions(article_id, version DESC);
+
+-- 为已有文章生成初始版本，避免首次更新时丢失原内容
+INSERT INTO article_revisions (article_id, version, action, title, slug, summary, content, cover_image, category_id, tag_ids, status, note)
+SELECT a.id, 1, 'create', a.title, a.slug, a.summary, a.content, a.cover_image, a.category_id,
+       COALESCE((SELECT jsonb_agg(at.tag_id ORDER BY at.tag_id) FROM article_tags at WHERE at.article_id = a.id), '[]'::jsonb),
+       a.status, '迁移时生成的初始版本'
+FROM articles a
+WHERE a.deleted_at IS NULL
+ON CONFLICT (article_id, version) DO NOTHING;
@@ -0,0 +1,34 @@
+-- 008_add_comments.sql
+-- 基于留言表实现文章评论
+
+-- 扩展留言表为评论表
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS article_id BIGINT REFERENCES articles(id) ON DELETE CASCADE;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES messages(id) ON DELETE CASCADE;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS content TEXT NOT NULL DEFAULT '';
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'pending';
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45);
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS user_agent TEXT;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- 历史留言不属于任何文章，视为已审核
+UPDATE messages SET status = 'approved' WHERE article_id IS NULL;
+
+COMMENT ON TABLE messages IS '评论/留言表';
+COMMENT ON COLUMN messages.article_id IS '所属文章ID（为空表示历史留言）';
+COMMENT ON COLUMN messages.parent_id IS '父评论ID（为空表示顶级评论）';
+COMMENT ON COLUMN messages.content IS '评论内容（纯文本）';
+COMMENT ON COLUMN messages.status IS '审核状态：pending/approved/spam';
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS check_message_status;
+ALTER TABLE messages ADD CONSTRAINT check_message_status
+    CHECK (status IN ('pending', 'approved', 'spam'));
+
+-- 索引
+CREATE INDEX IF NOT EXISTS idx_messages_article_status ON messages(article_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_messages_parent_id ON messages(parent_id);
+CREATE INDEX IF NOT EXISTS idx_messages_status_created_at ON messages(status, created_at DESC);
+
+-- updated_at自动更新
+DROP TRIGGER IF EXISTS update_messages_updated_at ON messages;
+CREATE TRIGGER update_messages_updated_at BEFORE UPDATE ON messages
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,6 @@
+-- 009_add_robots_txt_config.sql
+-- 添加 robots.txt 默认配置（/robots.txt 读取 config_key = 'robots_txt'，未包含 Sitemap 行时自动追加）
+
+INSERT INTO system_configs (config_key, config_value, config_type, is_encrypted, description) VALUES
+('robots_txt', E'User-agent: *\nDisallow: /admin\nDisallow: /api/\n', 'robots_txt', FALSE, 'robots.txt 内容')
+ON CONFLICT (config_key) DO NOTHING;
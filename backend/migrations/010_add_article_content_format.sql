@@ -0,0 +1,19 @@
+-- 010_add_article_content_format.sql
+-- 文章支持Markdown：保存正文格式与原文，content 保存服务端渲染并清洗后的HTML
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content_format VARCHAR(20) NOT NULL DEFAULT 'html';
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content_source TEXT;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS toc JSONB;
+
+COMMENT ON COLUMN articles.content IS '正文HTML（服务端渲染并按白名单清洗）';
+COMMENT ON COLUMN articles.content_format IS '正文格式：html/markdown';
+COMMENT ON COLUMN articles.content_source IS 'Markdown原文（仅markdown格式）';
+COMMENT ON COLUMN articles.toc IS '目录（由正文标题生成的JSON数组）';
+
+ALTER TABLE articles DROP CONSTRAINT IF EXISTS check_article_content_format;
+ALTER TABLE articles ADD CONSTRAINT check_article_content_format
+    CHECK (content_format IN ('html', 'markdown'));
+
+-- 修订快照同样记录格式与原文，恢复时不会丢失Markdown
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS content_format VARCHAR(20) NOT NULL DEFAULT 'html';
+ALTER TABLE article_revisions ADD COLUMN IF NOT EXISTS content_source TEXT;
@@ -0,0 +1,38 @@
+-- 011_add_article_search_tokens.sql
+-- 全文检索改为使用应用层分词结果：应用写入分词后的 search_* 列，触发器据此生成 search_vector
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_title TEXT;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_summary TEXT;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_content TEXT;
+
+COMMENT ON COLUMN articles.search_title IS '分词后的标题（以空格分隔的词元）';
+COMMENT ON COLUMN articles.search_summary IS '分词后的摘要（以空格分隔的词元）';
+COMMENT ON COLUMN articles.search_content IS '分词后的正文纯文本（以空格分隔的词元）';
+
+-- 触发器函数：由分词列生成search_vector，尚未分词的文章退回原始字段
+CREATE OR REPLACE FUNCTION articles_search_trigger() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', COALESCE(NEW.search_title, NEW.title, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(NEW.search_summary, NEW.summary, '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(NEW.search_content, NEW.content, '')), 'C');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- 只在相关字段变化时重新计算（避免浏览量更新等操作重复分析正文）
+DROP TRIGGER IF EXISTS tsvector_update_trigger ON articles;
+CREATE TRIGGER tsvector_update_trigger
+BEFORE INSERT OR UPDATE OF title, summary, content, search_title, search_summary, search_content ON articles
+FOR EACH ROW EXECUTE FUNCTION articles_search_trigger();
+
+-- 重建检索分词时只修改分词列，不应改变文章的更新时间
+DROP TRIGGER IF EXISTS update_articles_updated_at ON articles;
+CREATE TRIGGER update_articles_updated_at BEFORE UPDATE ON articles
+    FOR EACH ROW
+    WHEN ((OLD.title, OLD.summary, OLD.content) IS DISTINCT FROM (NEW.title, NEW.summary, NEW.content)
+        OR (OLD.search_title, OLD.search_summary, OLD.search_content)
+            IS NOT DISTINCT FROM (NEW.search_title, NEW.search_summary, NEW.search_content))
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- 已有文章的分词由应用启动时补全（search_content IS NULL）
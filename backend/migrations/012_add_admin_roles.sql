@@ -0,0 +1,18 @@
+-- 012_add_admin_roles.sql
+-- 多管理员与角色权限：owner/editor/author/viewer
+
+-- 已有管理员（默认管理员）均为站长
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'owner';
+ALTER TABLE admins ALTER COLUMN role SET DEFAULT 'author';
+
+COMMENT ON COLUMN admins.role IS '角色：owner（站长）/editor（编辑）/author（作者）/viewer（只读）';
+
+ALTER TABLE admins DROP CONSTRAINT IF EXISTS check_admin_role;
+ALTER TABLE admins ADD CONSTRAINT check_admin_role
+    CHECK (role IN ('owner', 'editor', 'author', 'viewer'));
+
+CREATE INDEX IF NOT EXISTS idx_admins_role ON admins(role);
+
+-- 用户名只需在未删除的账号中唯一（删除的账号保留记录以维持文章作者关联）
+ALTER TABLE admins DROP CONSTRAINT IF EXISTS admins_username_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uk_admins_username_active ON admins(username) WHERE deleted_at IS NULL;
@@ -0,0 +1,10 @@
+-- 013_add_admin_two_factor.sql
+-- 管理员两步验证（TOTP，RFC 6238）
+
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS two_factor_secret VARCHAR(255);
+ALTER TABLE admins ADD COLUMN IF NOT EXISTS two_factor_recovery_codes JSONB;
+
+COMMENT ON COLUMN admins.two_factor_enabled IS '是否启用两步验证';
+COMMENT ON COLUMN admins.two_factor_secret IS 'TOTP密钥（AES-256-GCM加密），未启用时为待验证的密钥';
+COMMENT ON COLUMN admins.two_factor_recovery_codes IS '未使用的恢复码（SHA-256哈希数组），每个恢复码只能使用一次';
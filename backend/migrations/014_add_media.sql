@@ -0,0 +1,53 @@
+-- 014_add_media.sql
+-- 媒体库：记录上传文件的元数据及文章引用情况
+
+-- 媒体表
+CREATE TABLE IF NOT EXISTS media (
+    id BIGSERIAL PRIMARY KEY,
+    storage_key VARCHAR(500) NOT NULL UNIQUE,
+    url VARCHAR(500) NOT NULL,
+    original_name VARCHAR(255),
+    mime_type VARCHAR(100),
+    size BIGINT NOT NULL DEFAULT 0,
+    width INT NOT NULL DEFAULT 0,
+    height INT NOT NULL DEFAULT 0,
+    uploader_id BIGINT REFERENCES admins(id) ON DELETE SET NULL,
+    reference_count INT NOT NULL DEFAULT 0,
+    last_scanned_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE media IS '媒体库（上传的文件）';
+COMMENT ON COLUMN media.storage_key IS '存储后端中的对象key（如 2024/01/xxx.webp）';
+COMMENT ON COLUMN media.url IS '访问地址';
+COMMENT ON COLUMN media.original_name IS '上传时的原始文件名';
+COMMENT ON COLUMN media.uploader_id IS '上传者ID';
+COMMENT ON COLUMN media.reference_count IS '引用该文件的文章数（由引用扫描更新）';
+COMMENT ON COLUMN media.last_scanned_at IS '最近一次引用扫描时间';
+
+CREATE INDEX IF NOT EXISTS idx_media_created_at ON media(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_media_uploader_id ON media(uploader_id);
+CREATE INDEX IF NOT EXISTS idx_media_reference_count ON media(reference_count);
+
+DROP TRIGGER IF EXISTS update_media_updated_at ON media;
+CREATE TRIGGER update_media_updated_at BEFORE UPDATE ON media
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- 媒体引用表（由引用扫描整体重建）
+CREATE TABLE IF NOT EXISTS media_references (
+    media_id BIGINT NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    article_id BIGINT NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    field VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (media_id, article_id, field)
+);
+
+COMMENT ON TABLE media_references IS '媒体引用表（文章正文或封面引用的文件）';
+COMMENT ON COLUMN media_references.field IS '引用位置：content/cover';
+
+ALTER TABLE media_references DROP CONSTRAINT IF EXISTS check_media_reference_field;
+ALTER TABLE media_references ADD CONSTRAINT check_media_reference_field
+    CHECK (field IN ('content', 'cover'));
+
+CREATE INDEX IF NOT EXISTS idx_media_references_article_id ON media_references(article_id);
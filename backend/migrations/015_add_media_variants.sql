@@ -0,0 +1,6 @@
+-- 015_add_media_variants.sql
+-- 媒体库记录上传时生成的宽度变体（WebP及原图格式）
+
+ALTER TABLE media ADD COLUMN IF NOT EXISTS variants JSONB;
+
+COMMENT ON COLUMN media.variants IS '宽度变体列表（JSON数组：width/height/key/url/mime_type/size）';
@@ -0,0 +1,23 @@
+-- 016_add_crawl_results.sql
+-- 爬虫采集结果：任务注册时可声明结果的JSON Schema，结果按批次以NDJSON提交
+
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS result_schema JSONB;
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS result_count INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN crawl_tasks.result_schema IS '采集结果的JSON Schema（注册任务时声明，为空时不校验）';
+COMMENT ON COLUMN crawl_tasks.result_count IS '已提交的结果数';
+
+-- 采集结果表
+CREATE TABLE IF NOT EXISTS crawl_results (
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL REFERENCES crawl_tasks(id) ON DELETE CASCADE,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE crawl_results IS '爬虫采集结果表';
+COMMENT ON COLUMN crawl_results.task_id IS '所属任务ID（crawl_tasks.id）';
+COMMENT ON COLUMN crawl_results.data IS '结果数据（JSON对象）';
+
+CREATE INDEX IF NOT EXISTS idx_crawl_results_task_id ON crawl_results(task_id, id);
+CREATE INDEX IF NOT EXISTS idx_crawl_results_created_at ON crawl_results(created_at DESC);
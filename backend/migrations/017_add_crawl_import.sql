@@ -0,0 +1,18 @@
+-- 017_add_crawl_import.sql
+-- 采集结果导入草稿文章：任务上配置字段映射规则，任务完成后按规则导入，按来源URL去重
+
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS import_mapping JSONB;
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS import_summary JSONB;
+
+COMMENT ON COLUMN crawl_tasks.import_mapping IS '采集结果导入文章的字段映射规则（为空时不导入）';
+COMMENT ON COLUMN crawl_tasks.import_summary IS '最近一次导入的结果汇总';
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS source_url VARCHAR(1000);
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS crawl_task_id BIGINT REFERENCES crawl_tasks(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN articles.source_url IS '来源URL（从采集结果导入的文章，用于去重）';
+COMMENT ON COLUMN articles.crawl_task_id IS '导入来源的爬虫任务ID';
+
+-- 同一来源只导入一次（包括已软删除的文章，避免删除后被重新导入）
+CREATE UNIQUE INDEX IF NOT EXISTS idx_articles_source_url ON articles(source_url) WHERE source_url IS NOT NULL AND source_url <> '';
+CREATE INDEX IF NOT EXISTS idx_articles_crawl_task_id ON articles(crawl_task_id);
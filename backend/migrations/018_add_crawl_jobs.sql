@@ -0,0 +1,58 @@
+-- 018_add_crawl_jobs.sql
+-- 爬虫作业调度：管理员定义作业和cron表达式，每次运行生成待执行任务，由爬虫工作节点领取（租约+心跳）
+
+CREATE TABLE IF NOT EXISTS crawl_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    cron_spec VARCHAR(100) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    params JSONB,
+    result_schema JSONB,
+    import_mapping JSONB,
+    lease_seconds INT NOT NULL DEFAULT 300,
+    max_attempts INT NOT NULL DEFAULT 3,
+    allow_overlap BOOLEAN NOT NULL DEFAULT FALSE,
+    last_run_at TIMESTAMP,
+    created_by BIGINT REFERENCES admins(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE crawl_jobs IS '爬虫作业定义表';
+COMMENT ON COLUMN crawl_jobs.cron_spec IS 'cron表达式（秒 分 时 日 月 周，支持@every等描述符）';
+COMMENT ON COLUMN crawl_jobs.params IS '下发给爬虫的参数（作为任务的metadata）';
+COMMENT ON COLUMN crawl_jobs.lease_seconds IS '任务租约时长（秒），工作节点超过该时长未心跳则任务重新排队';
+COMMENT ON COLUMN crawl_jobs.max_attempts IS '任务最多领取次数，超过后标记为失败';
+COMMENT ON COLUMN crawl_jobs.allow_overlap IS '上次生成的任务未结束时是否仍生成新任务';
+
+ALTER TABLE crawl_jobs DROP CONSTRAINT IF EXISTS check_crawl_job_lease;
+ALTER TABLE crawl_jobs ADD CONSTRAINT check_crawl_job_lease
+    CHECK (lease_seconds > 0 AND max_attempts > 0);
+
+DROP TRIGGER IF EXISTS update_crawl_jobs_updated_at ON crawl_jobs;
+CREATE TRIGGER update_crawl_jobs_updated_at BEFORE UPDATE ON crawl_jobs
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- 任务增加待执行状态和租约信息
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS job_id BIGINT REFERENCES crawl_jobs(id) ON DELETE SET NULL;
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS worker_id VARCHAR(100);
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS lease_seconds INT;
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP;
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMP;
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN crawl_tasks.status IS '任务状态：pending=待执行，running=运行中，completed=已完成，failed=失败';
+COMMENT ON COLUMN crawl_tasks.job_id IS '生成任务的作业ID（爬虫自行注册的任务为空）';
+COMMENT ON COLUMN crawl_tasks.worker_id IS '领取任务的工作节点标识';
+COMMENT ON COLUMN crawl_tasks.lease_expires_at IS '租约到期时间，到期未心跳则重新排队';
+COMMENT ON COLUMN crawl_tasks.heartbeat_at IS '最近一次心跳时间';
+COMMENT ON COLUMN crawl_tasks.attempts IS '已领取次数';
+
+ALTER TABLE crawl_tasks DROP CONSTRAINT IF EXISTS check_task_status;
+ALTER TABLE crawl_tasks ADD CONSTRAINT check_task_status
+    CHECK (status IN ('pending', 'running', 'completed', 'failed'));
+
+CREATE INDEX IF NOT EXISTS idx_crawl_tasks_job_id ON crawl_tasks(job_id);
+CREATE INDEX IF NOT EXISTS idx_crawl_tasks_pending ON crawl_tasks(created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_crawl_tasks_lease ON crawl_tasks(lease_expires_at) WHERE status = 'running';
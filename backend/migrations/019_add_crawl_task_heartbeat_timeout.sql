@@ -0,0 +1,11 @@
+-- 019_add_crawl_task_heartbeat_timeout.sql
+-- 失联任务检测：运行中的任务超过心跳超时没有上报则标记为失败
+
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS heartbeat_timeout INT;
+
+COMMENT ON COLUMN crawl_tasks.heartbeat_timeout IS '心跳超时（秒），为空时使用全局配置';
+
+-- 已在运行的任务以最近更新时间作为初始心跳
+UPDATE crawl_tasks SET heartbeat_at = updated_at WHERE heartbeat_at IS NULL AND status = 'running';
+
+CREATE INDEX IF NOT EXISTS idx_crawl_tasks_heartbeat ON crawl_tasks(heartbeat_at) WHERE status = 'running';
@@ -0,0 +1,15 @@
+-- 020_add_crawl_task_control.sql
+-- 任务控制：管理员可取消、暂停、恢复任务，命令以任务状态保存，爬虫确认后进入目标状态
+--   pausing    -> paused     （暂停）
+--   resuming   -> running    （恢复）
+--   cancelling -> cancelled  （取消）
+
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS control_requested_at TIMESTAMP;
+ALTER TABLE crawl_tasks ADD COLUMN IF NOT EXISTS control_acked_at TIMESTAMP;
+
+COMMENT ON COLUMN crawl_tasks.control_requested_at IS '最近一次下发控制命令的时间';
+COMMENT ON COLUMN crawl_tasks.control_acked_at IS '爬虫最近一次确认控制命令的时间';
+
+ALTER TABLE crawl_tasks DROP CONSTRAINT IF EXISTS check_task_status;
+ALTER TABLE crawl_tasks ADD CONSTRAINT check_task_status
+    CHECK (status IN ('pending', 'running', 'pausing', 'paused', 'resuming', 'cancelling', 'cancelled', 'completed', 'failed'));
@@ -0,0 +1,32 @@
+-- 021_add_visit_daily_stats.sql
+-- 访问统计日汇总：每晚将已结束日期的访问记录汇总到日汇总表，统计接口对已结束日期读取汇总表，只对当天扫描访问记录
+
+CREATE TABLE IF NOT EXISTS visit_daily_stats (
+    stat_date DATE PRIMARY KEY,
+    pv BIGINT NOT NULL DEFAULT 0,
+    uv BIGINT NOT NULL DEFAULT 0,
+    stay_duration_sum BIGINT NOT NULL DEFAULT 0,
+    stay_duration_count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE visit_daily_stats IS '全站访问日汇总表（没有访问的日期也有一行，用于判断汇总进度）';
+COMMENT ON COLUMN visit_daily_stats.uv IS '当天去重的浏览器指纹数';
+COMMENT ON COLUMN visit_daily_stats.stay_duration_sum IS '当天上报的停留时间之和（秒）';
+COMMENT ON COLUMN visit_daily_stats.stay_duration_count IS '当天上报了停留时间的访问数';
+
+CREATE TABLE IF NOT EXISTS article_daily_stats (
+    stat_date DATE NOT NULL,
+    article_id BIGINT NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+    pv BIGINT NOT NULL DEFAULT 0,
+    uv BIGINT NOT NULL DEFAULT 0,
+    stay_duration_sum BIGINT NOT NULL DEFAULT 0,
+    stay_duration_count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (stat_date, article_id)
+);
+
+COMMENT ON TABLE article_daily_stats IS '文章访问日汇总表（只包含当天有访问的文章）';
+COMMENT ON COLUMN article_daily_stats.uv IS '当天访问该文章的去重浏览器指纹数';
+
+CREATE INDEX IF NOT EXISTS idx_article_daily_stats_article ON article_daily_stats(article_id, stat_date DESC);
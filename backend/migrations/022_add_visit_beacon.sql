@@ -0,0 +1,10 @@
+-- 022_add_visit_beacon.sql
+-- 访问离开信标：记录访问时生成访问标识返回给前端，页面离开时按标识回填停留时间和滚动深度
+
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS visit_key UUID;
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS scroll_depth SMALLINT;
+
+COMMENT ON COLUMN visits.visit_key IS '访问标识（记录访问时生成，离开信标按该标识更新）';
+COMMENT ON COLUMN visits.scroll_depth IS '最大滚动深度（百分比，0-100）';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_visits_visit_key ON visits(visit_key) WHERE visit_key IS NOT NULL;
@@ -0,0 +1,26 @@
+-- 023_add_client_info.sql
+-- 客户端信息：记录访问和采集指纹时解析User-Agent，写入浏览器、操作系统和设备类型
+-- 已有记录的device_type为NULL，服务启动后由后台任务用同一个解析器分批补齐（SQL无法复现解析规则）
+
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS browser VARCHAR(50);
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS browser_version VARCHAR(20);
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS os VARCHAR(50);
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS os_version VARCHAR(20);
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS device_type VARCHAR(10);
+
+COMMENT ON COLUMN visits.browser IS '浏览器（爬虫时为爬虫名称）';
+COMMENT ON COLUMN visits.browser_version IS '浏览器主版本号';
+COMMENT ON COLUMN visits.os IS '操作系统（Windows、macOS、iOS、Android、Linux等）';
+COMMENT ON COLUMN visits.device_type IS '设备类型：desktop、mobile、tablet、bot，为NULL表示尚未解析';
+
+ALTER TABLE fingerprints ADD COLUMN IF NOT EXISTS browser VARCHAR(50);
+ALTER TABLE fingerprints ADD COLUMN IF NOT EXISTS browser_version VARCHAR(20);
+ALTER TABLE fingerprints ADD COLUMN IF NOT EXISTS os VARCHAR(50);
+ALTER TABLE fingerprints ADD COLUMN IF NOT EXISTS os_version VARCHAR(20);
+ALTER TABLE fingerprints ADD COLUMN IF NOT EXISTS device_type VARCHAR(10);
+
+COMMENT ON COLUMN fingerprints.device_type IS '设备类型：desktop、mobile、tablet、bot，为NULL表示尚未解析';
+
+-- 补齐任务按ID分批查找未解析的记录
+CREATE INDEX IF NOT EXISTS idx_visits_unparsed_ua ON visits(id) WHERE device_type IS NULL;
+CREATE INDEX IF NOT EXISTS idx_fingerprints_unparsed_ua ON fingerprints(id) WHERE device_type IS NULL;
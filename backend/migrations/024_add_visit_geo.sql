@@ -0,0 +1,15 @@
+-- 024_add_visit_geo.sql
+-- 访问来源地区：记录访问时保存客户端IP（按配置截断），并用离线MaxMind数据库解析国家、省/州和城市
+-- 已有记录没有IP，不做补齐
+
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS ip VARCHAR(45);
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS country_code VARCHAR(2);
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS country VARCHAR(100);
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS region VARCHAR(100);
+ALTER TABLE visits ADD COLUMN IF NOT EXISTS city VARCHAR(100);
+
+COMMENT ON COLUMN visits.ip IS '客户端IP（开启截断时IPv4保留前24位，IPv6保留前48位）';
+COMMENT ON COLUMN visits.country_code IS '国家/地区代码（ISO 3166-1），未配置GeoIP数据库或无法识别时为空';
+COMMENT ON COLUMN visits.country IS '国家/地区';
+COMMENT ON COLUMN visits.region IS '省/州';
+COMMENT ON COLUMN visits.city IS '城市';
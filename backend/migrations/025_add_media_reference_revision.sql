@@ -0,0 +1,9 @@
+-- 025_add_media_reference_revision.sql
+-- 媒体引用增加修订快照：历史修订可以恢复，其中引用的文件不能作为未引用文件清理
+
+COMMENT ON TABLE media_references IS '媒体引用表（文章正文、封面或修订快照引用的文件）';
+COMMENT ON COLUMN media_references.field IS '引用位置：content/cover/revision';
+
+ALTER TABLE media_references DROP CONSTRAINT IF EXISTS check_media_reference_field;
+ALTER TABLE media_references ADD CONSTRAINT check_media_reference_field
+    CHECK (field IN ('content', 'cover', 'revision'));
@@ -0,0 +1,32 @@
+-- 026_add_visit_period_stats.sql
+-- 访问统计周期汇总：同一访客在一周/一月内多天访问只算一次，周期UV不能由日汇总相加得到，周期结束后按访问记录去重写入汇总表
+
+CREATE TABLE IF NOT EXISTS visit_period_stats (
+    period_type VARCHAR(10) NOT NULL,
+    period_key VARCHAR(10) NOT NULL,
+    uv BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (period_type, period_key),
+    CONSTRAINT check_visit_period_type CHECK (period_type IN ('weekly', 'monthly'))
+);
+
+COMMENT ON TABLE visit_period_stats IS '全站访问周期汇总表（只包含已结束且有访问的周期）';
+COMMENT ON COLUMN visit_period_stats.period_type IS '周期类型：weekly/monthly';
+COMMENT ON COLUMN visit_period_stats.period_key IS '周期标识：周为YYYY-"W"WW（按当年1月1日起每7天一周），月为YYYY-MM';
+COMMENT ON COLUMN visit_period_stats.uv IS '周期内去重的浏览器指纹数';
+
+-- 为已结束的周期补齐汇总
+INSERT INTO visit_period_stats (period_type, period_key, uv, updated_at)
+SELECT 'weekly', TO_CHAR(visit_time::date, 'YYYY-"W"WW'), COUNT(DISTINCT fingerprint_id), NOW()
+FROM visits
+WHERE visit_time < CURRENT_DATE
+    AND TO_CHAR(visit_time::date, 'YYYY-"W"WW') <> TO_CHAR(CURRENT_DATE, 'YYYY-"W"WW')
+GROUP BY 2
+ON CONFLICT (period_type, period_key) DO NOTHING;
+
+INSERT INTO visit_period_stats (period_type, period_key, uv, updated_at)
+SELECT 'monthly', TO_CHAR(visit_time::date, 'YYYY-MM'), COUNT(DISTINCT fingerprint_id), NOW()
+FROM visits
+WHERE visit_time < DATE_TRUNC('month', CURRENT_DATE)
+GROUP BY 2
+ON CONFLICT (period_type, period_key) DO NOTHING;
@@ -17,11 +17,29 @@ export default {
   },
 
   /**
-   * 登出（前端清除token）
+   * 两步验证登录
+   * @param {string} challengeToken - 登录接口返回的挑战Token
+   * @param {string} code - 6位验证码或恢复码
+   */
+  loginTwoFactor(challengeToken, code) {
+    return http.post('/auth/login/2fa', {
+      challenge_token: challengeToken,
+      code
+    })
+  },
+
+  /**
+   * 登出（吊销当前会话）
    */
   logout() {
-    // 后端不需要登出接口，只需要清除本地token
-    return Promise.resolve()
+    return http.post('/auth/logout')
+  },
+
+  /**
+   * 退出所有设备上的会话
+   */
+  logoutAll() {
+    return http.post('/auth/logout-all')
   },
 
   /**
@@ -44,10 +62,13 @@ export default {
   },
 
   /**
-   * 刷新Token
+   * 刷新Token（刷新Token每次使用后轮换）
+   * @param {string} refreshToken - 刷新Token
    */
-  refreshToken() {
-    return http.post('/auth/refresh')
+  refreshToken(refreshToken) {
+    return http.post('/auth/refresh', {
+      refresh_token: refreshToken
+    })
   }
 }
 
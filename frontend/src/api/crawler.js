@@ -9,7 +9,7 @@ export default {
    * @param {Object} params - 查询参数
    * @param {number} params.page - 页码
    * @param {number} params.page_size - 每页数量
-   * @param {string} params.status - 状态筛选 (running/completed/failed)
+   * @param {string} params.status - 状态筛选 (pending/running/pausing/paused/resuming/cancelling/cancelled/completed/failed)
    * @param {string} params.task_id - 任务ID
    */
   getTasks(params = {}) {
@@ -22,6 +22,15 @@ export default {
    */
   getTaskById(taskId) {
     return http.get(`/admin/crawler/tasks/${taskId}`)
+  },
+
+  /**
+   * 下发控制命令（管理员），爬虫确认后任务进入暂停、运行或取消状态
+   * @param {string|number} taskId - 任务ID或数字ID
+   * @param {string} command - 命令 (pause/resume/cancel)
+   */
+  sendCommand(taskId, command) {
+    return http.post(`/admin/crawler/tasks/${taskId}/command`, { command })
   }
 }
 
@@ -40,6 +40,48 @@ export default {
    */
   getReferrerStats(params = {}) {
     return http.get('/admin/stats/referrers', { params })
+  },
+
+  /**
+   * 获取会话统计（跳出率、每个会话的页面数、入口/退出页面）
+   * @param {Object} params - 查询参数
+   * @param {string} params.start_date - 开始日期 (YYYY-MM-DD)
+   * @param {string} params.end_date - 结束日期 (YYYY-MM-DD)
+   */
+  getSessionStats(params = {}) {
+    return http.get('/admin/stats/sessions', { params })
+  },
+
+  /**
+   * 获取客户端分布概览（浏览器、操作系统、设备类型）
+   * @param {Object} params - 查询参数
+   * @param {string} params.start_date - 开始日期 (YYYY-MM-DD)
+   * @param {string} params.end_date - 结束日期 (YYYY-MM-DD)
+   * @param {number} params.limit - 每个维度返回的数量
+   */
+  getClientStats(params = {}) {
+    return http.get('/admin/stats/clients', { params })
+  },
+
+  /**
+   * 获取某个维度的客户端分布
+   * @param {string} dimension - browser / browser_version / os / os_version / device_type
+   * @param {Object} params - 查询参数（同 getClientStats）
+   */
+  getClientBreakdown(dimension, params = {}) {
+    return http.get(`/admin/stats/clients/${dimension}`, { params })
+  },
+
+  /**
+   * 获取访问地区分布（国家/地区、省/州、城市）
+   * @param {Object} params - 查询参数
+   * @param {string} params.start_date - 开始日期 (YYYY-MM-DD)
+   * @param {string} params.end_date - 结束日期 (YYYY-MM-DD)
+   * @param {string} params.country - 国家/地区代码，指定时省/州和城市只统计该国家/地区
+   * @param {number} params.limit - 每个层级返回的数量
+   */
+  getGeoStats(params = {}) {
+    return http.get('/admin/stats/geo', { params })
   }
 }
 
@@ -9,6 +9,26 @@ export default {
    */
   recordVisit(data) {
     return http.post('/visit', data)
+  },
+
+  /**
+   * 上报离开信标（停留时间、最大滚动深度），页面卸载时也能送达
+   * @param {string} visitId - 记录访问时返回的 visit_id
+   * @param {Object} data - { stay_duration, scroll_depth }
+   */
+  sendBeacon(visitId, data) {
+    const url = `/api/v1/visit/${visitId}/beacon`
+    const body = JSON.stringify(data)
+    // sendBeacon 只能发送POST，服务端同时接受PUT和POST
+    if (navigator.sendBeacon && navigator.sendBeacon(url, body)) {
+      return
+    }
+    fetch(url, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body,
+      keepalive: true
+    }).catch(() => {}) // 忽略错误
   }
 }
 
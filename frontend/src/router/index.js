@@ -21,6 +21,18 @@ const routes = [
         component: () => import('@/views/Articles.vue'),
         meta: { titleKey: 'nav.articles' }
       },
+      {
+        path: 'categories/:slug',
+        name: 'CategoryArticles',
+        component: () => import('@/views/Articles.vue'),
+        meta: { titleKey: 'nav.articles' }
+      },
+      {
+        path: 'tags/:slug',
+        name: 'TagArticles',
+        component: () => import('@/views/Articles.vue'),
+        meta: { titleKey: 'nav.articles' }
+      },
       {
         path: 'article/:slug',
         name: 'ArticleDetail',
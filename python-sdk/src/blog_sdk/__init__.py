@@ -2,7 +2,7 @@
 
 __version__ = "1.0.0"
 
-from .crawler import Crawler, TaskReporter, TaskStatus
+from .crawler import Crawler, TaskReporter, TaskStatus, TaskCommand
 from .monitor import Monitor
 from .utils import HTTPClient, Config
 
@@ -10,6 +10,7 @@ __all__ = [
     "Crawler",
     "TaskReporter",
     "TaskStatus",
+    "TaskCommand",
     "Monitor",
     "HTTPClient",
     "Config",
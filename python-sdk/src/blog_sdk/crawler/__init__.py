@@ -1,7 +1,7 @@
 """Web Crawler Module"""
 
 from .crawler import Crawler
-from .task_reporter import TaskReporter, TaskStatus
+from .task_reporter import TaskReporter, TaskStatus, TaskCommand
 
-__all__ = ["Crawler", "TaskReporter", "TaskStatus"]
+__all__ = ["Crawler", "TaskReporter", "TaskStatus", "TaskCommand"]
 
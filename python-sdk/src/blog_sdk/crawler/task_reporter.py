@@ -1,17 +1,43 @@
 """Task Reporter for Crawler Tasks"""
 
+import json
 import requests
 from enum import Enum
-from typing import Optional, Dict, Any
+from typing import Optional, Dict, Any, Iterable, List
 from loguru import logger
 from ..utils.http_client import HTTPClient
 
 
 class TaskStatus(str, Enum):
     """Crawl Task Status"""
+    PENDING = "pending"
     RUNNING = "running"
     COMPLETED = "completed"
     FAILED = "failed"
+    PAUSING = "pausing"
+    PAUSED = "paused"
+    RESUMING = "resuming"
+    CANCELLING = "cancelling"
+    CANCELLED = "cancelled"
+
+
+class TaskCommand(str, Enum):
+    """Crawl Task Control Command"""
+    PAUSE = "pause"
+    RESUME = "resume"
+    CANCEL = "cancel"
+
+
+# Statuses that carry a command waiting for the crawler to acknowledge it
+_PENDING_COMMANDS = {
+    TaskStatus.PAUSING.value: TaskCommand.PAUSE,
+    TaskStatus.RESUMING.value: TaskCommand.RESUME,
+    TaskStatus.CANCELLING.value: TaskCommand.CANCEL,
+}
+
+
+# Maximum number of results the API accepts per batch
+MAX_RESULTS_PER_BATCH = 1000
 
 
 class TaskReporter:
@@ -42,6 +68,9 @@ class TaskReporter:
         task_id: str,
         task_name: str,
         metadata: Optional[Dict[str, Any]] = None,
+        result_schema: Optional[Dict[str, Any]] = None,
+        import_mapping: Optional[Dict[str, Any]] = None,
+        heartbeat_timeout: Optional[int] = None,
     ) -> Dict[str, Any]:
         """
         Register a new crawler task
@@ -50,6 +79,12 @@ class TaskReporter:
             task_id: Unique task identifier
             task_name: Task name
             metadata: Optional metadata dictionary
+            result_schema: Optional JSON Schema that every submitted result must match
+            import_mapping: Optional rules mapping result fields to article fields
+                (title_field, summary_field, content_field, tags_field, category_field, ...);
+                results are imported as draft articles when the task completes
+            heartbeat_timeout: Optional seconds without status updates, results or
+                heartbeats after which the server marks the task as failed
             
         Returns:
             Task data from API response
@@ -64,6 +99,12 @@ class TaskReporter:
         }
         if metadata:
             payload["metadata"] = metadata
+        if result_schema:
+            payload["result_schema"] = result_schema
+        if import_mapping:
+            payload["import_mapping"] = import_mapping
+        if heartbeat_timeout:
+            payload["heartbeat_timeout"] = heartbeat_timeout
         
         try:
             response = self.client.post(url, json=payload)
@@ -77,6 +118,180 @@ class TaskReporter:
             logger.error(f"Failed to register task {task_id}: {e}")
             raise
     
+    def claim_task(
+        self,
+        worker_id: str,
+        job_ids: Optional[List[int]] = None,
+    ) -> Optional[Dict[str, Any]]:
+        """
+        Claim the oldest pending task created by a scheduled crawl job
+        
+        The claimed task is leased to this worker; call heartbeat() before
+        the lease expires, otherwise the task is requeued for another worker.
+        
+        Args:
+            worker_id: Identifier of this worker
+            job_ids: Only claim tasks of these jobs (all jobs if omitted)
+            
+        Returns:
+            Claimed task data (its metadata holds the job parameters),
+            or None if no task is pending
+            
+        Raises:
+            requests.RequestException: If request fails
+        """
+        url = "/api/v1/crawler/tasks/claim"
+        payload: Dict[str, Any] = {"worker_id": worker_id}
+        if job_ids:
+            payload["job_ids"] = job_ids
+        
+        try:
+            response = self.client.post(url, json=payload)
+            data = response.json()
+            if data.get("code") == 0:
+                task = data.get("data")
+                if task:
+                    logger.info(f"Task claimed: {task.get('task_id')} by {worker_id}")
+                return task
+            raise Exception(f"Failed to claim task: {data.get('message', 'Unknown error')}")
+        except requests.RequestException as e:
+            logger.error(f"Failed to claim task for worker {worker_id}: {e}")
+            raise
+    
+    def heartbeat(
+        self,
+        task_id: str,
+        worker_id: Optional[str] = None,
+        progress: Optional[int] = None,
+        message: Optional[str] = None,
+    ) -> bool:
+        """
+        Renew the lease of a claimed task
+        
+        Args:
+            task_id: Task identifier
+            worker_id: Identifier of this worker (recommended when workers share a token)
+            progress: Optional progress percentage (0-100)
+            message: Optional status message
+            
+        Returns:
+            True if the lease was renewed, False if it was lost
+            (the task was requeued or claimed by another worker) and
+            the worker should stop processing the task
+            
+        Raises:
+            requests.RequestException: If request fails
+        """
+        url = f"/api/v1/crawler/tasks/{task_id}/heartbeat"
+        payload: Dict[str, Any] = {}
+        if worker_id:
+            payload["worker_id"] = worker_id
+        if progress is not None:
+            payload["progress"] = progress
+        if message:
+            payload["message"] = message
+        
+        try:
+            response = self.client.put(url, json=payload)
+            data = response.json()
+            if data.get("code") == 0:
+                return True
+            raise Exception(f"Failed to send heartbeat: {data.get('message', 'Unknown error')}")
+        except requests.HTTPError as e:
+            if e.response is not None and e.response.status_code == 409:
+                logger.warning(f"Task lease lost: {task_id}")
+                return False
+            logger.error(f"Failed to send heartbeat for {task_id}: {e}")
+            raise
+        except requests.RequestException as e:
+            logger.error(f"Failed to send heartbeat for {task_id}: {e}")
+            raise
+    
+    @staticmethod
+    def pending_command(task: Optional[Dict[str, Any]]) -> Optional[TaskCommand]:
+        """
+        Get the control command waiting in a task returned by the API
+        
+        Args:
+            task: Task data, e.g. returned by update_status() or check_command()
+            
+        Returns:
+            The command to carry out and acknowledge, or None
+        """
+        if not task:
+            return None
+        return _PENDING_COMMANDS.get(task.get("status"))
+    
+    def check_command(
+        self,
+        task_id: str,
+        worker_id: Optional[str] = None,
+    ) -> Optional[TaskCommand]:
+        """
+        Send a heartbeat and return the control command waiting for this task
+        
+        Keep calling this while the task is paused so it is not treated as lost.
+        
+        Args:
+            task_id: Task identifier
+            worker_id: Identifier of this worker (recommended when workers share a token)
+            
+        Returns:
+            The command to carry out and acknowledge, or None
+            
+        Raises:
+            requests.RequestException: If request fails (HTTP 409 when the lease is lost)
+        """
+        url = f"/api/v1/crawler/tasks/{task_id}/heartbeat"
+        payload: Dict[str, Any] = {}
+        if worker_id:
+            payload["worker_id"] = worker_id
+        
+        try:
+            response = self.client.put(url, json=payload)
+            data = response.json()
+            if data.get("code") == 0:
+                return self.pending_command(data.get("data"))
+            raise Exception(f"Failed to check command: {data.get('message', 'Unknown error')}")
+        except requests.RequestException as e:
+            logger.error(f"Failed to check command for {task_id}: {e}")
+            raise
+    
+    def acknowledge_command(
+        self,
+        task_id: str,
+        command: TaskCommand,
+    ) -> Dict[str, Any]:
+        """
+        Acknowledge a control command after carrying it out
+        
+        The task moves to paused, running or cancelled respectively.
+        
+        Args:
+            task_id: Task identifier
+            command: The acknowledged command
+            
+        Returns:
+            Updated task data from API response
+            
+        Raises:
+            requests.RequestException: If request fails (HTTP 409 when the
+                command was replaced by a newer one)
+        """
+        url = f"/api/v1/crawler/tasks/{task_id}/ack"
+        payload = {"command": TaskCommand(command).value}
+        
+        try:
+            response = self.client.put(url, json=payload)
+            data = response.json()
+            if data.get("code") == 0:
+                logger.info(f"Command acknowledged: {task_id} -> {payload['command']}")
+                return data.get("data", {})
+            raise Exception(f"Failed to acknowledge command: {data.get('message', 'Unknown error')}")
+        except requests.RequestException as e:
+            logger.error(f"Failed to acknowledge command for {task_id}: {e}")
+            raise
+    
     def update_status(
         self,
         task_id: str,
@@ -123,6 +338,64 @@ class TaskReporter:
             logger.error(f"Failed to update task status for {task_id}: {e}")
             raise
     
+    def submit_results(
+        self,
+        task_id: str,
+        results: Iterable[Dict[str, Any]],
+        batch_size: int = MAX_RESULTS_PER_BATCH,
+    ) -> int:
+        """
+        Submit crawl results as NDJSON batches
+        
+        Each batch is validated against the task's result schema on the server;
+        a batch containing any invalid result is rejected as a whole.
+        
+        Args:
+            task_id: Task identifier
+            results: Result objects (one JSON object per result)
+            batch_size: Results per request (at most 1000)
+            
+        Returns:
+            Number of results accepted
+            
+        Raises:
+            requests.RequestException: If request fails
+            ValueError: If batch_size is out of range
+        """
+        if not 0 < batch_size <= MAX_RESULTS_PER_BATCH:
+            raise ValueError(f"batch_size must be between 1 and {MAX_RESULTS_PER_BATCH}")
+        
+        url = f"/api/v1/crawler/tasks/{task_id}/results"
+        accepted = 0
+        batch = []
+        
+        def flush() -> int:
+            body = "\n".join(json.dumps(item, ensure_ascii=False) for item in batch) + "\n"
+            try:
+                response = self.client.post(
+                    url,
+                    data=body.encode("utf-8"),
+                    headers={"Content-Type": "application/x-ndjson"},
+                )
+                data = response.json()
+                if data.get("code") == 0:
+                    return data.get("data", {}).get("accepted", 0)
+                raise Exception(f"Failed to submit results: {data.get('message', 'Unknown error')}")
+            except requests.RequestException as e:
+                logger.error(f"Failed to submit results for {task_id}: {e}")
+                raise
+        
+        for item in results:
+            batch.append(item)
+            if len(batch) >= batch_size:
+                accepted += flush()
+                batch = []
+        if batch:
+            accepted += flush()
+        
+        logger.debug(f"Results submitted: {task_id} ({accepted})")
+        return accepted
+    
     def complete_task(
         self,
         task_id: str,